@@ -4,7 +4,7 @@
 // That is, an index for 1 million points requires approximately 40Mb in the heap.
 //
 // To achieve this speed, the index has three restrictions. It is static, once built it cannot be changed.
-// It only accepts points coordinates, no bboxes, lines or ids. And it only accepts (for now) one query, closest point to a given coordinate.
+// It only accepts points coordinates, no bboxes, lines or ids. And it only accepts (for now) a few queries, closest point to a given coordinate, points within a distance and points within a bbox.
 //
 // Beware, to achieve top performance one of the hot functions has been rewritten in assembly.
 // Library works in x86 but it probably won't work in other architectures. PRs are welcome to fix this deficiency.
@@ -48,12 +48,10 @@
 package SimpleRTree
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
-	"log"
 	"math"
-	"strings"
-	"text/template"
+	"os"
 	"unsafe"
 	"sync"
 	"sort"
@@ -66,10 +64,32 @@ type SimpleRTree struct {
 	options Options
 	nodes   []rNode
 	points  FlatPoints
+	source  Interface // points as provided to LoadInterface, nil when the tree was built from FlatPoints
 	built   bool
 	queuePool         sync.Pool
 	unsafeQueue         searchQueue // Only used in unsafe mode
 	sorterBuffer      []int // floyd rivest requires a bucket, we allocate it once and reuse
+	height            int // number of levels of nodes, the level of the points is not included
+	sortKey           func(x, y float64) uint64 // if set points are sorted by it and packed sequentially
+	progressDone      int // points placed in leaves during the current build, only tracked if Options.Progress is set
+	insideFactor      float64 // 1 + Options.InsideEpsilon, see computeDistances
+	queryAspectRatio  float64 // Options.QueryAspectRatio, 1 if not set
+	centroids         []float64 // x and y of the mean of the points under each node, only set with Options.StoreCentroids
+	hilbertValues     []uint64 // Hilbert value of each point, only set with Options.StoreHilbertValues
+	orientedBoxes     []orientedBox // oriented box of each leaf, only set with Options.OrientedLeaves
+	circles           []boundingCircle // minimum bounding circle of each node, only set with Options.BoundingCircles
+	leafPoints        FlatPoints // copy of the points of trees built over an Interface, in the order of the leaves, only set with Options.LeafOrderCopy
+	customQueuePool   sync.Pool // queues created with Options.NewQueue
+	unsafeCustomQueue Queue // Only used in unsafe mode with Options.NewQueue
+	cache             *queryCache // nil unless Options.QueryCache is set
+	disabled          []bool // points skipped by nearest queries, nil until SetEnabled disables one
+	nDisabled         int
+	loads             []float64 // loads set with SetLoad, nil until one is set
+	minLoad           []float64 // minimum load of the points under each node, nil as loads
+	maxRadius         []float64 // for trees loaded with LoadDiscs, the largest radius under each node
+	groups            []indexRuns // for trees loaded with LoadGrouped, indexes in the caller's array of the points at each position
+	attrs             map[string][]float64 // for trees loaded with LoadWithAttrs, values of each attribute at each position
+	timestamps        []float64 // for trees loaded with LoadWithTimestamps, timestamp of each position
 }
 
 // FlatPoints is the input format for coordinates
@@ -81,6 +101,27 @@ type SimpleRTree struct {
 // will return wrong results if the elements are modified
 type FlatPoints []float64
 
+// Result is a point returned by a query.
+// Index is the position of the point in the points given to Load. Load reorders them, so the index refers to the
+// order after the build, that is points.GetPointAt(Index) == (X, Y).
+// Distance is the distance squared from the query coordinates to the point
+type Result struct {
+	Index    int
+	X, Y     float64
+	Distance float64
+}
+
+// Interface is the set of methods a collection of points needs to provide to be indexed with LoadInterface.
+// FlatPoints implements it, but it is better to pass them to Load directly, since queries read them from memory
+// without going through the interface.
+//
+// Note: as with FlatPoints, rtree is assumed to have sole access to the collection, it will swap its elements
+type Interface interface {
+	Len() int
+	Swap(i, j int)
+	GetPointAt(i int) (x, y float64)
+}
+
 type TreeType uint8
 
 const (
@@ -95,6 +136,29 @@ type Options struct {
 	MAX_ENTRIES int
 	TreeType TreeType
 	RTreePool *sync.Pool // If a lot of RTrees are being created you can provide a pool to the tree. On destroy the underlying memory space will be saved back to the pool, so next tree can use it
+	MortonLeaves bool // Reorder the points within each leaf in Morton (z-order) after the build, so that leaf scans of range queries access memory with better locality
+	Progress func(done, total int) // Called during the build with the number of points already placed in leaves. It is called roughly every 1% of the points and always on completion, with done == total
+	InsideEpsilon float64 // Relative tolerance to consider coordinates inside a bbox when computing distances to it, so that floating errors do not classify a query just inside the boundary as outside, which adds the tiny distance to the side to the bound. Zero, the default, and negative values compare exactly. Treating coordinates as inside only lowers the distances to bboxes, so results stay correct but pruning gets looser and queries may visit more nodes, 1e-12 is enough for rounding errors
+	NewQueue func() Queue // Creates the priority queues used by FindNearestPoint and FindNearestPointWithin instead of the default one. Queues are reused across queries
+	QueryCache int // Number of FindNearestPoint results to keep in a least recently used cache, zero disables it. Meant for workloads repeating the same queries. The cache is cleared by Rebuild, RebuildRegion, RecomputeBBoxes and SetEnabled, the mutations that change its results
+	QueryCacheQuantum float64 // If set, FindNearestPoint rounds the coordinates to multiples of it before searching and caching, so that close queries share results. Returned points are then the closest to the rounded coordinates, distance is still computed from the original ones
+	RobustDistance bool // Compute the distance reported by FindNearestPoint and FindNearestPointWithin rounding only once, avoiding the precision lost by squaring large differences. Bounds and comparisons during the search are not affected, it only adds a few operations per query
+	QueryAspectRatio float64 // Expected width / height of the boxes of range queries, zero means square. STR tiles get the same aspect ratio, which minimizes the number of tiles a query overlaps: wide queries get fewer x slices with more nodes each. Only used by STR trees
+	DownsampleRule DownsampleRule // Representative of each cell in LoadDownsampled, the first point by default
+	StoreCentroids bool // Compute the centroid of every node during the build, the mean of the points under it. It takes 16 bytes per node. Centroids are returned by CellCounts and used as the representative of the nodes in FindNearestApproxDepth
+	LinearScanThreshold int // Trees with fewer points are not built: the points keep their order under a single leaf, and FindNearestPoint, FindNearestPointWithin and Search scan them all, which is faster than building and traversing a tiny tree. BenchmarkSimpleRTree_LinearScan puts the crossover of queries between 32 and 64 points, loads from 16 points. Zero, the default, and negative values always build the tree. It cannot exceed 128, the points of a leaf
+	DistanceUnit DistanceUnit // Unit of the distances of geographic queries such as FindNearestGeo, meters by default. HaversineComparison returns a cheaper value that is only meant to compare distances
+	StoreHilbertValues bool // Compute the value of every point along a Hilbert curve over the bbox of the points during the build, see HilbertValue and HilbertOrder. It takes 8 bytes per point
+	BalancedLeaves bool // Split the points of each STR node evenly between its children, so that leaves hold about the same number of points. By default children are filled with whole subtrees and the remainder ends up in the last ones, which can get very few points. Balanced leaves make the work per query more predictable, at the cost of less full nodes, slightly more of them, and boxes a bit less tight. Only used by STR trees
+	VerifyBBoxes bool // Check after every build that the bbox of each node, computed with the vectorized VectorBBoxExtend, matches a plain scalar computation over its points. Load panics and Rebuild and RebuildRegion return an error wrapping ErrBBoxMismatch if they differ. It is a safety net against miscompiled or wrong accelerated code, the check costs one more pass over the points
+	VerifyAgainstBruteForce bool // Check the result of every nearest point query, FindNearestPoint, FindNearestPointWithin and the queries built on them, against a scan of all the points and panic if they disagree. It is meant for debugging only, to catch pruning bugs as soon as they happen: every query costs a full scan of the points
+	WithinEpsilon float64 // Tolerance for points lying on the boundary of FindPointsWithin. Positive values include points up to d + WithinEpsilon, negative values only points strictly closer than d + WithinEpsilon
+	EmptyResults EmptyResults // How FindNearestPoints reports queries that find no point: a sentinel Result with Index -1 by default, left out with EmptyResultsSkip, or a sentinel plus a mask of the queries that found a point with EmptyResultsMask
+	OrientedLeaves bool // Compute for every leaf a rectangle aligned with the principal axis of its points, interior nodes keep their axis aligned bbox. FindNearestPoint, FindNearestPointWithin and Search prune leaves with it, which is much tighter than the bbox for points along diagonal lines, such as roads or tracks. It takes 48 bytes per node and nearest queries go through a slower generic traversal, so it only pays off for such data
+	BoundingCircles bool // Compute for every node the smallest circle containing its points. FindNearestPoint and FindNearestPointWithin prune nodes with the largest of the distances to their bbox and to their circle, which is tighter than the bbox alone for queries near the corners of boxes around round clusters. It takes 24 bytes per node and a pass over the points per level at build time, and the extra distance per node makes queries slower in practice unless the circles prune many more nodes than the boxes, see benchmarks.md. With disabled points or Options.NewQueue nearest queries also go through a slower generic traversal
+	MaxHeight int // Maximum number of levels of nodes, zero means no limit. Trees that would be higher get larger leaves instead, holding as many points as needed to fit, which bounds the depth of queries at the cost of scanning more points per leaf. Leaves hold at most 127 points, Load panics and Rebuild returns an error wrapping ErrMaxHeightTooLow if the points do not fit. Hilbert trees always have at least 2 levels
+	MaxBuildMemory int // Maximum number of bytes a build may allocate on top of the points, zero means no limit. When set, nodes are allocated to their exact number instead of one per point, which is the largest allocation of the build, and Load panics and Rebuild returns an error wrapping ErrBuildMemoryExceeded if the nodes and the data of the other options do not fit. Points are sorted in place, so this bounds all the memory of the build. STR builds over FlatPoints larger than MaxBuildMemory first sort them with an external merge sort, in runs of MaxBuildMemory bytes spilled to a temp file and merged back through buffers of as many bytes in total, so the points are read and written sequentially, and build the same tree, up to how points with equal x are split between nodes; Load panics and Rebuild returns an error if the temp file fails. The exact count is not known upfront for STR trees with QueryAspectRatio, they allocate one node per point
+	LeafOrderCopy bool // Keep a flat copy of the coordinates of trees loaded with LoadInterface, in the order of the leaves, and read points from it instead of the interface, so that range and radius queries scan contiguous memory without a method call per point. It costs 16 bytes per point on top of the collection. Trees built from FlatPoints already store their points in that order and ignore it
 }
 
 type rNode struct {
@@ -103,8 +167,18 @@ type rNode struct {
 	// Here we save firstChild - firstNode. That means that there is there is a theoretical upper limit to the tree of
 	// maxuint32 / node_size = 4294967295 / 40 = 107374182 ~ 100M
 	firstChildOffset uint32
-	BBox             rVectorBBox
+	BBox             VectorBBox
+}
+// firstChildIndex returns the position in nodes of the first child of a default node
+func (n *rNode) firstChildIndex() int {
+	return int(n.firstChildOffset / uint32(node_size))
 }
+
+// firstPointIndex returns the position in points of the first point of a preleaf node
+func (n *rNode) firstPointIndex() int {
+	return int(n.firstChildOffset / uint32(flat_point_size))
+}
+
 type nodeType int8
 const (
 	default_node = iota
@@ -141,11 +215,27 @@ func NewWithOptions(o Options) *SimpleRTree {
 		options: o,
 	}
 	if o.MAX_ENTRIES > MAX_POSSIBLE_SIZE {
-		panic(fmt.Sprintf("Cannot exceed %d for size", MAX_POSSIBLE_SIZE))
+		panic(fmt.Errorf("%w: cannot exceed %d for size", ErrInvalidMaxEntries, MAX_POSSIBLE_SIZE))
 	}
 	if o.MAX_ENTRIES == 0 {
 		r.options.MAX_ENTRIES = MAX_POSSIBLE_SIZE
 	}
+	r.queryAspectRatio = 1
+	if o.QueryAspectRatio > 0 {
+		r.queryAspectRatio = o.QueryAspectRatio
+	}
+	if o.QueryCache > 0 {
+		r.cache = newQueryCache(o.QueryCache, o.QueryCacheQuantum)
+	}
+	if o.NewQueue != nil {
+		r.customQueuePool.New = func() interface{} {
+			return o.NewQueue()
+		}
+	}
+	r.insideFactor = 1
+	if o.InsideEpsilon > 0 {
+		r.insideFactor = 1 + o.InsideEpsilon
+	}
 	return r
 }
 
@@ -171,6 +261,8 @@ func (r *SimpleRTree) Destroy () {
 	}
 }
 // Load accepts points, an flat array of coordinates and builds the RTree
+// It panics with an error wrapping ErrOddPointsLength or ErrNonFiniteCoordinate if a coordinate is missing or is NaN or
+// infinite, the same checks apply to the rest of Load functions
 //
 // Note: rtree is assumed to have sole access to the array, it will modify the underlying order and it
 // will return wrong results if the elements are modified
@@ -191,6 +283,106 @@ func (r *SimpleRTree) LoadSortedArray(points FlatPoints) *SimpleRTree {
 	return r.load(points, true)
 }
 
+// LoadInterface builds the RTree over any collection of points implementing Interface, for example IntPoints.
+// It avoids converting the points into FlatPoints, at the cost of slower builds and queries.
+//
+// If points implements FallibleInterface and reading a point fails it panics with the error, use LoadInterfaceErr
+// to handle it.
+//
+// Note: rtree is assumed to have sole access to the collection, it will modify the underlying order and it
+// will return wrong results if the elements are modified
+func (r *SimpleRTree) LoadInterface(points Interface) *SimpleRTree {
+	r, err := r.LoadInterfaceErr(points)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// LoadFunc builds the RTree over n points produced by gen, so that callers do not need to build the array themselves.
+// Points are still stored in an array owned by the tree, since the build needs random access to them.
+// Indexes in results refer to that array, which can be read with LeafPointsInOrder.
+// gen is called exactly once for every i in [0, n)
+func (r *SimpleRTree) LoadFunc(n int, gen func(i int) (x, y float64)) *SimpleRTree {
+	points := make(FlatPoints, 2*n)
+	for i := 0; i < n; i++ {
+		points[2*i], points[2*i+1] = gen(i)
+	}
+	return r.load(points, false)
+}
+
+// LoadWithSortKey builds the RTree packing the points sequentially in the order given by key,
+// instead of using the tree type of the options. Key should map close points to close values,
+// for example a space filling curve, otherwise the bboxes of the nodes will be large and queries slow.
+//
+// Note: rtree is assumed to have sole access to the array, it will modify the underlying order and it
+// will return wrong results if the elements are modified
+func (r *SimpleRTree) LoadWithSortKey(points FlatPoints, key func(x, y float64) uint64) *SimpleRTree {
+	r.sortKey = key
+	return r.load(points, false)
+}
+
+// Rebuild builds the tree again over points, reusing the memory of the previous build.
+// It is meant for datasets that change as a whole, for example the positions of a simulation on each frame.
+// If the number of points does not grow, no memory is allocated.
+//
+// Rebuild is not safe to call concurrently with queries.
+//
+// Note: rtree is assumed to have sole access to the array, it will modify the underlying order and it
+// will return wrong results if the elements are modified
+func (r *SimpleRTree) Rebuild(points FlatPoints) error {
+	if !r.built {
+		return fmt.Errorf("%w, use Load instead", ErrNotBuilt)
+	}
+	if err := checkPoints(points); err != nil {
+		return err
+	}
+	if points.Len() == 0 {
+		return fmt.Errorf("%w, cannot rebuild tree", ErrNoPoints)
+	}
+	if err := checkSize(points.Len()); err != nil {
+		return err
+	}
+	if _, err := r.heightCap(points.Len()); err != nil {
+		return err
+	}
+	if err := r.checkBuildMemory(points); err != nil {
+		return err
+	}
+	isSorted, err := r.spillSort(points, false)
+	if err != nil {
+		return err
+	}
+	previousHeight := r.height
+	r.groups = nil
+	r.maxRadius = nil
+	r.attrs = nil
+	r.timestamps = nil
+	r.enableAll()
+	r.resetLoads()
+	if r.cache != nil {
+		r.cache.clear()
+	}
+	rootNodeConstruct := r.build(points, isSorted)
+	queueSize := rootNodeConstruct.height*r.options.MAX_ENTRIES
+	if r.options.UnsafeConcurrencyMode {
+		if cap(r.unsafeQueue) < queueSize {
+			r.unsafeQueue = make(searchQueue, queueSize)
+		}
+	} else if rootNodeConstruct.height > previousHeight {
+		r.queuePool = sync.Pool{
+			New: func () interface {} {
+				sq := make(searchQueue, queueSize)
+				return &sq
+			},
+		}
+	}
+	if r.options.VerifyBBoxes {
+		return r.verifyBBoxes()
+	}
+	return nil
+}
+
 // FindNearestPoint will return the coordinates of the closest point
 // to the provided coordinates x and y. The function returns three parameters
 // x1, y1 coordinates of the point
@@ -198,6 +390,9 @@ func (r *SimpleRTree) LoadSortedArray(points FlatPoints) *SimpleRTree {
 //  x1, y1, d1 := r.FindNearestPoint(x, y)
 //  (x1 - x) * (x1 - x) + (y1 - y) * (y1 - y) == d1
 func (r *SimpleRTree) FindNearestPoint(x, y float64) (x1, y1, d1 float64) {
+	if r.cache != nil {
+		return r.findNearestPointCached(x, y)
+	}
 	x1, y1, d1, _ = r.FindNearestPointWithin(x, y, math.Inf(1))
 	return
 }
@@ -211,20 +406,59 @@ func (r *SimpleRTree) FindNearestPoint(x, y float64) (x1, y1, d1 float64) {
 //  x1, y1, d1, found := r.FindNearestPointWithin(x, y, 4)
 // (x1 - x) * (x1 - x) + (y1 - y) * (y1 - y) < 4
 func (r *SimpleRTree) FindNearestPointWithin(x, y, dsquared float64) (x1, y1, d1 float64, found bool) {
+	res, found := r.findNearestPointWithin(x, y, dsquared, nil)
+	if found && r.options.RobustDistance {
+		res.Distance = r.finalDistance(res.X, res.Y, x, y)
+	}
+	return res.X, res.Y, res.Distance, found
+}
+
+// findNearestPointWithin implements FindNearestPointWithin. If visited is not nil, it is increased by the number of
+// nodes and points whose distance is computed during the search
+func (r *SimpleRTree) findNearestPointWithin(x, y, dsquared float64, visited *int) (res Result, found bool) {
+	if len(r.nodes) == 0 {
+		return
+	}
+	if r.options.VerifyAgainstBruteForce {
+		defer func() {
+			r.verifyNearest(x, y, dsquared, res, found)
+		}()
+	}
+	if r.useLinearScan() {
+		return r.findNearestLinear(x, y, dsquared, visited)
+	}
+	// bounding circles are also checked below, but not by the traversals of disabled points and custom queues
+	if r.orientedBoxes != nil || r.circles != nil && (r.nDisabled > 0 || r.options.NewQueue != nil) {
+		return r.findNearestTight(x, y, dsquared, visited)
+	}
+	if r.nDisabled > 0 {
+		return r.findNearestAccepted(x, y, func(i int, px, py, d float64) bool {
+			return d <= dsquared
+		})
+	}
+	if r.options.NewQueue != nil {
+		return r.findNearestPointWithinQueue(x, y, dsquared, visited)
+	}
 	var minItem searchQueueItem
 	distanceLowerBound := math.Inf(1)
 	// if bbox is further from this bound then we don't explore it
 	distanceUpperBound := dsquared
 	var sq searchQueue
+	// pooled queues are stored as pointers, putting a slice in the pool would allocate its header on every query
+	var pooled *searchQueue
 	if r.options.UnsafeConcurrencyMode {
 		sq = r.unsafeQueue
 	} else {
-		sq = r.queuePool.Get().(searchQueue)
+		pooled = r.queuePool.Get().(*searchQueue)
+		sq = *pooled
 	}
 	sq = sq[0:0]
 
 	rootNode := &r.nodes[0]
-	unsafeRootLeafNode := uintptr(unsafe.Pointer(&r.points[0]))
+	var unsafeRootLeafNode uintptr
+	if r.points != nil {
+		unsafeRootLeafNode = uintptr(unsafe.Pointer(&r.points[0]))
+	}
 	unsafeRootNode := uintptr(unsafe.Pointer(rootNode))
 	sq = append(sq, searchQueueItem{node: uintptr(unsafe.Pointer(rootNode)), distance: 0}) // we don't need distance for first node
 
@@ -237,16 +471,31 @@ func (r *SimpleRTree) FindNearestPointWithin(x, y, dsquared float64) (x1, y1, d1
 			break
 		}
 
-		node := (*rNode)(unsafe.Pointer(item.node))
-		if node == nil { // Leaf
+		if isPointQueueNode(item.node) { // Leaf
 			// we know it is smaller from the previous test
 			distanceLowerBound = currentDistance
 			minItem = item
 			found = true
 			continue
 		}
+		node := (*rNode)(unsafe.Pointer(item.node))
+		if visited != nil {
+			*visited += int(node.nChildren)
+		}
 		switch node.nodeType {
 		case preleaf_node:
+			if r.points == nil {
+				start := int(node.firstChildOffset / uint32(flat_point_size))
+				for i := start + int(node.nChildren) - 1; i >= start; i-- {
+					px, py := r.source.GetPointAt(i)
+					d := sourceLeafDistance(r.source, i, px, py, x, y)
+					if d <= distanceUpperBound {
+						sq = append(sq, searchQueueItem{node: pointQueueNode(uintptr(i) * flat_point_size), px: px, py: py, distance: d})
+						distanceUpperBound = d
+					}
+				}
+				continue
+			}
 			f := unsafeRootLeafNode + uintptr(node.firstChildOffset)
 			var i int8
 			for i = node.nChildren; i>0; i-- {
@@ -256,7 +505,7 @@ func (r *SimpleRTree) FindNearestPointWithin(x, y, dsquared float64) (x1, y1, d1
 
 				d := computeLeafDistance(px, py, x, y)
 				if d <= distanceUpperBound {
-					sq = append(sq, searchQueueItem{node: uintptr(unsafe.Pointer(nil)), px: px, py: py, distance: d})
+					sq = append(sq, searchQueueItem{node: pointQueueNode(f - float_size - unsafeRootLeafNode), px: px, py: py, distance: d})
 					distanceUpperBound = d
 				}
 				f = f + float_size
@@ -266,7 +515,10 @@ func (r *SimpleRTree) FindNearestPointWithin(x, y, dsquared float64) (x1, y1, d1
 			var i int8
 			for i = node.nChildren; i>0; i-- {
 				n := (*rNode)(unsafe.Pointer(f))
-				mind, maxd := computeDistances(n.BBox, x, y)
+				mind, maxd := computeDistances(n.BBox, x, y, r.insideFactor)
+				if r.circles != nil {
+					mind = math.Max(mind, r.circles[(f - unsafeRootNode) / node_size].distance(x, y))
+				}
 				if mind <= distanceUpperBound {
 					sq = append(sq, searchQueueItem{node: uintptr(unsafe.Pointer(n)), distance: mind})
 					// Distance to one of the corners is lower than the upper bound
@@ -282,7 +534,8 @@ func (r *SimpleRTree) FindNearestPointWithin(x, y, dsquared float64) (x1, y1, d1
 
 	// return heap
 	if !r.options.UnsafeConcurrencyMode {
-		r.queuePool.Put(sq)
+		*pooled = sq
+		r.queuePool.Put(pooled)
 	} else {
 		r.unsafeQueue = sq
 	}
@@ -290,24 +543,59 @@ func (r *SimpleRTree) FindNearestPointWithin(x, y, dsquared float64) (x1, y1, d1
 	if !found {
 		return
 	}
-	x1 = minItem.px
-	y1 = minItem.py
-	d1 = distanceUpperBound
+	res = Result{
+		Index:    pointQueueIndex(minItem.node),
+		X:        minItem.px,
+		Y:        minItem.py,
+		Distance: distanceUpperBound,
+	}
 	return
 }
 
-func (r *SimpleRTree) load(points FlatPoints, isSorted bool) *SimpleRTree {
-	if points.Len() == 0 {
-		return r
+func (r *SimpleRTree) load(points Interface, isSorted bool) *SimpleRTree {
+	if err := r.checkLoad(points); err != nil {
+		panic(err)
+	}
+	isSorted, err := r.spillSort(points, isSorted)
+	if err != nil {
+		panic(err)
+	}
+	return r.loadChecked(points, isSorted)
+}
+
+// checkLoad returns the error that prevents building the tree over points, nil if load can proceed
+func (r *SimpleRTree) checkLoad(points Interface) error {
+	if err := checkSize(points.Len()); err != nil {
+		return err
+	}
+	if err := checkInterfacePoints(points); err != nil {
+		return err
 	}
-	if points.Len() >= math.MaxInt32 / int(node_size) {
-		log.Fatal("Exceded maximum possible size", math.MaxInt32 / int(node_size))
+	if points.Len() == 0 {
+		return nil
 	}
 	if r.options.MAX_ENTRIES == 0 {
-		panic("MAX entries was 0")
+		return errors.New("MAX entries was 0")
+	}
+	if err := r.options.Validate(); err != nil {
+		return err
+	}
+	if _, err := r.heightCap(points.Len()); err != nil {
+		return err
+	}
+	if err := r.checkBuildMemory(points); err != nil {
+		return err
 	}
 	if r.built {
-		log.Fatal("Tree is static, cannot load twice")
+		return ErrAlreadyBuilt
+	}
+	return nil
+}
+
+// loadChecked is load once checkLoad accepted the points
+func (r *SimpleRTree) loadChecked(points Interface, isSorted bool) *SimpleRTree {
+	if points.Len() == 0 {
+		return r
 	}
 	r.built = true
 
@@ -325,65 +613,121 @@ func (r *SimpleRTree) load(points FlatPoints, isSorted bool) *SimpleRTree {
 	} else {
 		r.sorterBuffer = make([]int, 0, r.options.MAX_ENTRIES+1)
 	}
-	r.points = points
-	if isPooledMemReceived && cap(rtreePooledMem.nodes) >= computeSize(points.Len()) {
-		r.nodes = rtreePooledMem.nodes[0: 0]
-	} else {
-		r.nodes = make([]rNode, 0, computeSize(points.Len()))
+	if isPooledMemReceived {
+		r.nodes = rtreePooledMem.nodes
 	}
-	var rootNodeConstruct nodeConstruct
-	if r.options.TreeType == STR {
-		rootNodeConstruct = r.buildSTR(points, isSorted)
-	} else {
-		rootNodeConstruct = r.buildHilbert(points, isSorted)
+	rootNodeConstruct := r.build(points, isSorted)
+	if r.options.VerifyBBoxes {
+		if err := r.verifyBBoxes(); err != nil {
+			panic(err)
+		}
 	}
 
 	if isPooledMemReceived && r.options.UnsafeConcurrencyMode && cap(rtreePooledMem.sq) >= rootNodeConstruct.height*r.options.MAX_ENTRIES {
 		r.unsafeQueue = rtreePooledMem.sq
 	} else {
-		if r.options.UnsafeConcurrencyMode {
-			r.unsafeQueue = make(searchQueue, rootNodeConstruct.height*r.options.MAX_ENTRIES)
-		} else {
-			r.queuePool = sync.Pool{
-				New: func () interface {} {
-					return make(searchQueue, rootNodeConstruct.height*r.options.MAX_ENTRIES)
-				},
-			}
-			firstQueue := r.queuePool.Get()
-			r.queuePool.Put(firstQueue)
-		}
+		r.initQueues(rootNodeConstruct.height)
 	}
 	return r
 }
 
-func (r *SimpleRTree) buildHilbert(points FlatPoints, isSorted bool) nodeConstruct {
+// initQueues allocates the search queues for a tree of the given height
+func (r *SimpleRTree) initQueues(height int) {
+	if r.options.UnsafeConcurrencyMode {
+		r.unsafeQueue = make(searchQueue, height*r.options.MAX_ENTRIES)
+	} else {
+		r.queuePool = sync.Pool{
+			New: func() interface{} {
+				sq := make(searchQueue, height*r.options.MAX_ENTRIES)
+				return &sq
+			},
+		}
+		firstQueue := r.queuePool.Get()
+		r.queuePool.Put(firstQueue)
+	}
+}
+
+// build sets the points of the tree and builds the nodes, reusing r.nodes if it has enough capacity
+func (r *SimpleRTree) build(points Interface, isSorted bool) nodeConstruct {
+	if fp, ok := points.(FlatPoints); ok {
+		r.points = fp
+		r.source = nil
+		r.leafPoints = nil
+	} else {
+		r.points = nil
+		r.source = points
+	}
+	if capacity := r.nodesCapacity(points.Len()); cap(r.nodes) >= capacity {
+		r.nodes = r.nodes[0: 0]
+	} else {
+		r.nodes = make([]rNode, 0, capacity)
+	}
+	r.progressDone = 0
+	var rootNodeConstruct nodeConstruct
+	if r.useLinearScan() {
+		// points are neither sorted nor grouped, the root leaf keeps the rest of queries working
+		rootNodeConstruct = nodeConstruct{height: 1, start: 0, end: uint32(points.Len())}
+		r.nodes = append(r.nodes, rNode{})
+		r.setLeafNode(&r.nodes[0], rootNodeConstruct)
+	} else if r.options.TreeType == STR && r.sortKey == nil {
+		rootNodeConstruct = r.buildSTR(points, isSorted)
+	} else {
+		rootNodeConstruct = r.buildHilbert(points, isSorted)
+	}
+	r.height = rootNodeConstruct.height
+	if r.options.MortonLeaves && !r.useLinearScan() {
+		r.sortLeavesMorton(0, len(r.nodes))
+	}
+	if r.options.LeafOrderCopy && r.points == nil {
+		r.copyLeafPoints()
+	}
+	if r.options.StoreCentroids {
+		r.computeCentroids()
+	}
+	if r.options.StoreHilbertValues {
+		r.computeHilbertValues()
+	}
+	if r.options.OrientedLeaves {
+		r.computeOrientedBoxes()
+	}
+	if r.options.BoundingCircles {
+		r.computeBoundingCircles()
+	}
+	return rootNodeConstruct
+}
+
+func (r *SimpleRTree) buildHilbert(points Interface, isSorted bool) nodeConstruct {
 	r.nodes = append(r.nodes, rNode{})
 	if (!isSorted) {
 		r.sortHilbert(points)
 	}
 
-	nBuckets := points.Len() / r.options.MAX_ENTRIES
-	if (points.Len() % r.options.MAX_ENTRIES > 0) {
+	leafSize := r.options.MAX_ENTRIES
+	if maxHeight, _ := r.heightCap(points.Len()); maxHeight > 0 {
+		leafSize = cappedLeafSize(points.Len(), r.options.MAX_ENTRIES, maxHeight)
+	}
+	nBuckets := points.Len() / leafSize
+	if (points.Len() % leafSize > 0) {
 		nBuckets++
 	}
 	previousStart := 0
 	nextStart := len(r.nodes)
 	height := 2
 	for i:= 0; i < nBuckets ; i++ {
-		start := previousStart + i * r.options.MAX_ENTRIES
-		end := minInt(start + r.options.MAX_ENTRIES, points.Len())
-		x0, y0 := r.points.GetPointAt(start)
-		vb := rVectorBBox{x0, y0, x0, y0}
+		start := previousStart + i * leafSize
+		end := minInt(start + leafSize, points.Len())
+		x0, y0 := r.getPointAt(start)
+		vb := VectorBBox{x0, y0, x0, y0}
 
 		for i := end - start - 1; i > 0; i-- {
-			x1, y1 := r.points.GetPointAt(start + i)
+			x1, y1 := r.getPointAt(start + i)
 			vb1 := [4]float64{
 				x1,
 				y1,
 				x1,
 				y1,
 			}
-			vb = vectorBBoxExtend(vb, vb1)
+			vb = VectorBBoxExtend(vb, vb1)
 		}
 		r.nodes = append(r.nodes, rNode{
 			nodeType: preleaf_node,
@@ -391,6 +735,7 @@ func (r *SimpleRTree) buildHilbert(points FlatPoints, isSorted bool) nodeConstru
 			nChildren: int8(end - start),
 			firstChildOffset: uint32(start) * uint32(flat_point_size),
 		})
+		r.reportProgress(end - start)
 	}
 	for nBuckets > r.options.MAX_ENTRIES {
 		height++
@@ -403,12 +748,13 @@ func (r *SimpleRTree) buildHilbert(points FlatPoints, isSorted bool) nodeConstru
 		}
 		for i:= 0; i < nBuckets ; i++ {
 			start := previousStart + i * r.options.MAX_ENTRIES
-			end := minInt(start + r.options.MAX_ENTRIES, len(r.nodes))
+			// children are the nodes of the previous level, which ends where this level starts
+			end := minInt(start + r.options.MAX_ENTRIES, nextStart)
 			vb := r.nodes[start].BBox
 
 			for i := end - start - 1; i > 0; i-- {
 				vb1 := r.nodes[start + i].BBox
-				vb = vectorBBoxExtend(vb, vb1)
+				vb = VectorBBoxExtend(vb, vb1)
 			}
 			r.nodes = append(r.nodes, rNode{
 				nodeType: default_node,
@@ -431,10 +777,14 @@ func (r *SimpleRTree) buildHilbert(points FlatPoints, isSorted bool) nodeConstru
 	}
 }
 
-func (r *SimpleRTree) sortHilbert(points FlatPoints) {
+func (r *SimpleRTree) sortHilbert(points Interface) {
+	key := GeoHash
+	if r.sortKey != nil {
+		key = r.sortKey
+	}
 	hashes := make([]uint64, points.Len())
 	for i:= 0; i < points.Len(); i++ {
-		hash := GeoHash(points.GetPointAt(i))
+		hash := key(points.GetPointAt(i))
 		hashes[i] = hash
 	}
 	sorter := GeoHashSorter{
@@ -444,150 +794,257 @@ func (r *SimpleRTree) sortHilbert(points FlatPoints) {
 	sort.Sort(sorter)
 }
 
-func (r *SimpleRTree) buildSTR(points FlatPoints, isSorted bool) nodeConstruct {
+func (r *SimpleRTree) buildSTR(points Interface, isSorted bool) nodeConstruct {
 	r.nodes = append(r.nodes, rNode{})
 	rootNodeConstruct := nodeConstruct{
-		height: int(math.Ceil(math.Log(float64(points.Len())) / math.Log(float64(r.options.MAX_ENTRIES)))),
+		height: strHeight(points.Len(), r.options.MAX_ENTRIES),
 		start:  uint32(0),
 		end:    uint32(points.Len()),
 	}
+	if maxHeight, _ := r.heightCap(points.Len()); maxHeight > 0 && rootNodeConstruct.height > maxHeight {
+		rootNodeConstruct.height = maxHeight
+	}
 
-	r.buildNodeDownwards(&r.nodes[0], rootNodeConstruct, isSorted)
+	r.buildNodeDownwards(0, rootNodeConstruct, isSorted)
 	return rootNodeConstruct
 }
 
-func (r *SimpleRTree) buildNodeDownwards(n *rNode, nc nodeConstruct, isSorted bool) rVectorBBox {
+// strHeight returns the number of levels of nodes of an STR tree of n points, the smallest height such that
+// maxEntries**height >= n. A tree always has at least the root, so it is never 0, even for a single point.
+// It is computed with integers, logarithms are not exact for powers of maxEntries
+func strHeight(n, maxEntries int) int {
+	height := 1
+	for capacity := maxEntries; capacity < n; capacity *= maxEntries {
+		height++
+	}
+	return height
+}
+
+// buildNodeDownwards builds the node at position index of nodes and its descendants. Nodes are appended while building,
+// which may reallocate the array, so the node is accessed by position and pointers are not kept across appends
+func (r *SimpleRTree) buildNodeDownwards(index int, nc nodeConstruct, isSorted bool) VectorBBox {
+	n := &r.nodes[index]
 	N := int(nc.end - nc.start)
 	// target number of root entries to maximize storage utilization
 	var M float64
-	if N <= r.options.MAX_ENTRIES { // Leaf node
+	if N <= r.options.MAX_ENTRIES || nc.height <= 1 { // Leaf node
 		return r.setLeafNode(n, nc)
 	}
 
-	M = math.Ceil(float64(N) / float64(math.Pow(float64(r.options.MAX_ENTRIES), float64(nc.height-1))))
+	// leaves hold MAX_ENTRIES points, unless the height is capped by Options.MaxHeight and the points of the node only
+	// fit below it with larger leaves
+	leafSize := math.Max(float64(r.options.MAX_ENTRIES), math.Ceil(float64(N) / math.Pow(float64(r.options.MAX_ENTRIES), float64(nc.height-1))))
+	M = math.Ceil(float64(N) / (leafSize * math.Pow(float64(r.options.MAX_ENTRIES), float64(nc.height-2))))
 
 	N2 := int(math.Ceil(float64(N) / M))
 	N1 := N2 * int(math.Ceil(math.Sqrt(M)))
+	if r.queryAspectRatio != 1 {
+		N1 = N2 * r.nodesPerSlice(int(nc.start), int(nc.end), M)
+	}
 
 	start := int(nc.start)
-	// parent node might already be sorted. In that case we avoid double computation
-	if !isSorted {
-		sortX := xSorter{n: n, points: r.points, start: start, end: int(nc.end), bucketSize: N1}
-		sortX.Sort(r.sorterBuffer)
-	}
 	nodeConstructs := [MAX_POSSIBLE_SIZE]nodeConstruct{}
 	var nodeConstructIndex int8
 	firstChildIndex := len(r.nodes)
-	for i := 0; i < N; i += N1 {
-		right2 := minInt(i+N1, N)
-		sortY := ySorter{n: n, points: r.points, start: start+ i, end: start+ right2, bucketSize: N2}
-		sortY.Sort(r.sorterBuffer)
-		for j := i; j < right2; j += N2 {
-			right3 := minInt(j+N2, right2)
-			child := rNode{}
-			childC := nodeConstruct{
-				start:  nc.start + uint32(j),
-				end:    nc.start + uint32(right3),
-				height: nc.height - 1,
+	if r.options.BalancedLeaves {
+		nodeConstructIndex = r.splitBalanced(n, nc, int(M), N1/N2, isSorted, &nodeConstructs)
+		for i := int8(0); i < nodeConstructIndex; i++ {
+			r.nodes = append(r.nodes, rNode{})
+		}
+	} else {
+		// parent node might already be sorted. In that case we avoid double computation
+		if !isSorted {
+			r.sortX(n, start, int(nc.end), N1)
+		}
+		for i := 0; i < N; i += N1 {
+			right2 := minInt(i+N1, N)
+			r.sortY(n, start+i, start+right2, N2)
+			for j := i; j < right2; j += N2 {
+				right3 := minInt(j+N2, right2)
+				child := rNode{}
+				childC := nodeConstruct{
+					start:  nc.start + uint32(j),
+					end:    nc.start + uint32(right3),
+					height: nc.height - 1,
+				}
+				r.nodes = append(r.nodes, child)
+				nodeConstructs[nodeConstructIndex] = childC
+				nodeConstructIndex++
 			}
-			r.nodes = append(r.nodes, child)
-			nodeConstructs[nodeConstructIndex] = childC
-			nodeConstructIndex++
 		}
 	}
+	n = &r.nodes[index]
 	n.firstChildOffset = uint32(firstChildIndex) * uint32(node_size)
 	n.nChildren = nodeConstructIndex
 	// compute children
 	var i int8
-	bbox := r.buildNodeDownwards(&r.nodes[firstChildIndex], nodeConstructs[i], false)
+	bbox := r.buildNodeDownwards(firstChildIndex, nodeConstructs[i], false)
 	for i = 1; i < nodeConstructIndex; i++ {
-		// TODO check why using (*Node)f here does not work
-		bbox2 := r.buildNodeDownwards(&r.nodes[firstChildIndex+int(i)], nodeConstructs[i], false)
-		bbox = vectorBBoxExtend(bbox, bbox2)
+		bbox2 := r.buildNodeDownwards(firstChildIndex+int(i), nodeConstructs[i], false)
+		bbox = VectorBBoxExtend(bbox, bbox2)
 	}
-	n.BBox = bbox
+	r.nodes[index].BBox = bbox
 	return bbox
 }
 
-func (r *SimpleRTree) setLeafNode(n *rNode, nc nodeConstruct) rVectorBBox {
+// splitBalanced splits the points of nc in M children whose number of points differs at most by one, grouped in x
+// slices of about perSlice children each that are split along y, and returns the number of children.
+// Unlike the default split, children do not hold a whole number of full subtrees, so the remainder is spread over
+// all of them instead of ending up in the last one, see Options.BalancedLeaves
+func (r *SimpleRTree) splitBalanced(n *rNode, nc nodeConstruct, M, perSlice int, isSorted bool, children *[MAX_POSSIBLE_SIZE]nodeConstruct) int8 {
+	start, N := int(nc.start), int(nc.end-nc.start)
+	// position of the first point of the k-th child, relative to start
+	boundary := func(k int) int {
+		return N * k / M
+	}
+	slices := (M + perSlice - 1) / perSlice
+	// children are spread evenly between slices too, the first M % slices slices get one more
+	firstChild := func(slice int) int {
+		return slice*(M/slices) + minInt(slice, M%slices)
+	}
+	for slice := 0; slice < slices; slice++ {
+		from, to := boundary(firstChild(slice)), boundary(firstChild(slice+1))
+		// the points of the slice are the to - from smallest x of the remaining ones
+		if !isSorted && to < N {
+			r.sortX(n, start+from, start+N, to-from)
+		}
+		for k := firstChild(slice); k < firstChild(slice+1); k++ {
+			if k+1 < firstChild(slice+1) {
+				r.sortY(n, start+boundary(k), start+to, boundary(k+1)-boundary(k))
+			}
+			children[k] = nodeConstruct{
+				start:  nc.start + uint32(boundary(k)),
+				end:    nc.start + uint32(boundary(k+1)),
+				height: nc.height - 1,
+			}
+		}
+	}
+	return int8(M)
+}
+
+// nodesPerSlice returns how many of the M children of the points between start and end go in each x slice, so
+// that the children have the aspect ratio of Options.QueryAspectRatio. It depends on the extent of the points and
+// not only on M, otherwise the ratio would compound at every level
+func (r *SimpleRTree) nodesPerSlice(start, end int, M float64) int {
+	x0, y0 := r.getPointAt(start)
+	vb := VectorBBox{x0, y0, x0, y0}
+	for i := start + 1; i < end; i++ {
+		x, y := r.getPointAt(i)
+		vb = VectorBBoxExtend(vb, VectorBBox{x, y, x, y})
+	}
+	w := vb[VECTOR_BBOX_MAX_X] - vb[VECTOR_BBOX_MIN_X]
+	h := vb[VECTOR_BBOX_MAX_Y] - vb[VECTOR_BBOX_MIN_Y]
+	if w == 0 || h == 0 {
+		return int(math.Ceil(math.Sqrt(M)))
+	}
+	// with s slices children are (w / s) wide and (h * s / M) tall
+	slices := math.Max(1, math.Min(M, math.Round(math.Sqrt(M*w/(h*r.queryAspectRatio)))))
+	return int(math.Ceil(M / slices))
+}
+
+func (r *SimpleRTree) setLeafNode(n *rNode, nc nodeConstruct) VectorBBox {
 	// Here we follow original rbush implementation.
 	start := int(nc.start)
 	end := int(nc.end)
 	firstChildIndex := start
 
-	x0, y0 := r.points.GetPointAt(start)
-	vb := rVectorBBox{x0, y0, x0, y0}
+	x0, y0 := r.getPointAt(start)
+	vb := VectorBBox{x0, y0, x0, y0}
 
 	for i := end-start - 1; i > 0; i-- {
-		x1, y1 := r.points.GetPointAt(start + i)
+		x1, y1 := r.getPointAt(start + i)
 		vb1 := [4]float64{
 			x1,
 			y1,
 			x1,
 			y1,
 		}
-		vb = vectorBBoxExtend(vb, vb1)
+		vb = VectorBBoxExtend(vb, vb1)
 	}
 	n.firstChildOffset = uint32(firstChildIndex) * uint32(flat_point_size) // We access leafs on the original array
 	n.nChildren = int8(nc.end - nc.start)
 	n.nodeType = preleaf_node
 	n.BBox = vb
+	r.reportProgress(end - start)
 	return vb
 }
 
-func (r *SimpleRTree) toJSON() {
-	text := make([]string, 0)
-	fmt.Println(strings.Join(r.toJSONAcc(&r.nodes[0], text), ","))
-}
-
-func (r *SimpleRTree) toJSONAcc(n *rNode, text []string) []string {
-	t, err := template.New("foo").Parse(`{
-	       "type": "Feature",
-	       "properties": {},
-	       "geometry": {
-       "type": "Polygon",
-       "coordinates": [
-       [
-       [
-       {{index .BBox 0}},
-       {{index .BBox 1}}
-       ],
-       [
-       {{index .BBox 2}},
-       {{index .BBox 1}}
-       ],
-       [
-       {{index .BBox 2}},
-       {{index .BBox 3}}
-       ],
-       [
-       {{index .BBox 0}},
-       {{index .BBox 3}}
-       ],
-       [
-       {{index .BBox 0}},
-       {{index .BBox 1}}
-       ]
-       ]
-       ]
-       }
-       }`)
-	if err != nil {
-		log.Fatal(err)
+// reportProgress accounts for n more points placed in leaves and calls Options.Progress when another 1% of the
+// points is done
+func (r *SimpleRTree) reportProgress(n int) {
+	if r.options.Progress == nil {
+		return
 	}
-	var tpl bytes.Buffer
-	if err := t.Execute(&tpl, n); err != nil {
-		log.Fatal(err)
+	total := r.getLen()
+	step := total / 100
+	if step == 0 {
+		step = 1
 	}
-	text = append(text, tpl.String())
-	f := unsafe.Pointer(uintptr(unsafe.Pointer(&r.nodes[0])) + uintptr(n.firstChildOffset))
-	var i int8
-	for i = 0; i < n.nChildren; i++ {
-		cn := (*rNode)(f)
-		text = r.toJSONAcc(cn, text)
-		f = unsafe.Pointer(uintptr(f) + node_size)
+	previous := r.progressDone
+	r.progressDone += n
+	if r.progressDone == total || r.progressDone/step != previous/step {
+		r.options.Progress(r.progressDone, total)
+	}
+}
+
+// sortX sorts points in [start, end) into buckets along the x axis. Concrete sorters are used for FlatPoints
+func (r *SimpleRTree) sortX(n *rNode, start, end, bucketSize int) {
+	if r.points != nil {
+		sortX := xSorter{n: n, points: r.points, start: start, end: end, bucketSize: bucketSize}
+		sortX.Sort(r.sorterBuffer)
+		return
+	}
+	sortX := interfaceXSorter{points: r.source, start: start, end: end, bucketSize: bucketSize}
+	sortX.Sort(r.sorterBuffer)
+}
+
+// sortY sorts points in [start, end) into buckets along the y axis. Concrete sorters are used for FlatPoints
+func (r *SimpleRTree) sortY(n *rNode, start, end, bucketSize int) {
+	if r.points != nil {
+		sortY := ySorter{n: n, points: r.points, start: start, end: end, bucketSize: bucketSize}
+		sortY.Sort(r.sorterBuffer)
+		return
+	}
+	sortY := interfaceYSorter{points: r.source, start: start, end: end, bucketSize: bucketSize}
+	sortY.Sort(r.sorterBuffer)
+}
+
+func (r *SimpleRTree) getLen() int {
+	if r.points != nil {
+		return r.points.Len()
+	}
+	return r.source.Len()
+}
+
+func (r *SimpleRTree) getPointAt(i int) (x, y float64) {
+	if r.points != nil {
+		return r.points.GetPointAt(i)
+	}
+	if r.leafPoints != nil {
+		return r.leafPoints.GetPointAt(i)
+	}
+	return r.source.GetPointAt(i)
+}
+
+// pointDistance returns the coordinates of the point at position i and its distance squared to (x, y)
+func (r *SimpleRTree) pointDistance(i int, x, y float64) (px, py, d float64) {
+	px, py = r.getPointAt(i)
+	if r.source != nil {
+		return px, py, sourceLeafDistance(r.source, i, px, py, x, y)
 	}
-	return text
+	return px, py, computeLeafDistance(px, py, x, y)
+}
+
+// SquaredDistance returns the distance squared between (x1, y1) and (x2, y2) computed in the same way as the
+// distances of the points to the queries, so that callers can compare their own distances with the results.
+// Trees loaded with IntPoints compute distances between integer coordinates exactly, and with
+// Options.RobustDistance distances are rounded once as in the results
+func (r *SimpleRTree) SquaredDistance(x1, y1, x2, y2 float64) float64 {
+	return r.finalDistance(x1, y1, x2, y2)
+}
+
+func (r *SimpleRTree) toJSON() {
+	r.WriteGeoJSON(os.Stdout, GeoJSONFilter{})
 }
 
 // node is point, there is only one distance
@@ -595,7 +1052,18 @@ func computeLeafDistance(px, py, x, y float64) float64 {
 	return (x-px)*(x-px) +
 		(y-py)*(y-py)
 }
-func computeDistances(bbox rVectorBBox, x, y float64) (mind, maxd float64) {
+
+// sourceLeafDistance computes the distance to the point at position i of a tree loaded through LoadInterface
+func sourceLeafDistance(source Interface, i int, px, py, x, y float64) float64 {
+	if ip, ok := source.(IntPoints); ok {
+		return ip.squaredDistanceTo(i, x, y)
+	}
+	return computeLeafDistance(px, py, x, y)
+}
+// computeDistances returns the minimum distance squared from (x, y) to the bbox, and an upper bound of the distance
+// squared to its closest point. insideFactor is 1 + the relative tolerance used to decide whether the coordinates
+// are inside the bbox, see Options.InsideEpsilon
+func computeDistances(bbox VectorBBox, x, y, insideFactor float64) (mind, maxd float64) {
 	// TODO try simd
 	minX := bbox[0]
 	minY := bbox[1]
@@ -608,10 +1076,10 @@ func computeDistances(bbox rVectorBBox, x, y float64) (mind, maxd float64) {
 	sideY := (maxY - minY) * (maxY - minY)
 
 	// Code is a bit cryptic but it is equivalent to the commented code which is clearer
-	if maxx >= sideX {
+	if maxx >= sideX*insideFactor {
 		mind += minx
 	}
-	if maxy >= sideY {
+	if maxy >= sideY*insideFactor {
 		mind += miny
 	}
 
@@ -641,6 +1109,12 @@ func minInt(a, b int) int {
 	}
 	return b
 }
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
 func minFloat(a, b float64) float64 {
 	if a < b {
 		return a
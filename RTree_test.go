@@ -5,6 +5,7 @@ import (
 	_ "github.com/stretchr/testify/assert"
 	"math"
 	"math/rand"
+	"sort"
 	"testing"
 	"sync"
 	"fmt"
@@ -98,6 +99,106 @@ func TestSimpleRTree_FindNearestPoint(t *testing.T) {
 	}
 }
 
+func TestSimpleRTree_SmallTrees(t *testing.T) {
+	for _, maxEntries := range []int{2, 4, MAX_POSSIBLE_SIZE} {
+		for _, size := range []int{1, 2, maxEntries, maxEntries + 1, maxEntries * maxEntries} {
+			for _, options := range []Options{
+				{MAX_ENTRIES: maxEntries},
+				{MAX_ENTRIES: maxEntries, UnsafeConcurrencyMode: true},
+				{MAX_ENTRIES: maxEntries, TreeType: HILBERT},
+				{MAX_ENTRIES: maxEntries, NewQueue: func() Queue { return &radixQueue{} }},
+			} {
+				points := make(FlatPoints, size*2)
+				for i := range points {
+					points[i] = rand.Float64()
+				}
+				r := NewWithOptions(options).Load(points)
+				assert.True(t, r.height >= 1, "Height of %d points with %d entries", size, maxEntries)
+				assertBBoxesContainChildren(t, r)
+				for i := 0; i < size; i++ {
+					px, py := points.GetPointAt(i)
+					dx := px + 1e-9 - px
+					x1, y1, d1 := r.FindNearestPoint(px+1e-9, py)
+					assert.Equal(t, dx*dx, d1, "%d points with %d entries", size, maxEntries)
+					assert.Equal(t, px, x1)
+					assert.Equal(t, py, y1)
+					assert.Contains(t, r.Search(BBox{px, py, px, py}), i)
+				}
+				for n := 0; n < 20; n++ {
+					x, y := rand.Float64()*2-0.5, rand.Float64()*2-0.5
+					expected := math.Inf(1)
+					for i := 0; i < size; i++ {
+						px, py := points.GetPointAt(i)
+						expected = math.Min(expected, (px-x)*(px-x)+(py-y)*(py-y))
+					}
+					_, _, d1 := r.FindNearestPoint(x, y)
+					assert.Equal(t, expected, d1)
+					_, _, _, found := r.FindNearestPointWithin(x, y, expected/2)
+					assert.False(t, found)
+				}
+			}
+		}
+	}
+}
+
+func TestSimpleRTree_StrHeight(t *testing.T) {
+	assert.Equal(t, 1, strHeight(1, 9))
+	assert.Equal(t, 1, strHeight(2, 9))
+	assert.Equal(t, 1, strHeight(9, 9))
+	assert.Equal(t, 2, strHeight(10, 9))
+	assert.Equal(t, 2, strHeight(81, 9))
+	assert.Equal(t, 3, strHeight(82, 9))
+	assert.Equal(t, 3, strHeight(729, 9))
+	assert.Equal(t, 1, strHeight(2, 2))
+	assert.Equal(t, 10, strHeight(1024, 2))
+}
+
+func TestSimpleRTree_SquaredDistance(t *testing.T) {
+	const size = 5000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()*2000 - 1000
+	}
+	r := New().Load(points)
+	for i := 0; i < 1000; i++ {
+		x, y := rand.Float64()*2000-1000, rand.Float64()*2000-1000
+		x1, y1, d1 := r.FindNearestPoint(x, y)
+		assert.Equal(t, d1, r.SquaredDistance(x, y, x1, y1))
+		assert.Equal(t, d1, r.SquaredDistance(x1, y1, x, y))
+	}
+	assert.Equal(t, 25., r.SquaredDistance(0, 0, 3, -4))
+}
+
+func TestSimpleRTree_HilbertNodesReachedOnce(t *testing.T) {
+	for _, size := range []int{1, 10, 100, 10000} {
+		points := make(FlatPoints, size*2)
+		for i := range points {
+			points[i] = rand.Float64()
+		}
+		r := NewWithOptions(Options{TreeType: HILBERT}).Load(points)
+		reached := make([]int, len(r.nodes))
+		pointsReached := 0
+		var walk func(i, depth int)
+		walk = func(i, depth int) {
+			reached[i]++
+			n := &r.nodes[i]
+			if n.nodeType == preleaf_node {
+				assert.Equal(t, r.height, depth, "Leaves at the bottom level for %d points", size)
+				pointsReached += int(n.nChildren)
+				return
+			}
+			for j := n.firstChildIndex(); j < n.firstChildIndex()+int(n.nChildren); j++ {
+				walk(j, depth+1)
+			}
+		}
+		walk(0, 1)
+		for i := range reached {
+			assert.Equal(t, 1, reached[i], "Node %d for %d points", i, size)
+		}
+		assert.Equal(t, size, pointsReached)
+	}
+}
+
 func TestSimpleRTree_FindNearestPointWithinOutOfBBox(t *testing.T) {
 	const size = 20
 	points := make([]float64, size*2)
@@ -179,6 +280,136 @@ func TestSimpleRTree_FindNearestPointBigUnsafeMode(t *testing.T) {
 
 }
 
+func TestSimpleRTree_Rebuild(t *testing.T) {
+	const size = 20000
+	for _, options := range []Options{{}, {UnsafeConcurrencyMode: true}, {TreeType: HILBERT}} {
+		points := make([]float64, size*2)
+		for i := 0; i < 2*size; i++ {
+			points[i] = rand.Float64()
+		}
+		r := NewWithOptions(options).Load(FlatPoints(points))
+		for frame := 0; frame < 3; frame++ {
+			// simulate a moving dataset
+			for i := 0; i < 2*size; i++ {
+				points[i] += (rand.Float64() - 0.5) * 0.01
+			}
+			fp := FlatPoints(points)
+			fp2 := FlatPoints(append(make([]float64, 0, len(points)), points...))
+			nodes := &r.nodes[0]
+			assert.NoError(t, r.Rebuild(fp))
+			assert.True(t, nodes == &r.nodes[0], "Nodes memory is reused")
+			fresh := NewWithOptions(options).Load(fp2)
+			assert.Equal(t, fresh.nodes, r.nodes)
+			for i := 0; i < 100; i++ {
+				x, y := rand.Float64(), rand.Float64()
+				x1, y1, d1 := r.FindNearestPoint(x, y)
+				x2, y2, d2 := fresh.FindNearestPoint(x, y)
+				_, _, d3 := fp.linearClosestPoint(x, y)
+				assert.Equal(t, x2, x1)
+				assert.Equal(t, y2, y1)
+				assert.Equal(t, d2, d1)
+				assert.Equal(t, d3, d1)
+			}
+		}
+	}
+}
+
+func TestSimpleRTree_RebuildErrors(t *testing.T) {
+	assert.Error(t, New().Rebuild(FlatPoints{0, 0}), "Tree was not loaded")
+	r := New().Load(FlatPoints{0, 0, 1, 1})
+	assert.Error(t, r.Rebuild(FlatPoints{}), "No points")
+	// rebuilding with more points is allowed
+	assert.NoError(t, r.Rebuild(FlatPoints{0, 0, 1, 1, 2, 2}))
+	x, y, _ := r.FindNearestPoint(3, 3)
+	assert.Equal(t, 2., x)
+	assert.Equal(t, 2., y)
+}
+
+func TestSimpleRTree_LoadWithSortKey(t *testing.T) {
+	const size = 20000
+	keys := []struct {
+		name string
+		key  func(x, y float64) uint64
+	}{
+		{"morton", unitSquareMorton},
+		// arbitrary order, bboxes will overlap a lot but the tree must still be correct
+		{"scrambled", func(x, y float64) uint64 { return uint64(x*1e9) * 2654435761 }},
+		{"constant", func(x, y float64) uint64 { return 0 }},
+	}
+	for _, k := range keys {
+		points := make([]float64, size*2)
+		for i := 0; i < 2*size; i++ {
+			points[i] = rand.Float64()
+		}
+		fp := FlatPoints(points)
+		r := New().LoadWithSortKey(fp, k.key)
+		assertBBoxesContainChildren(t, r)
+		for i := 0; i < 100; i++ {
+			x, y := rand.Float64(), rand.Float64()
+			x1, y1, _ := r.FindNearestPoint(x, y)
+			x2, y2, _ := fp.linearClosestPoint(x, y)
+			assert.Equal(t, x2, x1, k.name)
+			assert.Equal(t, y2, y1, k.name)
+		}
+	}
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	fp := FlatPoints(points)
+	New().LoadWithSortKey(fp, unitSquareMorton)
+	for i := 1; i < fp.Len(); i++ {
+		assert.True(t, unitSquareMorton(fp.GetPointAt(i-1)) <= unitSquareMorton(fp.GetPointAt(i)), "Points are sorted by key")
+	}
+}
+
+func TestSimpleRTree_LoadProgress(t *testing.T) {
+	for _, size := range []int{1, 5, 20, 20000} {
+		for _, treeType := range []TreeType{STR, HILBERT} {
+			points := make([]float64, size*2)
+			for i := 0; i < 2*size; i++ {
+				points[i] = rand.Float64()
+			}
+			var dones []int
+			r := NewWithOptions(Options{
+				TreeType: treeType,
+				Progress: func(done, total int) {
+					assert.Equal(t, size, total)
+					dones = append(dones, done)
+				},
+			})
+			r.Load(FlatPoints(points))
+			assert.NotEmpty(t, dones)
+			assert.True(t, len(dones) <= 101, "Progress is not reported too often")
+			for i := 1; i < len(dones); i++ {
+				assert.True(t, dones[i-1] < dones[i], "Progress is monotonic")
+			}
+			assert.Equal(t, size, dones[len(dones)-1], "Progress reports completion")
+		}
+	}
+}
+
+func TestSimpleRTree_LoadFunc(t *testing.T) {
+	const side = 100
+	calls := make([]int, side*side)
+	r := New().LoadFunc(side*side, func(i int) (x, y float64) {
+		calls[i]++
+		return float64(i % side), float64(i / side)
+	})
+	for _, c := range calls {
+		assert.Equal(t, 1, c)
+	}
+	points := r.LeafPointsInOrder()
+	assert.Equal(t, side*side, points.Len())
+	for i := 0; i < 1000; i++ {
+		x, y := rand.Float64()*side, rand.Float64()*side
+		x1, y1, _ := r.FindNearestPoint(x, y)
+		assert.Equal(t, math.Min(math.Round(x), side-1), x1)
+		assert.Equal(t, math.Min(math.Round(y), side-1), y1)
+	}
+	assert.Empty(t, New().LoadFunc(0, nil).LeafPointsInOrder())
+}
+
 func TestComputeSize(t *testing.T) {
 	testCases := []struct {
 		len      int
@@ -216,7 +447,7 @@ func Benchmark_ComputeDistances(b *testing.B) {
 		i %= size
 		bbox := newVectorBBox(points[i], points[i+1], points[i+2], points[i+3])
 		x, y := points[i+4], points[i+5]
-		_, _ = computeDistances(bbox, x, y)
+		_, _ = computeDistances(bbox, x, y, 1)
 	}
 
 }
@@ -298,6 +529,30 @@ func BenchmarkSimpleRTree_LoadPooled(b *testing.B) {
 	}
 }
 
+// Compare building a new tree on each frame with rebuilding the same one
+func BenchmarkSimpleRTree_RebuildFrames(b *testing.B) {
+	const size = 100000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	fp := FlatPoints(points)
+	b.Run("Load", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			_ = NewWithOptions(Options{UnsafeConcurrencyMode: true}).Load(fp)
+		}
+	})
+	b.Run("Rebuild", func(b *testing.B) {
+		r := NewWithOptions(Options{UnsafeConcurrencyMode: true}).Load(fp)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			_ = r.Rebuild(fp)
+		}
+	})
+}
+
 func BenchmarkSimpleRTree_FindNearestPoint(b *testing.B) {
 	benchmarks := []struct {
 		name string
@@ -329,6 +584,30 @@ func BenchmarkSimpleRTree_FindNearestPoint(b *testing.B) {
 	}
 }
 
+func BenchmarkSimpleRTree_FindNearestPointSortKey(b *testing.B) {
+	const size = 100000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	b.Run("STR", func(b *testing.B) {
+		fp := FlatPoints(append([]float64{}, points...))
+		r := NewWithOptions(Options{UnsafeConcurrencyMode: true}).Load(fp)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			_, _, _ = r.FindNearestPoint(rand.Float64(), rand.Float64())
+		}
+	})
+	b.Run("Morton", func(b *testing.B) {
+		fp := FlatPoints(append([]float64{}, points...))
+		r := NewWithOptions(Options{UnsafeConcurrencyMode: true}).LoadWithSortKey(fp, unitSquareMorton)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			_, _, _ = r.FindNearestPoint(rand.Float64(), rand.Float64())
+		}
+	})
+}
+
 func BenchmarkSimpleRTree_FindNearestPointHilbert(b *testing.B) {
 	benchmarks := []struct {
 		name string
@@ -436,6 +715,32 @@ func BenchmarkSimpleRTree_LoadMemory(b *testing.B) {
 	}
 }
 
+// unitSquareMorton is a z-order curve for points in [0, 1] x [0, 1]
+func unitSquareMorton(x, y float64) uint64 {
+	return interleave(uint32(x*math.MaxUint32), uint32(y*math.MaxUint32))
+}
+
+// assertBBoxesContainChildren checks that the bbox of every node contains its children and points
+func assertBBoxesContainChildren(t *testing.T, r *SimpleRTree) {
+	for i := range r.nodes {
+		n := &r.nodes[i]
+		if n.nodeType == preleaf_node {
+			for j := n.firstPointIndex(); j < n.firstPointIndex()+int(n.nChildren); j++ {
+				x, y := r.getPointAt(j)
+				assert.True(t, n.BBox.ToBBox().containsPoint(x, y), "Node %d contains point %d", i, j)
+			}
+			continue
+		}
+		if i == 0 {
+			// root bbox is not always computed
+			continue
+		}
+		for j := n.firstChildIndex(); j < n.firstChildIndex()+int(n.nChildren); j++ {
+			assert.True(t, n.BBox.ToBBox().contains(r.nodes[j].BBox.ToBBox()), "Node %d contains node %d", i, j)
+		}
+	}
+}
+
 func (fp FlatPoints) linearClosestPoint(x, y float64) (x1, y1, d float64) {
 	d = math.Inf(1)
 	for i := 0; i < fp.Len(); i++ {
@@ -457,3 +762,123 @@ func ExampleSimpleRTree_FindNearestPoint() {
 	// Output:
 	// x1 == 1.000000, y1 == 1.000000, d == 8.000000
 }
+
+func TestSimpleRTree_InsideEpsilon(t *testing.T) {
+	// just inside the right side of the bbox, the distance to the left side rounds to the width of the bbox, so the
+	// exact comparison classifies the query as outside and adds the distance to the right side, 1.2e-32
+	bbox := VectorBBox{0.2, 0, 0.9, 1}
+	x := math.Nextafter(0.9, 0)
+	mind, _ := computeDistances(bbox, x, 0.5, 1)
+	assert.True(t, mind > 0)
+	mind, _ = computeDistances(bbox, x, 0.5, 1+1e-12)
+	assert.Zero(t, mind)
+	assert.Equal(t, 1., New().insideFactor, "Exact by default")
+	assert.Equal(t, 1., NewWithOptions(Options{InsideEpsilon: -1}).insideFactor)
+
+	// grid with floating errors, queries lie on the boundaries of the bboxes
+	const side = 100
+	points := make([]float64, 0, side*side*2)
+	for i := 0; i < side; i++ {
+		for j := 0; j < side; j++ {
+			points = append(points, float64(i)*0.1, float64(j)*0.3/3)
+		}
+	}
+	r := New().Load(FlatPoints(append([]float64{}, points...)))
+	rEpsilon := NewWithOptions(Options{InsideEpsilon: 1e-12}).Load(FlatPoints(append([]float64{}, points...)))
+	fp := FlatPoints(points)
+	for i := 0; i < 2000; i++ {
+		x, y := float64(rand.Intn(side))*0.1, rand.Float64()*side*0.1
+		if i%2 == 0 {
+			x, y = y, x
+		}
+		_, _, d := fp.linearClosestPoint(x, y)
+		res, _ := r.findNearestPointWithin(x, y, math.Inf(1), nil)
+		assert.Equal(t, d, res.Distance)
+		res, _ = rEpsilon.findNearestPointWithin(x, y, math.Inf(1), nil)
+		assert.Equal(t, d, res.Distance)
+	}
+}
+
+func TestSimpleRTree_BalancedLeaves(t *testing.T) {
+	leafSizes := func(r *SimpleRTree) (min, max int) {
+		min = math.MaxInt32
+		for _, leaf := range r.cellNodes(0) {
+			size := int(r.nodes[leaf].nChildren)
+			if size < min {
+				min = size
+			}
+			if size > max {
+				max = size
+			}
+		}
+		return
+	}
+	for _, size := range []int{10, 100, 1000, 7000, 50000} {
+		for _, options := range []Options{{BalancedLeaves: true}, {BalancedLeaves: true, MAX_ENTRIES: 4}, {BalancedLeaves: true, QueryAspectRatio: 4}} {
+			points := generateDataset(uniformDataset, size, rand.Int63())
+			r := NewWithOptions(options).Load(points)
+			assertBBoxesContainChildren(t, r)
+			assert.NoError(t, r.verifyBBoxes())
+			min, max := leafSizes(r)
+			assert.LessOrEqual(t, max-min, 1+max/4, "Leaves of %d points have between %d and %d", size, min, max)
+			for n := 0; n < 50; n++ {
+				x, y := rand.Float64(), rand.Float64()
+				_, _, d1 := r.FindNearestPoint(x, y)
+				_, _, d2 := points.linearClosestPoint(x, y)
+				assert.Equal(t, d2, d1)
+			}
+			box := randomBBox(0.3)
+			results := r.Search(box)
+			sort.Ints(results)
+			assert.Equal(t, points.linearSearch(box), results)
+		}
+	}
+	// without the option remainders give much smaller leaves
+	min, max := leafSizes(New().Load(generateDataset(uniformDataset, 7000, 1)))
+	assert.Greater(t, max-min, 1+max/4)
+}
+
+func BenchmarkSimpleRTree_BalancedLeaves(b *testing.B) {
+	const size = 50000
+	points := generateDataset(uniformDataset, size, 1)
+	queries := generateDataset(uniformDataset, 1000, 2)
+	for _, balanced := range []bool{false, true} {
+		b.Run(fmt.Sprintf("Balanced=%v", balanced), func(b *testing.B) {
+			r := NewWithOptions(Options{BalancedLeaves: balanced}).Load(append(FlatPoints(nil), points...))
+			var sum, sumSquares float64
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				x, y := queries.GetPointAt(n % queries.Len())
+				visited := 0
+				r.findNearestPointWithin(x, y, math.Inf(1), &visited)
+				sum += float64(visited)
+				sumSquares += float64(visited * visited)
+			}
+			mean := sum / float64(b.N)
+			b.ReportMetric(mean, "visits/op")
+			b.ReportMetric(math.Sqrt(sumSquares/float64(b.N)-mean*mean), "visits-stddev")
+		})
+	}
+}
+
+// Small MAX_ENTRIES can need more nodes than the preallocated capacity, so the nodes array is reallocated while
+// buildNodeDownwards writes to it
+func TestSimpleRTree_NodesGrowDuringBuild(t *testing.T) {
+	grown := 0
+	for maxEntries := 2; maxEntries <= 4; maxEntries++ {
+		for size := 1; size < 300; size++ {
+			points := generateDataset(uniformDataset, size, int64(size))
+			r := NewWithOptions(Options{MAX_ENTRIES: maxEntries}).Load(append(FlatPoints{}, points...))
+			if len(r.nodes) > computeSize(size) {
+				grown++
+			}
+			assertBBoxesContainChildren(t, r)
+			assert.Len(t, r.Search(r.rootBBox().ToBBox()), size)
+			x, y := rand.Float64(), rand.Float64()
+			_, _, d := points.linearClosestPoint(x, y)
+			_, _, d2 := r.FindNearestPoint(x, y)
+			assert.Equal(t, d, d2)
+		}
+	}
+	assert.NotZero(t, grown, "Some builds reallocate the nodes")
+}
@@ -0,0 +1,80 @@
+package SimpleRTree
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// attrPoints are the points of a tree loaded with LoadWithAttrs, attribute values are swapped together with the points
+type attrPoints struct {
+	points FlatPoints
+	values [][]float64
+}
+
+func (a attrPoints) Len() int {
+	return a.points.Len()
+}
+
+func (a attrPoints) Swap(i, j int) {
+	a.points.Swap(i, j)
+	for _, v := range a.values {
+		v[i], v[j] = v[j], v[i]
+	}
+}
+
+func (a attrPoints) GetPointAt(i int) (x, y float64) {
+	return a.points.GetPointAt(i)
+}
+
+// LoadWithAttrs builds the RTree over points with several named values per point, such as elevation or category,
+// which are reordered together with the points so that attrs[name][i] stays the value of the point at position i.
+// Values of a point are returned by Attrs and FindNearestWithAttrs. Every slice must have one value per point.
+// Rebuild drops the attributes, it does not receive new values. RebuildRegion keeps the values of every point.
+//
+// Note: rtree is assumed to have sole access to points and to the slices of attrs, it will reorder them together
+func (r *SimpleRTree) LoadWithAttrs(points FlatPoints, attrs map[string][]float64) *SimpleRTree {
+	names := make([]string, 0, len(attrs))
+	for name, values := range attrs {
+		if len(values) != points.Len() {
+			panic(fmt.Sprintf("attribute %q has %d values for %d points", name, len(values), points.Len()))
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	a := attrPoints{points: points, values: make([][]float64, len(names))}
+	for i, name := range names {
+		a.values[i] = attrs[name]
+	}
+	r.load(a, false)
+	// the points were sorted in place, from now on they are read directly as in Load
+	r.points, r.source, r.leafPoints = points, nil, nil
+	r.attrs = attrs
+	return r
+}
+
+// Attrs returns the values of the attributes of the point at position idx, see LoadWithAttrs.
+// It returns nil if the tree was not loaded with attributes
+func (r *SimpleRTree) Attrs(idx int) map[string]float64 {
+	if r.attrs == nil {
+		return nil
+	}
+	values := make(map[string]float64, len(r.attrs))
+	for name, v := range r.attrs {
+		values[name] = v[idx]
+	}
+	return values
+}
+
+// FindNearestWithAttrs returns the closest point to x and y together with the values of its attributes, see
+// LoadWithAttrs. attrs is nil if the tree was not loaded with attributes
+func (r *SimpleRTree) FindNearestWithAttrs(x, y float64) (res Result, attrs map[string]float64, found bool) {
+	res, found = r.findNearestPointWithin(x, y, math.Inf(1), nil)
+	if !found {
+		return
+	}
+	if r.options.RobustDistance {
+		res.Distance = r.finalDistance(res.X, res.Y, x, y)
+	}
+	return res, r.Attrs(res.Index), true
+}
@@ -0,0 +1,57 @@
+package SimpleRTree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRTree_LoadWithAttrs(t *testing.T) {
+	const size = 5000
+	points := generateDataset(clusteredDataset, size, rand.Int63())
+	original := append(FlatPoints(nil), points...)
+	elevation := make([]float64, size)
+	category := make([]float64, size)
+	id := make([]float64, size)
+	for i := range id {
+		x, y := points.GetPointAt(i)
+		elevation[i] = x + y
+		category[i] = float64(rand.Intn(5))
+		id[i] = float64(i)
+	}
+	originalCategory := append([]float64(nil), category...)
+	r := New().LoadWithAttrs(points, map[string][]float64{"elevation": elevation, "category": category, "id": id})
+	// every attribute still belongs to its point
+	for i := 0; i < size; i++ {
+		x, y := points.GetPointAt(i)
+		j := int(id[i])
+		ox, oy := original.GetPointAt(j)
+		assert.Equal(t, [2]float64{ox, oy}, [2]float64{x, y})
+		assert.Equal(t, x+y, elevation[i])
+		assert.Equal(t, originalCategory[j], category[i])
+		assert.Equal(t, map[string]float64{"elevation": elevation[i], "category": category[i], "id": id[i]}, r.Attrs(i))
+	}
+	for n := 0; n < 100; n++ {
+		x, y := rand.Float64(), rand.Float64()
+		res, attrs, found := r.FindNearestWithAttrs(x, y)
+		assert.True(t, found)
+		_, _, d := original.linearClosestPoint(x, y)
+		assert.Equal(t, d, res.Distance)
+		assert.Equal(t, res.X+res.Y, attrs["elevation"])
+		ox, oy := original.GetPointAt(int(attrs["id"]))
+		assert.Equal(t, [2]float64{ox, oy}, [2]float64{res.X, res.Y})
+	}
+
+	assert.Panics(t, func() {
+		New().LoadWithAttrs(FlatPoints{0, 0, 1, 1}, map[string][]float64{"a": {1, 2}, "b": {1}})
+	})
+	p := New().Load(FlatPoints{0, 0, 1, 1})
+	res, attrs, found := p.FindNearestWithAttrs(0.9, 0.9)
+	assert.True(t, found)
+	assert.Nil(t, attrs)
+	assert.Equal(t, 1., res.X)
+	assert.NoError(t, r.Rebuild(FlatPoints{0, 0, math.Pi, 1}))
+	assert.Nil(t, r.Attrs(0))
+}
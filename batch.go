@@ -0,0 +1,183 @@
+package SimpleRTree
+
+import (
+	"math"
+	"sort"
+)
+
+// EmptyResults selects how FindNearestPoints reports the queries that find no point
+type EmptyResults uint8
+
+const (
+	EmptyResultsSentinel EmptyResults = iota // a Result with Index -1 and infinite Distance, results are aligned with the queries
+	EmptyResultsSkip                         // left out, results only hold the points found, in the order of their queries
+	EmptyResultsMask                         // as EmptyResultsSentinel, and found tells for each query whether it found a point
+)
+
+// FindNearestPoints returns the closest point to each point of queries within the distance squared dsquared, as
+// FindNearestPointWithin, use math.Inf(1) for no limit. Queries that find no point, because the tree is empty or
+// every point is farther than dsquared, are reported as set in Options.EmptyResults: by default with a sentinel
+// Result whose Index is -1, so that results[i] is the answer to query i. found is only returned with
+// EmptyResultsMask, otherwise it is nil
+func (r *SimpleRTree) FindNearestPoints(queries FlatPoints, dsquared float64) (results []Result, found []bool) {
+	results = make([]Result, 0, queries.Len())
+	if r.options.EmptyResults == EmptyResultsMask {
+		found = make([]bool, queries.Len())
+	}
+	for q := 0; q < queries.Len(); q++ {
+		x, y := queries.GetPointAt(q)
+		res, ok := r.findNearestPointWithin(x, y, dsquared, nil)
+		if ok && r.options.RobustDistance {
+			res.Distance = r.finalDistance(res.X, res.Y, x, y)
+		}
+		switch {
+		case ok:
+			results = append(results, res)
+			if found != nil {
+				found[q] = true
+			}
+		case r.options.EmptyResults != EmptyResultsSkip:
+			results = append(results, Result{Index: -1, Distance: math.Inf(1)})
+		}
+	}
+	return results, found
+}
+
+// kNearestScratch is the state FindNearestKBatch reuses across its queries
+type kNearestScratch struct {
+	heap     []Result        // max heap on Distance of the closest points found so far, bounded to k
+	stack    []traversalItem // nodes waiting to be visited, the closest on top
+	children []traversalItem
+}
+
+// FindNearestKBatch returns the k closest points to each point of queries in increasing order of distance, as
+// FindNearestKContext would for each of them, results[i] being the answer to query i. Queries with fewer than k
+// enabled points in the tree get all of them.
+// It is meant for large batches, such as building the kNN graph of a dataset against another: queries are visited
+// along a Hilbert curve, so that consecutive ones go through the same nodes, and they share a single bounded heap and
+// traversal stack. The results of all the queries are carved out of a single allocation
+func (r *SimpleRTree) FindNearestKBatch(k int, queries FlatPoints) [][]Result {
+	results := make([][]Result, queries.Len())
+	if k <= 0 || !r.built || len(r.nodes) == 0 {
+		return results
+	}
+	if n := r.getLen(); k > n {
+		k = n
+	}
+	order := make([]int, queries.Len())
+	keys := make([]uint64, queries.Len())
+	key := HilbertSortKey(r.rootBBox().ToBBox())
+	for q := range order {
+		order[q] = q
+		keys[q] = key(queries.GetPointAt(q))
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return keys[order[a]] < keys[order[b]]
+	})
+
+	s := kNearestScratch{
+		heap:  make([]Result, 0, k),
+		stack: make([]traversalItem, 0, r.height*r.options.MAX_ENTRIES+1),
+	}
+	backing := make([]Result, 0, k*queries.Len())
+	for _, q := range order {
+		x, y := queries.GetPointAt(q)
+		r.findNearestKScratch(&s, k, x, y)
+		start := len(backing)
+		backing = append(backing, s.heap...)
+		results[q] = backing[start:len(backing):len(backing)]
+	}
+	return results
+}
+
+// findNearestKScratch leaves in s.heap the k closest enabled points to x and y in increasing order of distance. It is
+// a depth first branch and bound: children are visited closest first, and nodes no closer than the k-th point found
+// so far are pruned
+func (r *SimpleRTree) findNearestKScratch(s *kNearestScratch, k int, x, y float64) {
+	s.heap = s.heap[:0]
+	// root bbox is not always computed, so it is always visited
+	s.stack = append(s.stack[:0], traversalItem{index: 0, priority: math.Inf(-1)})
+	for len(s.stack) > 0 {
+		item := s.stack[len(s.stack)-1]
+		s.stack = s.stack[:len(s.stack)-1]
+		if len(s.heap) == k && item.priority >= s.heap[0].Distance {
+			continue
+		}
+		n := &r.nodes[item.index]
+		if n.nodeType == preleaf_node {
+			start := n.firstPointIndex()
+			for i := start; i < start+int(n.nChildren); i++ {
+				if r.disabled != nil && r.disabled[i] {
+					continue
+				}
+				px, py, d := r.pointDistance(i, x, y)
+				s.pushBounded(k, Result{Index: i, X: px, Y: py, Distance: d})
+			}
+			continue
+		}
+		// pushed farthest first so that the closest child is popped next
+		s.children = s.children[:0]
+		first := n.firstChildIndex()
+		for i := first; i < first+int(n.nChildren); i++ {
+			mind, _ := computeDistances(r.nodes[i].BBox, x, y, r.insideFactor)
+			if len(s.heap) == k && mind >= s.heap[0].Distance {
+				continue
+			}
+			c := traversalItem{index: i, priority: mind}
+			j := len(s.children)
+			s.children = append(s.children, c)
+			for ; j > 0 && s.children[j-1].priority < mind; j-- {
+				s.children[j] = s.children[j-1]
+			}
+			s.children[j] = c
+		}
+		s.stack = append(s.stack, s.children...)
+	}
+	// heap sort in place, the largest distance goes to the end
+	for end := len(s.heap) - 1; end > 0; end-- {
+		s.heap[0], s.heap[end] = s.heap[end], s.heap[0]
+		siftDownResults(s.heap[:end], 0)
+	}
+}
+
+// pushBounded adds res to the heap if it holds fewer than k points or res is closer than the farthest of them, which
+// is then dropped
+func (s *kNearestScratch) pushBounded(k int, res Result) {
+	h := s.heap
+	if len(h) < k {
+		h = append(h, res)
+		for i := len(h) - 1; i > 0; {
+			parent := (i - 1) / 2
+			if h[parent].Distance >= h[i].Distance {
+				break
+			}
+			h[parent], h[i] = h[i], h[parent]
+			i = parent
+		}
+		s.heap = h
+		return
+	}
+	if res.Distance < h[0].Distance {
+		h[0] = res
+		siftDownResults(h, 0)
+	}
+}
+
+// siftDownResults restores the max heap on Distance below position i
+func siftDownResults(h []Result, i int) {
+	for {
+		largest := i
+		left, right := 2*i+1, 2*i+2
+		if left < len(h) && h[left].Distance > h[largest].Distance {
+			largest = left
+		}
+		if right < len(h) && h[right].Distance > h[largest].Distance {
+			largest = right
+		}
+		if largest == i {
+			return
+		}
+		h[i], h[largest] = h[largest], h[i]
+		i = largest
+	}
+}
@@ -0,0 +1,140 @@
+package SimpleRTree
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRTree_FindNearestPoints(t *testing.T) {
+	const size = 1000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	queries := make(FlatPoints, 200*2)
+	for i := range queries {
+		queries[i] = rand.Float64()*3 - 1
+	}
+	const dsquared = 0.01
+	hits := 0
+	for q := 0; q < queries.Len(); q++ {
+		x, y := queries.GetPointAt(q)
+		if _, _, d := points.linearClosestPoint(x, y); d <= dsquared {
+			hits++
+		}
+	}
+	// queries must mix hits and misses
+	assert.True(t, hits > 0 && hits < queries.Len())
+
+	for _, mode := range []EmptyResults{EmptyResultsSentinel, EmptyResultsSkip, EmptyResultsMask} {
+		r := NewWithOptions(Options{EmptyResults: mode}).Load(append(FlatPoints(nil), points...))
+		results, found := r.FindNearestPoints(queries, dsquared)
+		if mode == EmptyResultsSkip {
+			assert.Len(t, results, hits)
+		} else {
+			assert.Len(t, results, queries.Len())
+		}
+		if mode == EmptyResultsMask {
+			assert.Len(t, found, queries.Len())
+		} else {
+			assert.Nil(t, found)
+		}
+		next := 0
+		for q := 0; q < queries.Len(); q++ {
+			x, y := queries.GetPointAt(q)
+			x1, y1, d1, ok := r.FindNearestPointWithin(x, y, dsquared)
+			if found != nil {
+				assert.Equal(t, ok, found[q])
+			}
+			if !ok {
+				if mode != EmptyResultsSkip {
+					assert.Equal(t, -1, results[next].Index)
+					assert.True(t, math.IsInf(results[next].Distance, 1))
+					next++
+				}
+				continue
+			}
+			assert.Equal(t, [3]float64{x1, y1, d1}, [3]float64{results[next].X, results[next].Y, results[next].Distance})
+			next++
+		}
+		assert.Equal(t, len(results), next)
+	}
+
+	// an empty tree finds nothing
+	results, _ := New().FindNearestPoints(FlatPoints{0, 0, 1, 1}, math.Inf(1))
+	assert.Equal(t, []Result{{Index: -1, Distance: math.Inf(1)}, {Index: -1, Distance: math.Inf(1)}}, results)
+	results, _ = NewWithOptions(Options{EmptyResults: EmptyResultsSkip}).FindNearestPoints(FlatPoints{0, 0}, math.Inf(1))
+	assert.Empty(t, results)
+}
+
+func TestSimpleRTree_FindNearestKBatch(t *testing.T) {
+	queries := make(FlatPoints, 500*2)
+	for i := range queries {
+		queries[i] = rand.Float64()*1.2 - 0.1
+	}
+	for _, kind := range datasetKinds {
+		points := generateDataset(kind, 3000, 1)
+		r := New().Load(points)
+		for i := 0; i < r.getLen(); i += 7 {
+			r.SetEnabled(i, false)
+		}
+		for _, k := range []int{1, 5, 40} {
+			batch := r.FindNearestKBatch(k, queries)
+			assert.Len(t, batch, queries.Len())
+			for q := 0; q < queries.Len(); q++ {
+				x, y := queries.GetPointAt(q)
+				expected, _ := r.FindNearestKContext(context.Background(), k, x, y)
+				assert.Len(t, batch[q], len(expected), "%v k=%d", kind, k)
+				seen := map[int]bool{}
+				for j, res := range batch[q] {
+					// ties can be broken differently, distances are the same
+					assert.Equal(t, expected[j].Distance, res.Distance, "%v k=%d", kind, k)
+					_, _, d := r.pointDistance(res.Index, x, y)
+					assert.Equal(t, d, res.Distance)
+					assert.False(t, seen[res.Index] || r.disabled[res.Index])
+					seen[res.Index] = true
+				}
+			}
+		}
+	}
+
+	r := New().Load(FlatPoints{0, 0, 1, 1, 2, 2})
+	batch := r.FindNearestKBatch(10, FlatPoints{0.9, 0.9, 5, 5})
+	assert.Equal(t, []int{1, 0, 2}, []int{batch[0][0].Index, batch[0][1].Index, batch[0][2].Index})
+	assert.Equal(t, []int{2, 1, 0}, []int{batch[1][0].Index, batch[1][1].Index, batch[1][2].Index})
+	// results do not share capacity
+	assert.Equal(t, 3, cap(batch[0]))
+	assert.Equal(t, [][]Result{nil}, r.FindNearestKBatch(0, FlatPoints{0, 0}))
+	assert.Equal(t, [][]Result{nil}, New().FindNearestKBatch(3, FlatPoints{0, 0}))
+}
+
+func BenchmarkSimpleRTree_FindNearestKBatch(b *testing.B) {
+	const size = 100000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	queries := make(FlatPoints, 10000*2)
+	for i := range queries {
+		queries[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	const k = 10
+	b.Run("PerQuery", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for q := 0; q < queries.Len(); q++ {
+				x, y := queries.GetPointAt(q)
+				r.FindNearestKContext(context.Background(), k, x, y)
+			}
+		}
+	})
+	b.Run("Batch", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			r.FindNearestKBatch(k, queries)
+		}
+	})
+}
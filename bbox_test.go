@@ -2,26 +2,42 @@ package SimpleRTree
 
 import (
 	"github.com/stretchr/testify/assert"
+	"math/rand"
 	"testing"
 )
 
 func TestBBox_Extend(t *testing.T) {
 	testCases := []struct {
-		b1, b2   rBBox
-		expected rBBox
+		b1, b2   BBox
+		expected BBox
 	}{
 		{
-			b1:       rBBox{0, 0, 1, 1},
-			b2:       rBBox{1, 1, 2, 2},
-			expected: rBBox{0, 0, 2, 2},
+			b1:       BBox{0, 0, 1, 1},
+			b2:       BBox{1, 1, 2, 2},
+			expected: BBox{0, 0, 2, 2},
 		},
 	}
 
 	for _, tc := range testCases {
-		aB1 := bbox2VectorBBox(tc.b1)
-		aB2 := bbox2VectorBBox(tc.b2)
-		result := vectorBBoxExtend(aB1, aB2)
-		assert.Equal(t, tc.expected, result.toBBox())
+		aB1 := BBoxToVector(tc.b1)
+		aB2 := BBoxToVector(tc.b2)
+		result := VectorBBoxExtend(aB1, aB2)
+		assert.Equal(t, tc.expected, result.ToBBox())
 		assert.Equal(t, tc.expected, tc.b1.extend(tc.b2))
 	}
 }
+
+func TestVectorBBox_RoundTrip(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		b := randomBBox(rand.Float64())
+		vb := BBoxToVector(b)
+		assert.Equal(t, b, vb.ToBBox())
+		assert.Equal(t, vb, BBoxToVector(vb.ToBBox()))
+		assert.Equal(t, VectorBBox{b.MinX, b.MinY, b.MaxX, b.MaxY}, vb)
+		assert.Equal(t, b.MinX, vb[VECTOR_BBOX_MIN_X])
+		assert.Equal(t, b.MinY, vb[VECTOR_BBOX_MIN_Y])
+		assert.Equal(t, b.MaxX, vb[VECTOR_BBOX_MAX_X])
+		assert.Equal(t, b.MaxY, vb[VECTOR_BBOX_MAX_Y])
+		assert.Equal(t, vb, VectorBBoxExtend(vb, vb))
+	}
+}
@@ -0,0 +1,59 @@
+package SimpleRTree
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Builder collects points from several goroutines and builds a tree with all of them, for ingestion pipelines where
+// producers generate points concurrently. AddBatch can be called from any number of goroutines, each batch is
+// appended under a lock, so batches should be large enough for the lock not to dominate.
+//
+// The order of the points in the tree depends on how batches interleave, so positions in results differ between
+// runs, but the tree holds the same points as a serial build
+type Builder struct {
+	mu       sync.Mutex
+	options  Options
+	points   FlatPoints
+	finished bool
+}
+
+// NewBuilder creates a Builder whose tree will be built with the given options
+func NewBuilder(o Options) *Builder {
+	return &Builder{options: o}
+}
+
+// AddBatch adds points given as x and y pairs, as in FlatPoints. points are copied, the caller can reuse the slice.
+// It panics if points has an odd length or if Finish was already called
+func (b *Builder) AddBatch(points []float64) {
+	if len(points)%2 != 0 {
+		panic(fmt.Sprintf("batch of odd length %d, points are x and y pairs", len(points)))
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.finished {
+		panic("AddBatch called after Finish")
+	}
+	b.points = append(b.points, points...)
+}
+
+// Len returns the number of points added so far
+func (b *Builder) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.points.Len()
+}
+
+// Finish builds the tree with all the points added, as Load. Producers must be done before calling it, it panics
+// if called twice and AddBatch panics afterwards. As in Load, with no points the tree is left empty
+func (b *Builder) Finish() *SimpleRTree {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.finished {
+		panic("Finish called twice")
+	}
+	b.finished = true
+	points := b.points
+	b.points = nil
+	return NewWithOptions(b.options).Load(points)
+}
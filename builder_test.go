@@ -0,0 +1,53 @@
+package SimpleRTree
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRTree_Builder(t *testing.T) {
+	const producers, batches, batchSize = 8, 50, 40
+	all := make([]FlatPoints, producers)
+	b := NewBuilder(Options{})
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		all[p] = generateDataset(datasetKinds[p%len(datasetKinds)], batches*batchSize, int64(p))
+		wg.Add(1)
+		go func(points FlatPoints) {
+			defer wg.Done()
+			batch := make([]float64, 0, 2*batchSize)
+			for i := 0; i < batches; i++ {
+				batch = append(batch[:0], points[2*i*batchSize:2*(i+1)*batchSize]...)
+				b.AddBatch(batch)
+			}
+		}(all[p])
+	}
+	wg.Wait()
+	assert.Equal(t, producers*batches*batchSize, b.Len())
+	r := b.Finish()
+
+	var serialPoints FlatPoints
+	for _, points := range all {
+		serialPoints = append(serialPoints, points...)
+	}
+	serial := New().Load(append(FlatPoints(nil), serialPoints...))
+	assert.Equal(t, serial.getLen(), r.getLen())
+	assert.Equal(t, sortedPoints(serialPoints), sortedPoints(r.LeafPointsInOrder()))
+	assertBBoxesContainChildren(t, r)
+	for n := 0; n < 500; n++ {
+		x, y := rand.Float64(), rand.Float64()
+		_, _, d1 := serial.FindNearestPoint(x, y)
+		_, _, d2 := r.FindNearestPoint(x, y)
+		assert.Equal(t, d1, d2)
+		box := randomBBox(0.2)
+		assert.Equal(t, sortedPoints(serial.SearchInto(box, nil)), sortedPoints(r.SearchInto(box, nil)))
+	}
+
+	assert.Panics(t, func() { b.AddBatch([]float64{0, 0}) })
+	assert.Panics(t, func() { b.Finish() })
+	assert.Panics(t, func() { NewBuilder(Options{}).AddBatch([]float64{0}) })
+	assert.Empty(t, NewBuilder(Options{}).Finish().Search(BBox{0, 0, 1, 1}))
+}
@@ -0,0 +1,125 @@
+package SimpleRTree
+
+import (
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// nodesCapacity returns the capacity of the nodes allocated to build a tree of n points. By default it is an upper
+// bound, n nodes, which is cheap to compute. With Options.MaxBuildMemory it is the exact number of nodes, when it
+// does not depend on the coordinates of the points
+func (r *SimpleRTree) nodesCapacity(n int) int {
+	if r.options.MaxBuildMemory > 0 {
+		if count, ok := r.nodeCount(n); ok {
+			return count
+		}
+	}
+	return computeSize(n)
+}
+
+// nodeCount returns the number of nodes of the tree of n points, following the same splits as the build. It
+// returns false for STR trees with Options.QueryAspectRatio, whose splits depend on the extent of the points
+func (r *SimpleRTree) nodeCount(n int) (int, bool) {
+	maxEntries := r.options.MAX_ENTRIES
+	if r.options.TreeType != STR || r.sortKey != nil {
+		leafSize := maxEntries
+		if maxHeight, _ := r.heightCap(n); maxHeight > 0 {
+			leafSize = cappedLeafSize(n, maxEntries, maxHeight)
+		}
+		// the root, then the levels from the leaves up to the children of the root
+		buckets := (n + leafSize - 1) / leafSize
+		count := 1 + buckets
+		for buckets > maxEntries {
+			buckets = (buckets + maxEntries - 1) / maxEntries
+			count += buckets
+		}
+		return count, true
+	}
+	if r.queryAspectRatio != 1 {
+		return 0, false
+	}
+	height := strHeight(n, maxEntries)
+	if maxHeight, _ := r.heightCap(n); maxHeight > 0 && height > maxHeight {
+		height = maxHeight
+	}
+	// the number of nodes under a node only depends on its number of points and its height
+	memo := make(map[[2]int]int)
+	var count func(N, height int) int
+	count = func(N, height int) int {
+		if N <= maxEntries || height <= 1 {
+			return 1
+		}
+		if c, ok := memo[[2]int{N, height}]; ok {
+			return c
+		}
+		// as in buildNodeDownwards
+		leafSize := math.Max(float64(maxEntries), math.Ceil(float64(N)/math.Pow(float64(maxEntries), float64(height-1))))
+		M := math.Ceil(float64(N) / (leafSize * math.Pow(float64(maxEntries), float64(height-2))))
+		c := 1
+		if r.options.BalancedLeaves {
+			for k := 0; k < int(M); k++ {
+				c += count(N*(k+1)/int(M)-N*k/int(M), height-1)
+			}
+		} else {
+			N2 := int(math.Ceil(float64(N) / M))
+			N1 := N2 * int(math.Ceil(math.Sqrt(M)))
+			for i := 0; i < N; i += N1 {
+				right2 := minInt(i+N1, N)
+				for j := i; j < right2; j += N2 {
+					c += count(minInt(j+N2, right2)-j, height-1)
+				}
+			}
+		}
+		memo[[2]int{N, height}] = c
+		return c
+	}
+	return count(n, height), true
+}
+
+// buildMemory returns the number of bytes a build over points allocates on top of the points, for
+// Options.MaxBuildMemory: the nodes, the keys sorted by Hilbert trees and the data kept per node or per point by
+// the options set. The memory of the nodes of a previous build is reused if it is large enough
+func (r *SimpleRTree) buildMemory(points Interface) int {
+	n := points.Len()
+	nodes := r.nodesCapacity(n)
+	size := 0
+	if cap(r.nodes) < nodes {
+		size += nodes * int(node_size)
+	}
+	perNode := 0
+	if r.options.StoreCentroids {
+		perNode += 16
+	}
+	if r.options.OrientedLeaves {
+		perNode += int(unsafe.Sizeof(orientedBox{}))
+	}
+	if r.options.BoundingCircles {
+		// and the shuffled positions of the points of a node
+		perNode += int(unsafe.Sizeof(boundingCircle{}))
+		size += n * int(unsafe.Sizeof(int(0)))
+	}
+	size += nodes * perNode
+	if r.options.TreeType != STR || r.sortKey != nil {
+		size += n * 8
+	}
+	if r.options.StoreHilbertValues {
+		size += n * 8
+	}
+	if _, ok := points.(FlatPoints); !ok && r.options.LeafOrderCopy {
+		size += n * int(flat_point_size)
+	}
+	return size
+}
+
+// checkBuildMemory returns an error wrapping ErrBuildMemoryExceeded if building the tree over points allocates more
+// than Options.MaxBuildMemory
+func (r *SimpleRTree) checkBuildMemory(points Interface) error {
+	if r.options.MaxBuildMemory == 0 {
+		return nil
+	}
+	if size := r.buildMemory(points); size > r.options.MaxBuildMemory {
+		return fmt.Errorf("%w: %d points need %d bytes, MaxBuildMemory is %d", ErrBuildMemoryExceeded, points.Len(), size, r.options.MaxBuildMemory)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package SimpleRTree
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRTree_MaxBuildMemory(t *testing.T) {
+	const size = 20000
+	optionSets := []Options{
+		{},
+		{MAX_ENTRIES: 4},
+		{BalancedLeaves: true},
+		{MaxHeight: 4},
+		{TreeType: HILBERT},
+		{TreeType: HILBERT, MAX_ENTRIES: 3, MaxHeight: 6},
+		{StoreCentroids: true, BoundingCircles: true},
+	}
+	for _, kind := range datasetKinds {
+		points := generateDataset(kind, size, 1)
+		for _, o := range optionSets {
+			name := fmt.Sprintf("%v %+v", kind, o)
+			unbounded := NewWithOptions(o).Load(append(FlatPoints(nil), points...))
+			count, ok := unbounded.nodeCount(size)
+			assert.True(t, ok)
+			assert.Equal(t, len(unbounded.nodes), count, name)
+
+			o.MaxBuildMemory = 1 << 30
+			bounded := NewWithOptions(o).Load(append(FlatPoints(nil), points...))
+			assert.Equal(t, len(bounded.nodes), cap(bounded.nodes), name)
+			assert.Equal(t, unbounded.nodes, bounded.nodes, name)
+			assert.Equal(t, unbounded.points, bounded.points, name)
+			assert.True(t, bounded.MemoryUsage() < unbounded.MemoryUsage())
+		}
+	}
+
+	// the nodes take most of the memory, only one per point when they do not fit
+	points := generateDataset(uniformDataset, size, 2)
+	r := New().Load(append(FlatPoints(nil), points...))
+	needed := NewWithOptions(Options{MaxBuildMemory: 1}).buildMemory(points)
+	assert.Equal(t, len(r.nodes)*int(node_size), needed)
+	o := Options{MaxBuildMemory: needed}
+	assert.NotPanics(t, func() {
+		NewWithOptions(o).Load(append(FlatPoints(nil), points...))
+	})
+	o.MaxBuildMemory--
+	assert.PanicsWithError(t, fmt.Sprintf("build needs more memory than MaxBuildMemory: 20000 points need %d bytes, MaxBuildMemory is %d", needed, needed-1), func() {
+		NewWithOptions(o).Load(append(FlatPoints(nil), points...))
+	})
+
+	// rebuilds reuse the nodes, growing needs new ones
+	r = NewWithOptions(Options{MaxBuildMemory: needed}).Load(append(FlatPoints(nil), points...))
+	assert.NoError(t, r.Rebuild(append(FlatPoints(nil), points[:size]...)))
+	more := append(append(FlatPoints(nil), points...), points...)
+	assert.ErrorIs(t, r.Rebuild(more), ErrBuildMemoryExceeded)
+
+	// splits of STR trees with an aspect ratio depend on the points
+	_, ok := NewWithOptions(Options{QueryAspectRatio: 3}).nodeCount(size)
+	assert.False(t, ok)
+	r = NewWithOptions(Options{QueryAspectRatio: 3, MaxBuildMemory: size * int(node_size)}).Load(append(FlatPoints(nil), points...))
+	assert.Equal(t, size, cap(r.nodes))
+}
+
+func TestSimpleRTree_MaxBuildMemorySpill(t *testing.T) {
+	const size = 20000
+	optionSets := []Options{
+		{},
+		{MAX_ENTRIES: 4},
+		{BalancedLeaves: true},
+		{MaxHeight: 4},
+		{StoreCentroids: true},
+	}
+	for _, kind := range datasetKinds {
+		points := generateDataset(kind, size, 1)
+		for _, o := range optionSets {
+			name := fmt.Sprintf("%v %+v", kind, o)
+			o.MaxBuildMemory = 1 << 30
+			inMemory := NewWithOptions(o).Load(append(FlatPoints(nil), points...))
+			// the nodes fit, the points do not
+			o.MaxBuildMemory = NewWithOptions(o).buildMemory(points)
+			assert.True(t, o.MaxBuildMemory < len(points)*8, name)
+
+			spilled := append(FlatPoints(nil), points...)
+			sorted, err := NewWithOptions(o).spillSort(spilled, false)
+			assert.NoError(t, err)
+			assert.True(t, sorted, name)
+			assert.True(t, sort.IsSorted(lexicographicSorter(spilled)), name)
+			assert.Equal(t, sortedPoints(points), sortedPoints(spilled), name)
+
+			r := NewWithOptions(o).Load(append(FlatPoints(nil), points...))
+			rebuilt := NewWithOptions(o).Load(FlatPoints{0, 0})
+			assert.NoError(t, rebuilt.Rebuild(append(FlatPoints(nil), points...)))
+			for _, tree := range []*SimpleRTree{r, rebuilt} {
+				if kind == uniformDataset || kind == clusteredDataset {
+					// x coordinates are distinct, every node holds the same points
+					assert.Equal(t, inMemory.nodes, tree.nodes, name)
+					for i := range tree.nodes {
+						if n := &tree.nodes[i]; n.nodeType == preleaf_node {
+							start, end := n.firstPointIndex(), n.firstPointIndex()+int(n.nChildren)
+							assert.Equal(t, sortedPoints(inMemory.points[2*start:2*end]), sortedPoints(tree.points[2*start:2*end]), name)
+						}
+					}
+				}
+				assert.Equal(t, len(inMemory.nodes), len(tree.nodes), name)
+				for q := 0; q < 50; q++ {
+					x, y := rand.Float64(), rand.Float64()
+					_, _, d1 := inMemory.FindNearestPoint(x, y)
+					_, _, d2 := tree.FindNearestPoint(x, y)
+					assert.Equal(t, d1, d2, name)
+					box := randomBBox(0.1)
+					assert.Equal(t, sortedPoints(inMemory.SearchInto(box, nil)), sortedPoints(tree.SearchInto(box, nil)), name)
+				}
+			}
+		}
+	}
+
+	// points that fit and other tree types are not spilled
+	points := generateDataset(uniformDataset, size, 2)
+	for _, o := range []Options{{MaxBuildMemory: len(points) * 8}, {TreeType: HILBERT, MaxBuildMemory: 1 << 16}, {}} {
+		sorted, err := NewWithOptions(o).spillSort(points, false)
+		assert.NoError(t, err)
+		assert.False(t, sorted)
+	}
+
+	// the temp file cannot be created
+	t.Setenv("TMPDIR", filepath.Join(t.TempDir(), "missing"))
+	o := Options{MaxBuildMemory: 1 << 17}
+	r := NewWithOptions(o).Load(FlatPoints{0, 0})
+	assert.Error(t, r.Rebuild(append(FlatPoints(nil), points...)))
+	assert.Equal(t, 1, r.getLen())
+	assert.Panics(t, func() {
+		NewWithOptions(o).Load(append(FlatPoints(nil), points...))
+	})
+}
+
+func TestSimpleRTree_BucketsAreExact(t *testing.T) {
+	// the spilled and in memory builds only match if buckets do not depend on the order of the points, which needs
+	// every bucket to be split, also the ones with a single extra point
+	for _, n := range []int{5, 9, 13, 100, 1001} {
+		for _, bucketSize := range []int{1, 2, 4, 7} {
+			points := generateDataset(uniformDataset, n, int64(n))
+			r := New()
+			r.points = points
+			r.sortX(nil, 0, n, bucketSize)
+			for b := bucketSize; b < n; b += bucketSize {
+				maxX := math.Inf(-1)
+				for i := b - bucketSize; i < b; i++ {
+					maxX = math.Max(maxX, points[2*i])
+				}
+				for i := b; i < n; i++ {
+					assert.True(t, maxX < points[2*i], "n %d bucketSize %d bucket %d", n, bucketSize, b/bucketSize)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkSimpleRTree_MaxBuildMemory(b *testing.B) {
+	const size = 1000000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	buffer := make(FlatPoints, len(points))
+	for _, limit := range []int{0, 1 << 30, 1 << 23} {
+		b.Run(fmt.Sprintf("MaxBuildMemory=%d", limit), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				copy(buffer, points)
+				NewWithOptions(Options{MaxBuildMemory: limit}).Load(buffer)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package SimpleRTree
+
+import (
+	"container/list"
+	"math"
+	"sync"
+)
+
+// queryCache is a least recently used cache of nearest point results, keyed on the quantized query coordinates.
+// It is safe for concurrent use
+type queryCache struct {
+	mu      sync.Mutex
+	size    int
+	quantum float64
+	order   *list.List // front is the most recently used
+	items   map[[2]float64]*list.Element
+}
+
+type queryCacheEntry struct {
+	key [2]float64
+	res Result
+}
+
+func newQueryCache(size int, quantum float64) *queryCache {
+	return &queryCache{
+		size:    size,
+		quantum: quantum,
+		order:   list.New(),
+		items:   make(map[[2]float64]*list.Element, size),
+	}
+}
+
+// quantize rounds x and y to the closest multiple of quantum, if set
+func (c *queryCache) quantize(x, y float64) (qx, qy float64) {
+	if c.quantum <= 0 {
+		return x, y
+	}
+	return math.Round(x/c.quantum) * c.quantum, math.Round(y/c.quantum) * c.quantum
+}
+
+func (c *queryCache) get(key [2]float64) (Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return Result{}, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*queryCacheEntry).res, true
+}
+
+func (c *queryCache) put(key [2]float64, res Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		e.Value.(*queryCacheEntry).res = res
+		c.order.MoveToFront(e)
+		return
+	}
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*queryCacheEntry).key)
+	}
+	c.items[key] = c.order.PushFront(&queryCacheEntry{key: key, res: res})
+}
+
+func (c *queryCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.items = make(map[[2]float64]*list.Element, c.size)
+}
+
+// findNearestPointCached answers FindNearestPoint from the query cache, searching the tree with the quantized
+// coordinates on a miss. Distance is computed from the original coordinates
+func (r *SimpleRTree) findNearestPointCached(x, y float64) (x1, y1, d1 float64) {
+	qx, qy := r.cache.quantize(x, y)
+	key := [2]float64{qx, qy}
+	res, ok := r.cache.get(key)
+	if !ok {
+		var found bool
+		res, found = r.findNearestPointWithin(qx, qy, math.Inf(1), nil)
+		if !found {
+			return
+		}
+		r.cache.put(key, res)
+	}
+	_, _, d1 = r.pointDistance(res.Index, x, y)
+	if r.options.RobustDistance {
+		d1 = r.finalDistance(res.X, res.Y, x, y)
+	}
+	return res.X, res.Y, d1
+}
@@ -0,0 +1,138 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestSimpleRTree_QueryCache(t *testing.T) {
+	const size = 10000
+	points := make([]float64, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(FlatPoints(append([]float64(nil), points...)))
+	rc := NewWithOptions(Options{QueryCache: 64}).Load(FlatPoints(append([]float64(nil), points...)))
+	queries := make([][2]float64, 100)
+	for i := range queries {
+		queries[i] = [2]float64{rand.Float64(), rand.Float64()}
+	}
+	for n := 0; n < 1000; n++ {
+		q := queries[rand.Intn(len(queries))]
+		x1, y1, d1 := r.FindNearestPoint(q[0], q[1])
+		x2, y2, d2 := rc.FindNearestPoint(q[0], q[1])
+		assert.Equal(t, x1, x2)
+		assert.Equal(t, y1, y2)
+		assert.Equal(t, d1, d2)
+	}
+	assert.Equal(t, 64, rc.cache.order.Len())
+	assert.Len(t, rc.cache.items, 64)
+}
+
+func TestSimpleRTree_QueryCacheQuantum(t *testing.T) {
+	const size = 10000
+	points := make([]float64, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(FlatPoints(append([]float64(nil), points...)))
+	rc := NewWithOptions(Options{QueryCache: 1000, QueryCacheQuantum: 0.01}).Load(FlatPoints(append([]float64(nil), points...)))
+	for n := 0; n < 1000; n++ {
+		x, y := rand.Float64(), rand.Float64()
+		qx, qy := math.Round(x/0.01)*0.01, math.Round(y/0.01)*0.01
+		x1, y1, _ := r.FindNearestPoint(qx, qy)
+		x2, y2, d2 := rc.FindNearestPoint(x, y)
+		assert.Equal(t, x1, x2)
+		assert.Equal(t, y1, y2)
+		assert.Equal(t, (x2-x)*(x2-x)+(y2-y)*(y2-y), d2)
+	}
+}
+
+func TestSimpleRTree_QueryCacheEviction(t *testing.T) {
+	c := newQueryCache(2, 0)
+	c.put([2]float64{0, 0}, Result{Index: 0})
+	c.put([2]float64{1, 1}, Result{Index: 1})
+	_, ok := c.get([2]float64{0, 0})
+	assert.True(t, ok)
+	// {1, 1} is the least recently used
+	c.put([2]float64{2, 2}, Result{Index: 2})
+	_, ok = c.get([2]float64{1, 1})
+	assert.False(t, ok)
+	res, ok := c.get([2]float64{0, 0})
+	assert.True(t, ok)
+	assert.Equal(t, 0, res.Index)
+	res, ok = c.get([2]float64{2, 2})
+	assert.True(t, ok)
+	assert.Equal(t, 2, res.Index)
+	c.clear()
+	_, ok = c.get([2]float64{2, 2})
+	assert.False(t, ok)
+}
+
+func TestSimpleRTree_QueryCacheRebuild(t *testing.T) {
+	r := NewWithOptions(Options{QueryCache: 10}).Load(FlatPoints{0, 0, 1, 1})
+	x, y, _ := r.FindNearestPoint(0.1, 0.1)
+	assert.Equal(t, [2]float64{0, 0}, [2]float64{x, y})
+	assert.NoError(t, r.Rebuild(FlatPoints{5, 5, 1, 1}))
+	x, y, _ = r.FindNearestPoint(0.1, 0.1)
+	assert.Equal(t, [2]float64{1, 1}, [2]float64{x, y})
+}
+
+func TestSimpleRTree_QueryCacheConcurrent(t *testing.T) {
+	const size = 10000
+	points := make([]float64, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(FlatPoints(append([]float64(nil), points...)))
+	rc := NewWithOptions(Options{QueryCache: 16, QueryCacheQuantum: 0.1}).Load(FlatPoints(append([]float64(nil), points...)))
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(seed))
+			for n := 0; n < 1000; n++ {
+				x, y := rnd.Float64(), rnd.Float64()
+				x1, y1, _ := r.FindNearestPoint(math.Round(x/0.1)*0.1, math.Round(y/0.1)*0.1)
+				x2, y2, _ := rc.FindNearestPoint(x, y)
+				assert.Equal(t, x1, x2)
+				assert.Equal(t, y1, y2)
+			}
+		}(int64(g))
+	}
+	wg.Wait()
+}
+
+func BenchmarkSimpleRTree_FindNearestPointCache(b *testing.B) {
+	const size = 100000
+	points := make([]float64, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	// snapping a coarse grid, the same 1000 queries are repeated
+	queries := make([][2]float64, 1000)
+	for i := range queries {
+		queries[i] = [2]float64{float64(i%40) / 40, float64(i/40) / 25}
+	}
+	benchmarks := []struct {
+		name    string
+		options Options
+	}{
+		{"NoCache", Options{}},
+		{"Cache", Options{QueryCache: 1024}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			r := NewWithOptions(bm.options).Load(FlatPoints(append([]float64(nil), points...)))
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				q := queries[n%len(queries)]
+				_, _, _ = r.FindNearestPoint(q[0], q[1])
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package SimpleRTree
+
+// computeCentroids sets the centroid of every node, the mean of the points under it, from the sums of its children
+func (r *SimpleRTree) computeCentroids() {
+	if cap(r.centroids) >= 2*len(r.nodes) {
+		r.centroids = r.centroids[:2*len(r.nodes)]
+	} else {
+		r.centroids = make([]float64, 2*len(r.nodes))
+	}
+	var sum func(i int) (sx, sy float64, n int)
+	sum = func(i int) (sx, sy float64, n int) {
+		node := &r.nodes[i]
+		if node.nodeType == preleaf_node {
+			start := node.firstPointIndex()
+			for j := start; j < start+int(node.nChildren); j++ {
+				x, y := r.getPointAt(j)
+				sx, sy = sx+x, sy+y
+			}
+			n = int(node.nChildren)
+		} else {
+			for j := node.firstChildIndex(); j < node.firstChildIndex()+int(node.nChildren); j++ {
+				cx, cy, cn := sum(j)
+				sx, sy, n = sx+cx, sy+cy, n+cn
+			}
+		}
+		r.centroids[2*i] = sx / float64(n)
+		r.centroids[2*i+1] = sy / float64(n)
+		return
+	}
+	sum(0)
+}
+
+// centroid returns the mean of the points under the node at position i, if the tree stores centroids
+func (r *SimpleRTree) centroid(i int) (x, y float64, ok bool) {
+	if r.centroids == nil {
+		return 0, 0, false
+	}
+	return r.centroids[2*i], r.centroids[2*i+1], true
+}
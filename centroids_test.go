@@ -0,0 +1,62 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestSimpleRTree_StoreCentroids(t *testing.T) {
+	const size = 5000
+	for _, options := range []Options{{}, {TreeType: HILBERT}, {MortonLeaves: true}, {MAX_ENTRIES: 3}} {
+		options.StoreCentroids = true
+		points := make(FlatPoints, size*2)
+		for i := range points {
+			points[i] = rand.Float64()
+		}
+		r := NewWithOptions(options).Load(points)
+		assertCentroids(t, r)
+
+		for _, cell := range r.CellCounts(2) {
+			assert.True(t, cell.Box.containsPoint(cell.Centroid[0], cell.Centroid[1]))
+		}
+		// the approximation at the root is the mean of all the points
+		res := r.FindNearestApproxDepth(0, 0, 0)
+		assert.Equal(t, r.centroids[:2], []float64{res.X, res.Y})
+
+		if options.TreeType == STR {
+			box := randomBBox(0.1)
+			newPoints := make(FlatPoints, 2*len(r.Search(box)))
+			for i := range newPoints {
+				newPoints[i] = rand.Float64()
+			}
+			assert.NoError(t, r.RebuildRegion(box, newPoints))
+			assertCentroids(t, r)
+		}
+		data, err := r.MarshalBinary()
+		assert.NoError(t, err)
+		decoded := NewWithOptions(Options{StoreCentroids: true})
+		assert.NoError(t, decoded.UnmarshalBinary(data))
+		assertCentroids(t, decoded)
+	}
+	r := New().Load(FlatPoints{0, 0, 1, 1})
+	assert.Nil(t, r.centroids)
+	assert.Equal(t, [2]float64{}, r.CellCounts(1)[0].Centroid)
+}
+
+// assertCentroids checks that the centroid of every node is the mean of its range of points
+func assertCentroids(t *testing.T, r *SimpleRTree) {
+	assert.Len(t, r.centroids, 2*len(r.nodes))
+	for i := range r.nodes {
+		start, end := r.nodePointRange(i)
+		var sx, sy float64
+		for j := start; j < end; j++ {
+			x, y := r.getPointAt(j)
+			sx, sy = sx+x, sy+y
+		}
+		cx, cy, ok := r.centroid(i)
+		assert.True(t, ok)
+		assert.InDelta(t, sx/float64(end-start), cx, 1e-9)
+		assert.InDelta(t, sy/float64(end-start), cy, 1e-9)
+	}
+}
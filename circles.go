@@ -0,0 +1,108 @@
+package SimpleRTree
+
+import (
+	"math"
+	"math/rand"
+)
+
+// boundingCircle is the smallest circle containing the points under a node, centered on (cx, cy) with radius r
+type boundingCircle struct {
+	cx, cy, r float64
+}
+
+// computeBoundingCircles sets the minimum bounding circle of every node, see Options.BoundingCircles. Each circle is
+// computed from the points under its node, not from the circles of its children, so it is the smallest possible.
+// Every level goes over all the points once, in expected linear time
+func (r *SimpleRTree) computeBoundingCircles() {
+	if cap(r.circles) >= len(r.nodes) {
+		r.circles = r.circles[:len(r.nodes)]
+	} else {
+		r.circles = make([]boundingCircle, len(r.nodes))
+	}
+	// points are visited in random order, which makes Welzl's algorithm linear in expectation. The seed is fixed so
+	// that builds are reproducible
+	rnd := rand.New(rand.NewSource(1))
+	order := make([]int, 0, r.getLen())
+	for i := range r.nodes {
+		start, end := r.nodePointRange(i)
+		order = order[:0]
+		for j := start; j < end; j++ {
+			order = append(order, j)
+		}
+		rnd.Shuffle(len(order), func(a, b int) {
+			order[a], order[b] = order[b], order[a]
+		})
+		r.circles[i] = r.minimumCircle(order)
+	}
+}
+
+// minimumCircle returns the smallest circle containing the points at the positions in order, with Welzl's algorithm
+// in its iterative form. The radius is grown slightly so that points on the circle are never left outside by rounding
+func (r *SimpleRTree) minimumCircle(order []int) boundingCircle {
+	if len(order) == 0 {
+		return boundingCircle{}
+	}
+	x, y := r.getPointAt(order[0])
+	c := boundingCircle{cx: x, cy: y}
+	for i := 1; i < len(order); i++ {
+		ix, iy := r.getPointAt(order[i])
+		if c.contains(ix, iy) {
+			continue
+		}
+		// point i is on the boundary of the circle of the first i + 1 points
+		c = boundingCircle{cx: ix, cy: iy}
+		for j := 0; j < i; j++ {
+			jx, jy := r.getPointAt(order[j])
+			if c.contains(jx, jy) {
+				continue
+			}
+			// and so is point j
+			c = circleOfDiameter(ix, iy, jx, jy)
+			for k := 0; k < j; k++ {
+				kx, ky := r.getPointAt(order[k])
+				if !c.contains(kx, ky) {
+					c = circumscribedCircle(ix, iy, jx, jy, kx, ky)
+				}
+			}
+		}
+	}
+	c.r += 1e-9 * (math.Abs(c.cx) + math.Abs(c.cy) + c.r)
+	return c
+}
+
+// contains returns whether (x, y) is inside the circle, with a relative tolerance for points on it
+func (c *boundingCircle) contains(x, y float64) bool {
+	return math.Hypot(x-c.cx, y-c.cy) <= c.r*(1+1e-12)
+}
+
+// distance returns the distance squared between (x, y) and the closest point of the circle, 0 inside it
+func (c *boundingCircle) distance(x, y float64) float64 {
+	d := math.Max(0, math.Hypot(x-c.cx, y-c.cy)-c.r)
+	return d * d
+}
+
+// circleOfDiameter returns the circle whose diameter is the segment between the two points
+func circleOfDiameter(x1, y1, x2, y2 float64) boundingCircle {
+	return boundingCircle{cx: (x1 + x2) / 2, cy: (y1 + y2) / 2, r: math.Hypot(x2-x1, y2-y1) / 2}
+}
+
+// circumscribedCircle returns the circle through the three points. If they are collinear, or too close to it for
+// the center to be computed accurately, it returns the circle having the two farthest points as diameter, which
+// contains the third one
+func circumscribedCircle(x1, y1, x2, y2, x3, y3 float64) boundingCircle {
+	bx, by := x2-x1, y2-y1
+	cx, cy := x3-x1, y3-y1
+	d := 2 * (bx*cy - by*cx)
+	b2, c2 := bx*bx+by*by, cx*cx+cy*cy
+	if math.Abs(d) <= 1e-12*(b2+c2) {
+		c := circleOfDiameter(x1, y1, x2, y2)
+		for _, other := range []boundingCircle{circleOfDiameter(x1, y1, x3, y3), circleOfDiameter(x2, y2, x3, y3)} {
+			if other.r > c.r {
+				c = other
+			}
+		}
+		return c
+	}
+	ux, uy := (cy*b2-by*c2)/d, (bx*c2-cx*b2)/d
+	return boundingCircle{cx: x1 + ux, cy: y1 + uy, r: math.Hypot(ux, uy)}
+}
@@ -0,0 +1,92 @@
+package SimpleRTree
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRTree_MinimumCircle(t *testing.T) {
+	c := circumscribedCircle(0, 0, 2, 0, 1, 1)
+	assert.InDelta(t, 1, c.cx, 1e-12)
+	assert.InDelta(t, 0, c.cy, 1e-12)
+	assert.InDelta(t, 1, c.r, 1e-12)
+	// collinear points get the circle of the farthest two
+	c = circumscribedCircle(0, 0, 1, 1, 3, 3)
+	assert.Equal(t, boundingCircle{cx: 1.5, cy: 1.5, r: math.Hypot(3, 3) / 2}, c)
+
+	for _, kind := range datasetKinds {
+		r := NewWithOptions(Options{BoundingCircles: true}).Load(generateDataset(kind, 5000, 1))
+		for i := range r.nodes {
+			c := r.circles[i]
+			start, end := r.nodePointRange(i)
+			for j := start; j < end; j++ {
+				x, y := r.getPointAt(j)
+				assert.Zero(t, c.distance(x, y), "%v node %d", kind, i)
+			}
+			// the smallest circle is at most the one around the bbox, and at least as wide as its longest side
+			b := r.nodes[i].BBox
+			if i == 0 {
+				b = r.rootBBox()
+			}
+			w, h := b[VECTOR_BBOX_MAX_X]-b[VECTOR_BBOX_MIN_X], b[VECTOR_BBOX_MAX_Y]-b[VECTOR_BBOX_MIN_Y]
+			tolerance := 1e-8 * (1 + math.Abs(c.cx) + math.Abs(c.cy))
+			assert.True(t, c.r <= math.Hypot(w, h)/2+tolerance, "%v node %d", kind, i)
+			assert.True(t, c.r >= math.Max(w, h)/2-tolerance, "%v node %d", kind, i)
+		}
+	}
+}
+
+func TestSimpleRTree_BoundingCircles(t *testing.T) {
+	for _, kind := range datasetKinds {
+		points := generateDataset(kind, 20000, 2)
+		r := NewWithOptions(Options{BoundingCircles: true}).Load(append(FlatPoints(nil), points...))
+		for n := 0; n < 300; n++ {
+			x, y := rand.Float64()*1.4-0.2, rand.Float64()*1.4-0.2
+			_, _, d := points.linearClosestPoint(x, y)
+			res, found := r.findNearestPointWithin(x, y, math.Inf(1), nil)
+			assert.True(t, found)
+			assert.Equal(t, d, res.Distance, "%v", kind)
+			px, py := r.getPointAt(res.Index)
+			assert.Equal(t, [2]float64{px, py}, [2]float64{res.X, res.Y})
+		}
+	}
+
+	points := generateDataset(clusteredDataset, 20000, 3)
+	box := New().Load(append(FlatPoints(nil), points...))
+	circles := NewWithOptions(Options{BoundingCircles: true}).Load(append(FlatPoints(nil), points...))
+	visitedBox, visitedCircles := 0, 0
+	for n := 0; n < 1000; n++ {
+		x, y := rand.Float64(), rand.Float64()
+		// the same traversal pruning with bboxes only
+		expected, _ := box.findNearestTight(x, y, math.Inf(1), &visitedBox)
+		res, _ := circles.findNearestPointWithin(x, y, math.Inf(1), &visitedCircles)
+		assert.Equal(t, expected.Distance, res.Distance)
+	}
+	assert.True(t, visitedCircles < visitedBox, "circles visit %d nodes and points, bboxes %d", visitedCircles, visitedBox)
+	assert.Equal(t, New().Load(append(FlatPoints(nil), points...)).MemoryUsage()+len(circles.nodes)*24, circles.MemoryUsage())
+
+	_, found := circles.findNearestPointWithin(2, 2, 0.01, nil)
+	assert.False(t, found)
+	circles.SetEnabled(0, false)
+	x, y := circles.getPointAt(0)
+	res, _ := circles.findNearestPointWithin(x, y, math.Inf(1), nil)
+	assert.NotEqual(t, 0, res.Index)
+}
+
+func BenchmarkSimpleRTree_BoundingCircles(b *testing.B) {
+	points := generateDataset(clusteredDataset, 100000, 1)
+	for _, circles := range []bool{false, true} {
+		r := NewWithOptions(Options{BoundingCircles: circles}).Load(append(FlatPoints(nil), points...))
+		b.Run(fmt.Sprintf("Circles=%v", circles), func(b *testing.B) {
+			visited := 0
+			for n := 0; n < b.N; n++ {
+				r.findNearestPointWithin(rand.Float64(), rand.Float64(), math.Inf(1), &visited)
+			}
+			b.ReportMetric(float64(visited)/float64(b.N), "visits/op")
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package SimpleRTree
+
+import (
+	"math"
+	"sort"
+)
+
+// FindNearestKClustered returns the k closest points to x and y grouped by their ancestor node at the given height,
+// for example to show clustered markers. Keys are positions of the nodes in the tree, which are stable for a
+// given build. Height is counted as in GeoJSONFilter: the root has the height of the tree and nodes holding points
+// height 1 in balanced trees. Heights above the tree group every point under the root, and points under a leaf
+// higher than the requested height are grouped under that leaf.
+// Results of each group are in increasing order of distance
+func (r *SimpleRTree) FindNearestKClustered(k, height int, x, y float64) map[int][]Result {
+	clusters := make(map[int][]Result)
+	if k <= 0 {
+		return clusters
+	}
+	n := 0
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			mind, _ := computeDistances(bbox, x, y, r.insideFactor)
+			return mind, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return d, true
+		},
+		func(i int, px, py, d float64) bool {
+			ancestor := r.ancestorAt(i, height)
+			clusters[ancestor] = append(clusters[ancestor], Result{Index: i, X: px, Y: py, Distance: d})
+			n++
+			return n < k
+		},
+	)
+	return clusters
+}
+
+// FindNearestPointWithCluster returns the closest point to x and y and the bbox of its ancestor node at the given
+// height, the cell it belongs to, for example to draw a marker with its cluster. Heights are counted as in
+// FindNearestKClustered
+func (r *SimpleRTree) FindNearestPointWithCluster(x, y float64, height int) (res Result, clusterBox BBox, found bool) {
+	res, found = r.findNearestPointWithin(x, y, math.Inf(1), nil)
+	if !found {
+		return
+	}
+	ancestor := r.ancestorAt(res.Index, height)
+	if ancestor == 0 {
+		return res, r.rootBBox().ToBBox(), true
+	}
+	return res, r.nodes[ancestor].BBox.ToBBox(), true
+}
+
+// SiblingPoints returns the points under the ancestor node at the given height of the closest point to x and y,
+// the closest point included, for example to expand the cell of a cluster. Heights are counted as in
+// FindNearestKClustered, height 1 gives the points of the leaf of the closest point.
+// Results are in the order of the tree, with their distance squared to x and y. Disabled points are left out
+func (r *SimpleRTree) SiblingPoints(x, y float64, height int) []Result {
+	res, found := r.findNearestPointWithin(x, y, math.Inf(1), nil)
+	if !found {
+		return nil
+	}
+	start, end := r.nodePointRange(r.ancestorAt(res.Index, height))
+	results := make([]Result, 0, end-start)
+	for i := start; i < end; i++ {
+		if r.disabled != nil && r.disabled[i] {
+			continue
+		}
+		px, py, d := r.pointDistance(i, x, y)
+		results = append(results, Result{Index: i, X: px, Y: py, Distance: d})
+	}
+	return results
+}
+
+// ancestorAt returns the position in nodes of the ancestor at the given height of the point at position i.
+// Nodes cover contiguous ranges of points, so it descends from the root into the child containing i
+func (r *SimpleRTree) ancestorAt(i, height int) int {
+	node := 0
+	for h := r.height; h > height && r.nodes[node].nodeType != preleaf_node; h-- {
+		n := &r.nodes[node]
+		for child := n.firstChildIndex(); child < n.firstChildIndex()+int(n.nChildren); child++ {
+			if _, end := r.nodePointRange(child); i < end {
+				node = child
+				break
+			}
+		}
+	}
+	return node
+}
+
+// FindNearestPointInDenseCell returns the closest point to x and y among those whose ancestor node at the given
+// height holds at least minCount points, so that points in sparse cells, such as isolated outliers, are skipped in
+// favour of denser cells nearby. Heights are counted as in FindNearestKClustered.
+// The number of points of a node is the length of its range of points, cells are pruned without visiting them
+func (r *SimpleRTree) FindNearestPointInDenseCell(x, y float64, height, minCount int) (res Result, found bool) {
+	if !r.built || len(r.nodes) == 0 {
+		return
+	}
+	// the root is always visited, so its cell is checked upfront
+	if height >= r.height || r.nodes[0].nodeType == preleaf_node {
+		if r.getLen() < minCount {
+			return
+		}
+	}
+	r.bestFirstNodes(
+		func(i, h int) (float64, bool) {
+			n := &r.nodes[i]
+			if h == height || (h > height && n.nodeType == preleaf_node) {
+				if start, end := r.nodePointRange(i); end-start < minCount {
+					return 0, false
+				}
+			}
+			mind, _ := computeDistances(n.BBox, x, y, r.insideFactor)
+			return mind, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return d, true
+		},
+		func(i int, px, py, d float64) bool {
+			res = Result{Index: i, X: px, Y: py, Distance: d}
+			found = true
+			return false
+		},
+	)
+	return
+}
+
+// CellCount is a cell of the tree returned by CellCounts
+type CellCount struct {
+	Box      BBox
+	Count    int
+	Centroid [2]float64 // mean of the points of the cell, only set if the tree was built with Options.StoreCentroids
+}
+
+// CellCounts returns the bbox and the number of points of every node at the given height, counted as in
+// FindNearestKClustered, in the order of their points. Leaves higher than the requested height are returned as
+// cells, so that every point is in exactly one cell and counts add up to the number of points.
+// Boxes cover all the points but, as in any R tree, they can overlap
+func (r *SimpleRTree) CellCounts(height int) []CellCount {
+	if !r.built || len(r.nodes) == 0 {
+		return nil
+	}
+	var cells []CellCount
+	for _, i := range r.cellNodes(height) {
+		bbox := r.nodes[i].BBox
+		if i == 0 {
+			bbox = r.rootBBox()
+		}
+		start, end := r.nodePointRange(i)
+		cell := CellCount{Box: bbox.ToBBox(), Count: end - start}
+		if cx, cy, ok := r.centroid(i); ok {
+			cell.Centroid = [2]float64{cx, cy}
+		}
+		cells = append(cells, cell)
+	}
+	return cells
+}
+
+// cellNodes returns the positions of the nodes that are cells at the given height, see CellCounts
+func (r *SimpleRTree) cellNodes(height int) []int {
+	var cells []int
+	var walk func(i, h int)
+	walk = func(i, h int) {
+		n := &r.nodes[i]
+		if h <= height || n.nodeType == preleaf_node {
+			cells = append(cells, i)
+			return
+		}
+		for j := n.firstChildIndex(); j < n.firstChildIndex()+int(n.nChildren); j++ {
+			walk(j, h-1)
+		}
+	}
+	walk(0, r.height)
+	return cells
+}
+
+// FindNearestPointInDensestCells returns the closest point to x and y among the points of the n cells at the given
+// height with the most points, as counted by CellCounts, so that results come from dense areas and sparse noise is
+// skipped. Cells with the same number of points are ranked in the order of their points
+func (r *SimpleRTree) FindNearestPointInDensestCells(x, y float64, height, n int) (res Result, found bool) {
+	if !r.built || len(r.nodes) == 0 || n <= 0 {
+		return
+	}
+	cells := r.cellNodes(height)
+	counts := make(map[int]int, len(cells))
+	for _, i := range cells {
+		start, end := r.nodePointRange(i)
+		counts[i] = end - start
+	}
+	sort.SliceStable(cells, func(a, b int) bool {
+		return counts[cells[a]] > counts[cells[b]]
+	})
+	if len(cells) > n {
+		cells = cells[:n]
+	}
+	densest := make(map[int]bool, len(cells))
+	for _, i := range cells {
+		densest[i] = true
+	}
+	r.bestFirstNodes(
+		func(i, h int) (float64, bool) {
+			node := &r.nodes[i]
+			if (h == height || (h > height && node.nodeType == preleaf_node)) && !densest[i] {
+				return 0, false
+			}
+			mind, _ := computeDistances(node.BBox, x, y, r.insideFactor)
+			return mind, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return d, true
+		},
+		func(i int, px, py, d float64) bool {
+			res = Result{Index: i, X: px, Y: py, Distance: d}
+			found = true
+			return false
+		},
+	)
+	return
+}
+
+// ClusterResult is a cluster returned by FindNearestClusters
+type ClusterResult struct {
+	Box            BBox
+	Count          int     // number of points of the cluster, disabled ones included, as in CellCounts
+	Distance       float64 // distance squared between the query and Box, 0 inside it
+	Representative Result  // the centroid of the cluster with Index -1 if the tree stores centroids, otherwise its closest point to the query. Index is -1 and Distance infinite if every point of the cluster is disabled
+}
+
+// FindNearestClusters returns the m clusters closest to x and y, the nodes at the given height counted as in
+// FindNearestKClustered, in increasing order of the distance to their bbox, for example to render clustered markers
+// at a zoom level. Leaves higher than the requested height are clusters, so every point is in exactly one of them as
+// in CellCounts. Each cluster comes with a representative point: its centroid if the tree was built with
+// Options.StoreCentroids, otherwise the closest of its points to x and y
+func (r *SimpleRTree) FindNearestClusters(m, height int, x, y float64) []ClusterResult {
+	if !r.built || len(r.nodes) == 0 || m <= 0 {
+		return nil
+	}
+	var clusters []ClusterResult
+	q := make(traversalQueue, 0, r.height*r.options.MAX_ENTRIES+1)
+	q.push(traversalItem{index: 0, height: r.height, priority: math.Inf(-1)})
+	for len(q) > 0 && len(clusters) < m {
+		item := q.pop()
+		n := &r.nodes[item.index]
+		// nodes are popped in increasing order of distance to their bbox, and so are clusters
+		if item.height <= height || n.nodeType == preleaf_node {
+			clusters = append(clusters, r.clusterResult(item.index, item.height, x, y))
+			continue
+		}
+		for i := n.firstChildIndex(); i < n.firstChildIndex()+int(n.nChildren); i++ {
+			mind, _ := computeDistances(r.nodes[i].BBox, x, y, r.insideFactor)
+			q.push(traversalItem{index: i, height: item.height - 1, priority: mind})
+		}
+	}
+	return clusters
+}
+
+// clusterResult returns the cluster of the node at position i with the given height
+func (r *SimpleRTree) clusterResult(i, height int, x, y float64) ClusterResult {
+	bbox := r.nodes[i].BBox
+	if i == 0 {
+		bbox = r.rootBBox()
+	}
+	start, end := r.nodePointRange(i)
+	mind, _ := computeDistances(bbox, x, y, r.insideFactor)
+	cluster := ClusterResult{Box: bbox.ToBBox(), Count: end - start, Distance: mind}
+	if cx, cy, ok := r.centroid(i); ok {
+		cluster.Representative = Result{Index: -1, X: cx, Y: cy, Distance: computeLeafDistance(cx, cy, x, y)}
+		return cluster
+	}
+	cluster.Representative = Result{Index: -1, Distance: math.Inf(1)}
+	q := make(traversalQueue, 0, height*r.options.MAX_ENTRIES+1)
+	q.push(traversalItem{index: i, priority: math.Inf(-1)})
+	for len(q) > 0 {
+		item := q.pop()
+		if item.isPoint {
+			px, py := r.getPointAt(item.index)
+			cluster.Representative = Result{Index: item.index, X: px, Y: py, Distance: item.priority}
+			break
+		}
+		n := &r.nodes[item.index]
+		if n.nodeType == preleaf_node {
+			for j := n.firstPointIndex(); j < n.firstPointIndex()+int(n.nChildren); j++ {
+				if r.disabled != nil && r.disabled[j] {
+					continue
+				}
+				_, _, d := r.pointDistance(j, x, y)
+				q.push(traversalItem{index: j, isPoint: true, priority: d})
+			}
+			continue
+		}
+		for j := n.firstChildIndex(); j < n.firstChildIndex()+int(n.nChildren); j++ {
+			mind, _ := computeDistances(r.nodes[j].BBox, x, y, r.insideFactor)
+			q.push(traversalItem{index: j, priority: mind})
+		}
+	}
+	return cluster
+}
@@ -0,0 +1,345 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSimpleRTree_FindNearestKClustered(t *testing.T) {
+	const size = 5000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	for height := 1; height <= r.height+1; height++ {
+		// positions of the nodes at each height
+		atHeight := make(map[int]bool)
+		var walk func(i, h int)
+		walk = func(i, h int) {
+			n := &r.nodes[i]
+			if h == height || (h > height && n.nodeType == preleaf_node) || (i == 0 && height >= r.height) {
+				atHeight[i] = true
+				return
+			}
+			for j := n.firstChildIndex(); j < n.firstChildIndex()+int(n.nChildren); j++ {
+				walk(j, h-1)
+			}
+		}
+		walk(0, r.height)
+		for _, k := range []int{1, 10, 200} {
+			x, y := rand.Float64(), rand.Float64()
+			clusters := r.FindNearestKClustered(k, height, x, y)
+			var distances []float64
+			for node, results := range clusters {
+				assert.True(t, atHeight[node], "Node %d at height %d", node, height)
+				start, end := r.nodePointRange(node)
+				for j, res := range results {
+					assert.True(t, start <= res.Index && res.Index < end, "Point %d under node %d", res.Index, node)
+					if j > 0 {
+						assert.True(t, results[j-1].Distance <= res.Distance)
+					}
+					distances = append(distances, res.Distance)
+				}
+			}
+			// grouping is complete, it contains the k nearest points
+			sort.Float64s(distances)
+			var expected []float64
+			for i := 0; i < points.Len(); i++ {
+				px, py := points.GetPointAt(i)
+				expected = append(expected, (px-x)*(px-x)+(py-y)*(py-y))
+			}
+			sort.Float64s(expected)
+			assert.Equal(t, expected[:k], distances)
+		}
+	}
+	assert.Empty(t, r.FindNearestKClustered(0, 1, 0, 0))
+	assert.Empty(t, New().FindNearestKClustered(3, 1, 0, 0))
+}
+
+func TestSimpleRTree_FindNearestPointWithCluster(t *testing.T) {
+	const size = 5000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	for height := 1; height <= r.height+1; height++ {
+		for n := 0; n < 100; n++ {
+			x, y := rand.Float64(), rand.Float64()
+			res, box, found := r.FindNearestPointWithCluster(x, y, height)
+			assert.True(t, found)
+			_, _, d := r.FindNearestPoint(x, y)
+			assert.Equal(t, d, res.Distance)
+			assert.True(t, box.containsPoint(res.X, res.Y), "Cluster box contains the point")
+			ancestor := r.ancestorAt(res.Index, height)
+			start, end := r.nodePointRange(ancestor)
+			assert.True(t, start <= res.Index && res.Index < end)
+			// the box is tight on the points of the cell
+			indexes := make([]int, 0, end-start)
+			for i := start; i < end; i++ {
+				indexes = append(indexes, i)
+			}
+			assert.Equal(t, r.BBoxOf(indexes), box)
+		}
+	}
+	_, _, found := New().FindNearestPointWithCluster(0, 0, 1)
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_SiblingPoints(t *testing.T) {
+	const size = 5000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	for height := 1; height <= r.height+1; height++ {
+		for n := 0; n < 50; n++ {
+			x, y := rand.Float64(), rand.Float64()
+			results := r.SiblingPoints(x, y, height)
+			res, _, _ := r.FindNearestPointWithCluster(x, y, height)
+			start, end := r.nodePointRange(r.ancestorAt(res.Index, height))
+			assert.Len(t, results, end-start)
+			for j, sibling := range results {
+				assert.Equal(t, start+j, sibling.Index)
+				px, py := points.GetPointAt(sibling.Index)
+				assert.Equal(t, []float64{px, py, (px-x)*(px-x) + (py-y)*(py-y)}, []float64{sibling.X, sibling.Y, sibling.Distance})
+			}
+			assert.Contains(t, results, res)
+		}
+	}
+	// the leaf of the nearest point is at most MAX_ENTRIES points
+	assert.True(t, len(r.SiblingPoints(0.5, 0.5, 1)) <= MAX_POSSIBLE_SIZE)
+	assert.Len(t, r.SiblingPoints(0.5, 0.5, r.height), size)
+
+	r.SetEnabled(0, false)
+	for _, res := range r.SiblingPoints(0.5, 0.5, r.height) {
+		assert.NotEqual(t, 0, res.Index)
+	}
+	assert.Empty(t, New().SiblingPoints(0, 0, 1))
+}
+
+func TestSimpleRTree_FindNearestPointInDenseCell(t *testing.T) {
+	const size = 2000
+	points := make(FlatPoints, 0, size*2)
+	// a dense cluster and a few isolated outliers around it
+	for i := 0; i < size-10; i++ {
+		points = append(points, 0.5+rand.Float64()*0.1, 0.5+rand.Float64()*0.1)
+	}
+	for i := 0; i < 10; i++ {
+		points = append(points, rand.Float64(), rand.Float64())
+	}
+	r := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(points)
+	skipped := 0
+	for height := 1; height <= r.height+1; height++ {
+		// every cell at this height has at least minSpan points, use it to force skipping some of them
+		spans := make(map[int]int)
+		for i := 0; i < points.Len(); i++ {
+			start, end := r.nodePointRange(r.ancestorAt(i, height))
+			spans[i] = end - start
+		}
+		for _, minCount := range []int{0, 2, 3, 4, 5, 16, 20, 100, size + 1} {
+			for n := 0; n < 50; n++ {
+				x, y := rand.Float64(), rand.Float64()
+				expected, expectedFound := math.Inf(1), false
+				for i := 0; i < points.Len(); i++ {
+					if spans[i] < minCount {
+						continue
+					}
+					px, py := points.GetPointAt(i)
+					expected = math.Min(expected, (px-x)*(px-x)+(py-y)*(py-y))
+					expectedFound = true
+				}
+				res, found := r.FindNearestPointInDenseCell(x, y, height, minCount)
+				assert.Equal(t, expectedFound, found, "Height %d min count %d", height, minCount)
+				if found {
+					assert.Equal(t, expected, res.Distance)
+					assert.True(t, spans[res.Index] >= minCount)
+					if _, _, d := r.FindNearestPoint(x, y); d < res.Distance {
+						skipped++
+					}
+				}
+			}
+		}
+	}
+	assert.True(t, skipped > 0, "Closer points in sparse cells were skipped")
+	_, found := New().FindNearestPointInDenseCell(0, 0, 1, 1)
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_FindNearestPointInDensestCells(t *testing.T) {
+	const size = 2000
+	points := make(FlatPoints, 0, size*2)
+	// clusters of different density and uniform noise
+	for i := 0; i < size; i++ {
+		switch i % 4 {
+		case 0, 1:
+			points = append(points, 0.2+rand.Float64()*0.05, 0.2+rand.Float64()*0.05)
+		case 2:
+			points = append(points, 0.7+rand.Float64()*0.1, 0.7+rand.Float64()*0.1)
+		default:
+			points = append(points, rand.Float64(), rand.Float64())
+		}
+	}
+	r := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(points)
+	for height := 1; height <= r.height+1; height++ {
+		cells := r.CellCounts(height)
+		nodes := r.cellNodes(height)
+		for _, n := range []int{1, 3, 10, len(cells) + 1} {
+			// the n largest counts, ties in the order of the cells
+			ranked := make([]int, len(cells))
+			for i := range ranked {
+				ranked[i] = i
+			}
+			sort.SliceStable(ranked, func(a, b int) bool {
+				return cells[ranked[a]].Count > cells[ranked[b]].Count
+			})
+			if len(ranked) > n {
+				ranked = ranked[:n]
+			}
+			var allowed []int
+			for _, c := range ranked {
+				start, end := r.nodePointRange(nodes[c])
+				for i := start; i < end; i++ {
+					allowed = append(allowed, i)
+				}
+			}
+			for q := 0; q < 20; q++ {
+				x, y := rand.Float64(), rand.Float64()
+				expected := math.Inf(1)
+				for _, i := range allowed {
+					px, py := points.GetPointAt(i)
+					expected = math.Min(expected, (px-x)*(px-x)+(py-y)*(py-y))
+				}
+				res, found := r.FindNearestPointInDensestCells(x, y, height, n)
+				assert.True(t, found)
+				assert.Equal(t, expected, res.Distance, "Height %d n %d", height, n)
+				assert.Contains(t, allowed, res.Index)
+			}
+		}
+	}
+	_, found := r.FindNearestPointInDensestCells(0, 0, 1, 0)
+	assert.False(t, found)
+	_, found = New().FindNearestPointInDensestCells(0, 0, 1, 1)
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_CellCounts(t *testing.T) {
+	const size = 5000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	extent := r.rootBBox().ToBBox()
+	for height := 0; height <= r.height+1; height++ {
+		cells := r.CellCounts(height)
+		total := 0
+		union := BBox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+		for _, cell := range cells {
+			// cells follow the order of the points
+			for i := total; i < total+cell.Count; i++ {
+				assert.True(t, cell.Box.containsPoint(points.GetPointAt(i)))
+			}
+			total += cell.Count
+			union = union.extend(cell.Box)
+		}
+		assert.Equal(t, size, total, "Height %d", height)
+		assert.Equal(t, extent, union)
+		if height >= r.height {
+			assert.Len(t, cells, 1)
+		}
+	}
+	assert.Len(t, r.CellCounts(1), len(r.FindNearestKClustered(size, 1, 0.5, 0.5)))
+	assert.Empty(t, New().CellCounts(1))
+}
+
+func TestSimpleRTree_FindNearestClusters(t *testing.T) {
+	const size = 5000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	for _, centroids := range []bool{false, true} {
+		r := NewWithOptions(Options{StoreCentroids: centroids}).Load(append(FlatPoints(nil), points...))
+		for i := 0; i < size; i += 3 {
+			r.SetEnabled(i, false)
+		}
+		for height := 0; height <= r.height+1; height++ {
+			// brute force grouping of the points by their ancestor
+			type group struct {
+				box        VectorBBox
+				members    []int
+				sumX, sumY float64
+			}
+			groups := map[int]*group{}
+			for i := 0; i < size; i++ {
+				a := r.ancestorAt(i, height)
+				if groups[a] == nil {
+					groups[a] = &group{box: VectorBBox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}}
+				}
+				g := groups[a]
+				px, py := r.getPointAt(i)
+				g.box = VectorBBoxExtend(g.box, VectorBBox{px, py, px, py})
+				g.members = append(g.members, i)
+				g.sumX, g.sumY = g.sumX+px, g.sumY+py
+			}
+			for n := 0; n < 20; n++ {
+				x, y := rand.Float64()*1.2-0.1, rand.Float64()*1.2-0.1
+				var distances []float64
+				for _, g := range groups {
+					d, _ := computeDistances(g.box, x, y, r.insideFactor)
+					distances = append(distances, d)
+				}
+				sort.Float64s(distances)
+				const m = 7
+				clusters := r.FindNearestClusters(m, height, x, y)
+				assert.Len(t, clusters, int(math.Min(m, float64(len(groups)))))
+				for j, c := range clusters {
+					assert.Equal(t, distances[j], c.Distance, "Height %d", height)
+					var g *group
+					for _, candidate := range groups {
+						if candidate.box.ToBBox() == c.Box {
+							g = candidate
+						}
+					}
+					if !assert.NotNil(t, g) {
+						continue
+					}
+					assert.Equal(t, len(g.members), c.Count)
+					if centroids {
+						assert.Equal(t, -1, c.Representative.Index)
+						assert.InDelta(t, g.sumX/float64(len(g.members)), c.Representative.X, 1e-9)
+						assert.InDelta(t, g.sumY/float64(len(g.members)), c.Representative.Y, 1e-9)
+						continue
+					}
+					closest := math.Inf(1)
+					for _, i := range g.members {
+						if !r.disabled[i] {
+							_, _, d := r.pointDistance(i, x, y)
+							closest = math.Min(closest, d)
+						}
+					}
+					assert.Equal(t, closest, c.Representative.Distance)
+					assert.False(t, r.disabled[c.Representative.Index])
+					assert.Contains(t, g.members, c.Representative.Index)
+				}
+			}
+		}
+	}
+
+	r := New().Load(FlatPoints{0, 0, 1, 1})
+	r.SetEnabled(0, false)
+	r.SetEnabled(1, false)
+	clusters := r.FindNearestClusters(3, 1, 0, 0)
+	assert.Equal(t, []ClusterResult{{
+		Box:            BBox{0, 0, 1, 1},
+		Count:          2,
+		Representative: Result{Index: -1, Distance: math.Inf(1)},
+	}}, clusters)
+	assert.Empty(t, New().FindNearestClusters(3, 1, 0, 0))
+}
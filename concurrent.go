@@ -0,0 +1,170 @@
+package SimpleRTree
+
+import (
+	"math"
+	"sync"
+)
+
+// minConcurrentPending is the number of inserted and deleted points a ConcurrentRTree accumulates at least before
+// rebuilding its tree
+const minConcurrentPending = 64
+
+// ConcurrentRTree adds Insert and Delete to a tree and can be shared between goroutines that both query and mutate it.
+// Queries take a read lock, so they run in parallel with each other, mutations take the write lock.
+//
+// The tree itself stays static: inserted points are kept in a buffer that queries scan linearly, deleted points are
+// disabled with SetEnabled. Once the buffer and the deleted points exceed an eighth of the points the tree is rebuilt,
+// so a mutation costs O(1) most of the time and a full rebuild every n/8 mutations.
+//
+// Performance with writers: every mutation waits for the running queries to finish and blocks the queries that
+// arrive meanwhile, and the mutation that triggers a rebuild holds the lock for the whole build. With frequent
+// mutations queries get serialized behind them, so batch mutations if possible. For read only workloads use
+// SimpleRTree directly, it needs no locks
+type ConcurrentRTree struct {
+	mu      sync.RWMutex
+	options Options
+	tree    *SimpleRTree // nil if there were no points on the last build
+	pending FlatPoints   // points inserted after the last build
+}
+
+// NewConcurrent creates a ConcurrentRTree with the given points, which are copied. points can be empty.
+// UnsafeConcurrencyMode is ignored, the tree is queried from several goroutines
+func NewConcurrent(o Options, points FlatPoints) *ConcurrentRTree {
+	o.UnsafeConcurrencyMode = false
+	c := &ConcurrentRTree{options: o}
+	c.build(append(FlatPoints{}, points...))
+	return c
+}
+
+// Insert adds a point
+func (c *ConcurrentRTree) Insert(x, y float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending = append(c.pending, x, y)
+	c.maybeRebuild()
+}
+
+// Delete removes one point with exactly the coordinates x and y and returns whether there was one
+func (c *ConcurrentRTree) Delete(x, y float64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := 0; i < c.pending.Len(); i++ {
+		if px, py := c.pending.GetPointAt(i); px == x && py == y {
+			last := c.pending.Len() - 1
+			c.pending.Swap(i, last)
+			c.pending = c.pending[:2*last]
+			return true
+		}
+	}
+	if c.tree == nil {
+		return false
+	}
+	idx := -1
+	c.tree.search(BBox{x, y, x, y}, func(i int, px, py float64) {
+		if idx < 0 && c.tree.IsEnabled(i) {
+			idx = i
+		}
+	})
+	if idx < 0 {
+		return false
+	}
+	c.tree.SetEnabled(idx, false)
+	c.maybeRebuild()
+	return true
+}
+
+// Len returns the number of points
+func (c *ConcurrentRTree) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	n := c.pending.Len()
+	if c.tree != nil {
+		n += c.tree.getLen() - c.tree.nDisabled
+	}
+	return n
+}
+
+// FindNearestPoint returns the closest point to x and y and its distance squared, as SimpleRTree.FindNearestPoint.
+// found is false if there are no points
+func (c *ConcurrentRTree) FindNearestPoint(x, y float64) (x1, y1, d1 float64, found bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	d1 = math.Inf(1)
+	if c.tree != nil {
+		x1, y1, d1, found = c.tree.FindNearestPointWithin(x, y, math.Inf(1))
+	}
+	for i := 0; i < c.pending.Len(); i++ {
+		px, py := c.pending.GetPointAt(i)
+		if d := c.distance(px, py, x, y); !found || d < d1 {
+			x1, y1, d1, found = px, py, d, true
+		}
+	}
+	return
+}
+
+// Search returns the coordinates of the points inside box, boundary included. Order of the results is not specified
+func (c *ConcurrentRTree) Search(box BBox) FlatPoints {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var results FlatPoints
+	if c.tree != nil {
+		c.tree.search(box, func(i int, x, y float64) {
+			if c.tree.IsEnabled(i) {
+				results = append(results, x, y)
+			}
+		})
+	}
+	for i := 0; i < c.pending.Len(); i++ {
+		if x, y := c.pending.GetPointAt(i); box.containsPoint(x, y) {
+			results = append(results, x, y)
+		}
+	}
+	return results
+}
+
+func (c *ConcurrentRTree) distance(px, py, x, y float64) float64 {
+	if c.options.RobustDistance {
+		return robustSquaredDistance(px, py, x, y)
+	}
+	return computeLeafDistance(px, py, x, y)
+}
+
+// maybeRebuild builds the tree again with the live points once there are too many pending or deleted ones.
+// It must be called with the write lock held
+func (c *ConcurrentRTree) maybeRebuild() {
+	changes := c.pending.Len()
+	live := c.pending.Len()
+	if c.tree != nil {
+		changes += c.tree.nDisabled
+		live += c.tree.getLen() - c.tree.nDisabled
+	}
+	if changes <= minConcurrentPending || changes <= live/8 {
+		return
+	}
+	points := make(FlatPoints, 0, 2*live)
+	if c.tree != nil {
+		for i := 0; i < c.tree.getLen(); i++ {
+			if c.tree.IsEnabled(i) {
+				x, y := c.tree.getPointAt(i)
+				points = append(points, x, y)
+			}
+		}
+	}
+	points = append(points, c.pending...)
+	c.pending = nil
+	c.build(points)
+}
+
+// build replaces the tree with one owning points
+func (c *ConcurrentRTree) build(points FlatPoints) {
+	if points.Len() == 0 {
+		c.tree = nil
+		return
+	}
+	if c.tree != nil {
+		if err := c.tree.Rebuild(points); err == nil {
+			return
+		}
+	}
+	c.tree = NewWithOptions(c.options).Load(points)
+}
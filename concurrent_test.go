@@ -0,0 +1,109 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestSimpleRTree_Concurrent(t *testing.T) {
+	const size = 2000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	c := NewConcurrent(Options{}, FlatPoints(points))
+	assert.Equal(t, size, c.Len())
+
+	const writers, readers, steps = 4, 4, 1000
+	var wg sync.WaitGroup
+	inserted := make([]FlatPoints, writers)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(int64(w)))
+			for i := 0; i < steps; i++ {
+				x, y := rnd.Float64(), rnd.Float64()
+				c.Insert(x, y)
+				inserted[w] = append(inserted[w], x, y)
+				// delete half of the inserted points and some of the initial ones
+				if i%2 == 1 {
+					n := inserted[w].Len()
+					px, py := inserted[w].GetPointAt(n - 2)
+					assert.True(t, c.Delete(px, py))
+					inserted[w].Swap(n-2, n-1)
+					inserted[w] = inserted[w][:2*(n-1)]
+				}
+				if i%10 == 0 {
+					j := w + writers*(i/10)
+					assert.True(t, c.Delete(points[2*j], points[2*j+1]))
+				}
+			}
+		}(w)
+	}
+	for rd := 0; rd < readers; rd++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < steps; i++ {
+				x, y := rand.Float64(), rand.Float64()
+				x1, y1, d1, found := c.FindNearestPoint(x, y)
+				assert.True(t, found)
+				assert.Equal(t, (x1-x)*(x1-x)+(y1-y)*(y1-y), d1)
+				c.Search(BBox{x, y, x + 0.05, y + 0.05})
+			}
+		}()
+	}
+	wg.Wait()
+
+	expected := FlatPoints{}
+	for j := 0; j < size; j++ {
+		// the first writers*steps/10 initial points are deleted
+		if j >= writers*steps/10 {
+			expected = append(expected, points[2*j], points[2*j+1])
+		}
+	}
+	for w := range inserted {
+		expected = append(expected, inserted[w]...)
+	}
+	assert.Equal(t, expected.Len(), c.Len())
+	assert.Equal(t, sortedPoints(expected), sortedPoints(c.Search(BBox{0, 0, 1, 1})))
+	for i := 0; i < 200; i++ {
+		x, y := rand.Float64(), rand.Float64()
+		_, _, d1, found := c.FindNearestPoint(x, y)
+		_, _, d2 := expected.linearClosestPoint(x, y)
+		assert.True(t, found)
+		assert.Equal(t, d2, d1)
+	}
+	assert.False(t, c.Delete(2, 2))
+}
+
+func TestSimpleRTree_ConcurrentEmpty(t *testing.T) {
+	c := NewConcurrent(Options{}, nil)
+	_, _, d, found := c.FindNearestPoint(0, 0)
+	assert.False(t, found)
+	assert.Equal(t, math.Inf(1), d)
+	assert.False(t, c.Delete(0, 0))
+
+	// enough points to trigger rebuilds, then delete all of them
+	points := FlatPoints{}
+	for i := 0; i < 500; i++ {
+		x, y := rand.Float64(), rand.Float64()
+		c.Insert(x, y)
+		points = append(points, x, y)
+	}
+	assert.Equal(t, 500, c.Len())
+	x1, y1, _, found := c.FindNearestPoint(points[0], points[1])
+	assert.True(t, found)
+	assert.Equal(t, []float64{points[0], points[1]}, []float64{x1, y1})
+	for _, i := range rand.Perm(500) {
+		assert.True(t, c.Delete(points[2*i], points[2*i+1]))
+	}
+	assert.Equal(t, 0, c.Len())
+	_, _, _, found = c.FindNearestPoint(0.5, 0.5)
+	assert.False(t, found)
+	assert.Empty(t, c.Search(BBox{0, 0, 1, 1}))
+}
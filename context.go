@@ -0,0 +1,62 @@
+package SimpleRTree
+
+import "context"
+
+// contextCheckInterval is the number of nodes queries taking a context visit between checks of it. Checking is a
+// function call, spacing checks keeps their cost negligible while queries still stop within a few microseconds
+const contextCheckInterval = 64
+
+// SearchContext is Search stopping once ctx is done, for example when its deadline passes, so that queries on huge
+// boxes cannot run unbounded. On cancellation it returns the points found so far and the error of ctx, the context
+// is checked every few nodes visited
+func (r *SimpleRTree) SearchContext(ctx context.Context, box BBox) ([]int, error) {
+	var results []int
+	err := r.searchContext(ctx, box, func(i int, x, y float64) {
+		results = append(results, i)
+	})
+	return results, err
+}
+
+// FindPointsWithinContext is FindPointsWithin stopping once ctx is done, returning the points found so far and the
+// error of ctx, see SearchContext
+func (r *SimpleRTree) FindPointsWithinContext(ctx context.Context, x, y, d float64) ([]Result, error) {
+	return r.findPointsWithin(ctx, x, y, d)
+}
+
+// FindNearestKContext returns the k closest points to x and y in increasing order of distance, or all of them if
+// there are fewer. If ctx is done before the k points are found it returns the closest ones found so far, which are
+// still the nearest in order, and the error of ctx. Disabled points are skipped
+func (r *SimpleRTree) FindNearestKContext(ctx context.Context, k int, x, y float64) ([]Result, error) {
+	var results []Result
+	if k <= 0 {
+		return results, nil
+	}
+	var err error
+	visited := 0
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			// once ctx is done nodes are pruned, the queue drains without expanding more nodes
+			if visited++; err != nil || (visited%contextCheckInterval == 0 && ctx.Err() != nil) {
+				err = ctx.Err()
+				return 0, false
+			}
+			mind, _ := computeDistances(bbox, x, y, r.insideFactor)
+			return mind, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return d, true
+		},
+		func(i int, px, py, d float64) bool {
+			if err != nil {
+				return false
+			}
+			results = append(results, Result{Index: i, X: px, Y: py, Distance: d})
+			return len(results) < k
+		},
+	)
+	return results, err
+}
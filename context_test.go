@@ -0,0 +1,69 @@
+package SimpleRTree
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRTree_QueriesContext(t *testing.T) {
+	const size = 200000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	box := BBox{0, 0, 1, 1}
+
+	// without deadline they match the plain queries
+	indexes, err := r.SearchContext(context.Background(), box)
+	assert.NoError(t, err)
+	assert.Len(t, indexes, size)
+	within, err := r.FindPointsWithinContext(context.Background(), 0.5, 0.5, 0.1)
+	assert.NoError(t, err)
+	assert.Equal(t, r.FindPointsWithin(0.5, 0.5, 0.1), within)
+	nearest, err := r.FindNearestKContext(context.Background(), 100, 0.5, 0.5)
+	assert.NoError(t, err)
+	assert.Len(t, nearest, 100)
+	assert.True(t, sort.SliceIsSorted(nearest, func(i, j int) bool { return nearest[i].Distance < nearest[j].Distance }))
+	d, _ := r.KthNearestDistance(100, 0.5, 0.5)
+	assert.Equal(t, d, nearest[99].Distance)
+
+	// expired deadlines return promptly with partial results
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	indexes, err = r.SearchContext(ctx, box)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, len(indexes) < size)
+	within, err = r.FindPointsWithinContext(ctx, 0.5, 0.5, 1)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, len(within) < size)
+	for _, res := range within {
+		assert.True(t, res.Distance <= 1)
+	}
+	nearest, err = r.FindNearestKContext(ctx, size, 0.5, 0.5)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, len(nearest) < size)
+	// partial nearest results are still the closest points in order
+	for i, res := range nearest {
+		d, _ := r.KthNearestDistance(i+1, 0.5, 0.5)
+		assert.Equal(t, d, res.Distance)
+	}
+
+	// a deadline in the middle of the query
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = r.FindNearestKContext(ctx, math.MaxInt32, 0.5, 0.5)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(start) < time.Second)
+
+	indexes, err = New().SearchContext(ctx, box)
+	assert.NoError(t, err)
+	assert.Empty(t, indexes)
+}
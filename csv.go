@@ -0,0 +1,32 @@
+package SimpleRTree
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// WriteCSV writes the points of the tree to w as CSV, for inspection in spreadsheets and GIS tools. After a header
+// row x,y,index it writes one row per point in the order of the leaves, the order of LeafPointsInOrder, where
+// consecutive points are spatially close. index is the position of the point in that order, the Result.Index of
+// queries. Coordinates are written with the fewest digits that parse back to the same values
+func (r *SimpleRTree) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"x", "y", "index"}); err != nil {
+		return err
+	}
+	if r.built {
+		row := make([]string, 3)
+		for i := 0; i < r.getLen(); i++ {
+			x, y := r.getPointAt(i)
+			row[0] = strconv.FormatFloat(x, 'g', -1, 64)
+			row[1] = strconv.FormatFloat(y, 'g', -1, 64)
+			row[2] = strconv.Itoa(i)
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,52 @@
+package SimpleRTree
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestSimpleRTree_WriteCSV(t *testing.T) {
+	points := make(FlatPoints, 3000*2)
+	for i := range points {
+		points[i] = rand.NormFloat64() * 1e5
+	}
+	points[0], points[1] = math.SmallestNonzeroFloat64, -1/3.
+	r := New().Load(points)
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.WriteCSV(&buf))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"x", "y", "index"}, rows[0])
+	assert.Len(t, rows[1:], r.getLen())
+	inOrder := r.LeafPointsInOrder()
+	for i, row := range rows[1:] {
+		x, err := strconv.ParseFloat(row[0], 64)
+		assert.NoError(t, err)
+		y, err := strconv.ParseFloat(row[1], 64)
+		assert.NoError(t, err)
+		index, err := strconv.Atoi(row[2])
+		assert.NoError(t, err)
+		assert.Equal(t, i, index)
+		ix, iy := inOrder.GetPointAt(i)
+		assert.Equal(t, [2]float64{ix, iy}, [2]float64{x, y})
+	}
+
+	buf.Reset()
+	assert.NoError(t, New().WriteCSV(&buf))
+	assert.Equal(t, "x,y,index\n", buf.String())
+	assert.EqualError(t, r.WriteCSV(failingWriter{}), "disk full")
+}
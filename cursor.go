@@ -0,0 +1,74 @@
+package SimpleRTree
+
+// RangeCursor walks the points inside a box in pages, keeping the state of the traversal between them, so that
+// servers can stream the results of a huge range query without buffering them all or restarting it for every page.
+// Pages concatenated give the same points in the same order as SearchPoints.
+//
+// A cursor reads the tree lazily: any mutation of the tree after it is created, such as Rebuild, RebuildRegion or
+// RecomputeBBoxes, invalidates it and further pages are undefined. A cursor must not be used from several goroutines
+// at the same time, different cursors on the same tree can
+type RangeCursor struct {
+	r     *SimpleRTree
+	box   BBox
+	stack []int // nodes left to visit
+	next  int   // position of the next point to check in the leaf being scanned
+	end   int   // end of the points of the leaf being scanned
+}
+
+// NewRangeCursor creates a cursor over the points inside box, boundary included. No node is visited until the
+// first call to Next
+func (r *SimpleRTree) NewRangeCursor(box BBox) *RangeCursor {
+	c := &RangeCursor{r: r, box: box}
+	if !r.built || len(r.nodes) == 0 {
+		return c
+	}
+	if r.useLinearScan() {
+		// as in Search, small trees are scanned in the order of the points
+		c.end = r.getLen()
+		return c
+	}
+	c.stack = make([]int, 1, 32)
+	return c
+}
+
+// Next returns the next page of at most n points inside the box, with their coordinates and without distance as in
+// SearchPoints. Pages are only shorter than n at the end, once Done is true. It returns nil if n is not positive
+func (c *RangeCursor) Next(n int) []Result {
+	if n <= 0 {
+		return nil
+	}
+	var page []Result
+	r := c.r
+	for len(page) < n {
+		if c.next < c.end {
+			if x, y := r.getPointAt(c.next); c.box.containsPoint(x, y) {
+				page = append(page, Result{Index: c.next, X: x, Y: y})
+			}
+			c.next++
+			continue
+		}
+		if len(c.stack) == 0 {
+			break
+		}
+		node := &r.nodes[c.stack[len(c.stack)-1]]
+		c.stack = c.stack[:len(c.stack)-1]
+		if node.nodeType == preleaf_node {
+			c.next = node.firstPointIndex()
+			c.end = c.next + int(node.nChildren)
+			continue
+		}
+		first := node.firstChildIndex()
+		for i := first; i < first+int(node.nChildren); i++ {
+			if r.nodeIntersects(i, c.box) {
+				c.stack = append(c.stack, i)
+			}
+		}
+	}
+	return page
+}
+
+// Done returns whether every point inside the box was returned. Nodes are only visited by Next, so Done can be false
+// while the remaining nodes hold no point inside the box, the next page is then empty
+func (c *RangeCursor) Done() bool {
+	return c.next >= c.end && len(c.stack) == 0
+}
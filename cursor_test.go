@@ -0,0 +1,44 @@
+package SimpleRTree
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRTree_RangeCursor(t *testing.T) {
+	for _, size := range []int{10, 100000} {
+		for _, options := range []Options{{}, {TreeType: HILBERT}, {OrientedLeaves: true}} {
+			r := NewWithOptions(options).Load(generateDataset(clusteredDataset, size, rand.Int63()))
+			for n := 0; n < 20; n++ {
+				box := randomBBox(0.6)
+				expected := r.SearchPoints(box)
+				pageSize := 1 + rand.Intn(500)
+				c := r.NewRangeCursor(box)
+				var all []Result
+				for !c.Done() {
+					page := c.Next(pageSize)
+					assert.True(t, len(page) <= pageSize)
+					if len(page) < pageSize {
+						assert.True(t, c.Done())
+					}
+					all = append(all, page...)
+				}
+				assert.Equal(t, expected, all)
+				assert.Empty(t, c.Next(pageSize))
+			}
+		}
+	}
+
+	r := New().Load(FlatPoints{0, 0, 1, 1, 2, 2})
+	c := r.NewRangeCursor(BBox{0, 0, 1, 1})
+	assert.Nil(t, c.Next(0))
+	assert.Equal(t, []Result{{Index: 0}}, c.Next(1))
+	assert.False(t, c.Done())
+	assert.Equal(t, []Result{{Index: 1, X: 1, Y: 1}}, c.Next(5))
+	assert.True(t, c.Done())
+	c = New().NewRangeCursor(BBox{0, 0, 1, 1})
+	assert.True(t, c.Done())
+	assert.Empty(t, c.Next(10))
+}
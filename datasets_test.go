@@ -0,0 +1,134 @@
+package SimpleRTree
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// datasetKind is a distribution of points generated by generateDataset. Besides typical data, kinds cover the
+// inputs that trigger edge cases of the tree: boxes with zero width or height, repeated coordinates and ties
+type datasetKind int
+
+const (
+	uniformDataset    datasetKind = iota // uniform in the unit square
+	clusteredDataset                     // mixture of gaussians of different spread, some points far from any center
+	collinearDataset                     // on a diagonal line, a horizontal one and a vertical one, boxes are degenerate
+	duplicatesDataset                    // few distinct points repeated many times, queries have ties
+	gridDataset                          // integer grid, many points are at the same distance from a query
+	singlePointDataset                   // every point at the same coordinates, every box is a point
+)
+
+var datasetKinds = []datasetKind{uniformDataset, clusteredDataset, collinearDataset, duplicatesDataset, gridDataset, singlePointDataset}
+
+func (k datasetKind) String() string {
+	return [...]string{"uniform", "clustered", "collinear", "duplicates", "grid", "single point"}[k]
+}
+
+// generateDataset returns n points of the given kind. The same seed always gives the same points, so failures can be
+// reproduced by logging the seed, see datasetName. Coordinates are roughly in the unit square
+func generateDataset(kind datasetKind, n int, seed int64) FlatPoints {
+	rnd := rand.New(rand.NewSource(seed))
+	points := make(FlatPoints, 0, 2*n)
+	switch kind {
+	case uniformDataset:
+		for i := 0; i < n; i++ {
+			points = append(points, rnd.Float64(), rnd.Float64())
+		}
+	case clusteredDataset:
+		centers := make([][3]float64, 1+rnd.Intn(8))
+		for i := range centers {
+			centers[i] = [3]float64{rnd.Float64(), rnd.Float64(), math.Pow(10, -1-3*rnd.Float64())}
+		}
+		for i := 0; i < n; i++ {
+			if rnd.Intn(50) == 0 {
+				points = append(points, rnd.Float64(), rnd.Float64())
+				continue
+			}
+			c := centers[rnd.Intn(len(centers))]
+			points = append(points, c[0]+rnd.NormFloat64()*c[2], c[1]+rnd.NormFloat64()*c[2])
+		}
+	case collinearDataset:
+		for i := 0; i < n; i++ {
+			t := rnd.Float64()
+			switch i % 3 {
+			case 0:
+				points = append(points, t, t)
+			case 1:
+				points = append(points, t, 0.25)
+			default:
+				points = append(points, 0.75, t)
+			}
+		}
+	case duplicatesDataset:
+		distinct := make(FlatPoints, 0, 20)
+		for i := 0; i < 1+rnd.Intn(10); i++ {
+			distinct = append(distinct, rnd.Float64(), rnd.Float64())
+		}
+		for i := 0; i < n; i++ {
+			x, y := distinct.GetPointAt(rnd.Intn(distinct.Len()))
+			points = append(points, x, y)
+		}
+	case gridDataset:
+		side := int(math.Ceil(math.Sqrt(float64(n))))
+		for i := 0; i < n; i++ {
+			points = append(points, float64(i%side)/float64(side), float64(i/side)/float64(side))
+		}
+		rnd.Shuffle(n, points.Swap)
+	case singlePointDataset:
+		x, y := rnd.Float64(), rnd.Float64()
+		for i := 0; i < n; i++ {
+			points = append(points, x, y)
+		}
+	default:
+		panic(fmt.Sprintf("unknown dataset kind %d", kind))
+	}
+	return points
+}
+
+// datasetName describes a generated dataset, to be used as the name of subtests
+func datasetName(kind datasetKind, n int, seed int64) string {
+	return fmt.Sprintf("%s/%d/seed=%d", kind, n, seed)
+}
+
+func TestGenerateDataset(t *testing.T) {
+	for _, kind := range datasetKinds {
+		a := generateDataset(kind, 100, 42)
+		assert.Len(t, a, 200)
+		assert.Equal(t, a, generateDataset(kind, 100, 42), "Datasets are reproducible")
+		assert.NoError(t, checkPoints(a))
+	}
+	assert.Equal(t, sortedPoints(generateDataset(singlePointDataset, 5, 1))[0], sortedPoints(generateDataset(singlePointDataset, 5, 1))[4])
+	assert.Panics(t, func() { generateDataset(datasetKind(-1), 1, 0) })
+}
+
+// TestSimpleRTree_Datasets compares nearest point and search queries with linear scans on every kind of dataset
+func TestSimpleRTree_Datasets(t *testing.T) {
+	for _, kind := range datasetKinds {
+		for _, n := range []int{1, 2, 9, 10, 100, 2000} {
+			seed := rand.Int63()
+			t.Run(datasetName(kind, n, seed), func(t *testing.T) {
+				points := generateDataset(kind, n, seed)
+				rnd := rand.New(rand.NewSource(seed))
+				for _, options := range []Options{{}, {TreeType: HILBERT}, {MAX_ENTRIES: 2, LinearScanThreshold: -1}} {
+					fp := append(FlatPoints(nil), points...)
+					r := NewWithOptions(options).Load(fp)
+					for q := 0; q < 50; q++ {
+						x, y := rnd.Float64()*1.2-0.1, rnd.Float64()*1.2-0.1
+						_, _, d1 := r.FindNearestPoint(x, y)
+						_, _, d2 := fp.linearClosestPoint(x, y)
+						assert.Equal(t, d2, d1)
+						box := BBox{x, y, x + rnd.Float64()*0.3, y + rnd.Float64()*0.3}
+						results := r.Search(box)
+						sort.Ints(results)
+						assert.Equal(t, fp.linearSearch(box), results)
+					}
+				}
+			})
+		}
+	}
+}
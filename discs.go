@@ -0,0 +1,145 @@
+package SimpleRTree
+
+import (
+	"fmt"
+	"math"
+)
+
+// discs are the points of a tree loaded with LoadDiscs, radii are swapped together with the centers
+type discs struct {
+	centers FlatPoints
+	radii   []float64
+}
+
+func (d discs) Len() int {
+	return d.centers.Len()
+}
+
+func (d discs) Swap(i, j int) {
+	d.centers.Swap(i, j)
+	d.radii[i], d.radii[j] = d.radii[j], d.radii[i]
+}
+
+func (d discs) GetPointAt(i int) (x, y float64) {
+	return d.centers.GetPointAt(i)
+}
+
+// LoadDiscs builds the RTree over discs given by their centers and radii, for FindNearestDisc.
+// The rest of the queries treat discs as their centers. Radii must not be negative and there must be one per center.
+//
+// Note: rtree is assumed to have sole access to both arrays, it will reorder them together
+func (r *SimpleRTree) LoadDiscs(centers FlatPoints, radii []float64) *SimpleRTree {
+	if len(radii) != centers.Len() {
+		panic(fmt.Sprintf("%d radii given for %d centers", len(radii), centers.Len()))
+	}
+	for _, radius := range radii {
+		if !(radius >= 0) {
+			panic(fmt.Sprintf("invalid radius %v", radius))
+		}
+	}
+	d := discs{centers: centers, radii: radii}
+	r.load(d, false)
+	if len(r.nodes) > 0 {
+		r.maxRadius = make([]float64, len(r.nodes))
+		r.computeMaxRadius(0, d.radii)
+	}
+	return r
+}
+
+// computeMaxRadius sets the largest radius under the node at position i and its descendants
+func (r *SimpleRTree) computeMaxRadius(i int, radii []float64) float64 {
+	n := &r.nodes[i]
+	maxRadius := 0.
+	if n.nodeType == preleaf_node {
+		for j := n.firstPointIndex(); j < n.firstPointIndex()+int(n.nChildren); j++ {
+			maxRadius = math.Max(maxRadius, radii[j])
+		}
+	} else {
+		for j := n.firstChildIndex(); j < n.firstChildIndex()+int(n.nChildren); j++ {
+			maxRadius = math.Max(maxRadius, r.computeMaxRadius(j, radii))
+		}
+	}
+	r.maxRadius[i] = maxRadius
+	return maxRadius
+}
+
+// FindNearestDisc returns the disc whose surface is closest to x and y, with the distance squared to its surface,
+// that is max(0, distance to the center - radius) squared, and the coordinates of its center.
+// Coordinates inside several discs are at distance 0 of all of them, any of them can be returned.
+// Trees not loaded with LoadDiscs behave as discs of radius 0
+func (r *SimpleRTree) FindNearestDisc(x, y float64) (res Result, found bool) {
+	d, isDiscs := r.source.(discs)
+	r.bestFirstNodes(
+		func(i, height int) (float64, bool) {
+			mind, _ := computeDistances(r.nodes[i].BBox, x, y, r.insideFactor)
+			if isDiscs {
+				gap := math.Max(0, math.Sqrt(mind)-r.maxRadius[i])
+				return gap * gap, true
+			}
+			return mind, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			_, _, distance := r.pointDistance(i, x, y)
+			if isDiscs {
+				gap := math.Max(0, math.Sqrt(distance)-d.radii[i])
+				return gap * gap, true
+			}
+			return distance, true
+		},
+		func(i int, px, py, distance float64) bool {
+			res = Result{Index: i, X: px, Y: py, Distance: distance}
+			found = true
+			return false
+		},
+	)
+	return
+}
+
+// CoveringDiscs returns the positions of the discs that contain x and y, that is whose center is at distance radius
+// or less, for example the sensors whose coverage reaches a location. It is the dual of FindNearestDisc.
+// Nodes are pruned when their bbox is farther than the largest radius under them, so large discs only slow down
+// queries around them. Positions are those of Result.Index, the radii given to LoadDiscs are reordered with them.
+// Trees not loaded with LoadDiscs behave as discs of radius 0. Disabled discs are left out.
+// Order of the results is not specified
+func (r *SimpleRTree) CoveringDiscs(x, y float64) []int {
+	var results []int
+	if !r.built || len(r.nodes) == 0 {
+		return results
+	}
+	d, isDiscs := r.source.(discs)
+	stack := make([]int, 1, 32)
+	for len(stack) > 0 {
+		n := &r.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if n.nodeType == preleaf_node {
+			start := n.firstPointIndex()
+			for i := start; i < start+int(n.nChildren); i++ {
+				if r.disabled != nil && r.disabled[i] {
+					continue
+				}
+				radius := 0.
+				if isDiscs {
+					radius = d.radii[i]
+				}
+				if _, _, distance := r.pointDistance(i, x, y); distance <= radius*radius {
+					results = append(results, i)
+				}
+			}
+			continue
+		}
+		first := n.firstChildIndex()
+		for i := first; i < first+int(n.nChildren); i++ {
+			maxRadius := 0.
+			if isDiscs {
+				maxRadius = r.maxRadius[i]
+			}
+			if mind, _ := computeDistances(r.nodes[i].BBox, x, y, r.insideFactor); mind <= maxRadius*maxRadius {
+				stack = append(stack, i)
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,110 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSimpleRTree_FindNearestDisc(t *testing.T) {
+	const size = 5000
+	centers := make(FlatPoints, size*2)
+	radii := make([]float64, size)
+	for i := range centers {
+		centers[i] = rand.Float64()
+	}
+	for i := range radii {
+		radii[i] = rand.Float64() * 0.01
+		// a few large discs
+		if rand.Intn(100) == 0 {
+			radii[i] = rand.Float64() * 0.2
+		}
+	}
+	original := append(FlatPoints(nil), centers...)
+	originalRadii := append([]float64(nil), radii...)
+	r := New().LoadDiscs(centers, radii)
+	// radii follow their centers
+	for i := 0; i < centers.Len(); i++ {
+		x, y := centers.GetPointAt(i)
+		found := false
+		for j := 0; j < original.Len() && !found; j++ {
+			if ox, oy := original.GetPointAt(j); ox == x && oy == y {
+				assert.Equal(t, originalRadii[j], radii[i])
+				found = true
+			}
+		}
+		assert.True(t, found)
+	}
+	for n := 0; n < 500; n++ {
+		x, y := rand.Float64()*1.4-0.2, rand.Float64()*1.4-0.2
+		expected := math.Inf(1)
+		for i := 0; i < centers.Len(); i++ {
+			px, py := centers.GetPointAt(i)
+			gap := math.Max(0, math.Sqrt((px-x)*(px-x)+(py-y)*(py-y))-radii[i])
+			expected = math.Min(expected, gap*gap)
+		}
+		res, found := r.FindNearestDisc(x, y)
+		assert.True(t, found)
+		assert.Equal(t, expected, res.Distance)
+		px, py := centers.GetPointAt(res.Index)
+		assert.Equal(t, [2]float64{px, py}, [2]float64{res.X, res.Y})
+	}
+
+	// without radii it is the nearest point
+	p := New().Load(FlatPoints{0, 0, 1, 1})
+	res, found := p.FindNearestDisc(0.9, 0.9)
+	assert.True(t, found)
+	assert.Equal(t, 1, int(res.X))
+	_, found = New().FindNearestDisc(0, 0)
+	assert.False(t, found)
+	assert.Panics(t, func() {
+		New().LoadDiscs(FlatPoints{0, 0}, []float64{1, 2})
+	})
+	assert.Panics(t, func() {
+		New().LoadDiscs(FlatPoints{0, 0}, []float64{-1})
+	})
+}
+
+func TestSimpleRTree_CoveringDiscs(t *testing.T) {
+	const size = 5000
+	centers := make(FlatPoints, size*2)
+	radii := make([]float64, size)
+	for i := range centers {
+		centers[i] = rand.Float64()
+	}
+	for i := range radii {
+		radii[i] = rand.Float64() * 0.02
+		if rand.Intn(100) == 0 {
+			radii[i] = rand.Float64() * 0.3
+		}
+	}
+	r := New().LoadDiscs(centers, radii)
+	for n := 0; n < 300; n++ {
+		x, y := rand.Float64()*1.4-0.2, rand.Float64()*1.4-0.2
+		var expected []int
+		for i := 0; i < centers.Len(); i++ {
+			px, py := centers.GetPointAt(i)
+			if computeLeafDistance(px, py, x, y) <= radii[i]*radii[i] {
+				expected = append(expected, i)
+			}
+		}
+		results := r.CoveringDiscs(x, y)
+		sort.Ints(results)
+		assert.Equal(t, expected, results)
+	}
+	// boundary is included
+	r = New().LoadDiscs(FlatPoints{0, 0, 3, 0}, []float64{2, 1})
+	results := r.CoveringDiscs(2, 0)
+	sort.Ints(results)
+	assert.Len(t, results, 2)
+	r.SetEnabled(0, false)
+	assert.Len(t, r.CoveringDiscs(2, 0), 1)
+
+	// without radii only points at the query cover it
+	p := New().Load(FlatPoints{0, 0, 1, 1})
+	assert.Len(t, p.CoveringDiscs(1, 1), 1)
+	assert.Empty(t, p.CoveringDiscs(0.5, 0.5))
+	assert.Empty(t, New().CoveringDiscs(0, 0))
+}
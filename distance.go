@@ -0,0 +1,37 @@
+package SimpleRTree
+
+import "math"
+
+// robustSquaredDistance returns (x - px)**2 + (y - py)**2 rounded once, instead of once per operation as
+// computeLeafDistance does. Differences are scaled by a power of two so that squaring them cannot underflow or
+// overflow before the end, and the rounding errors of the products and the sum are recovered with fused
+// multiply adds and added back.
+// Differences are still rounded, they are exact when the coordinates are within a factor of 2 of each other
+func robustSquaredDistance(px, py, x, y float64) float64 {
+	dx, dy := x-px, y-py
+	m := math.Max(math.Abs(dx), math.Abs(dy))
+	if m == 0 || math.IsInf(m, 0) || math.IsNaN(m) {
+		return dx*dx + dy*dy
+	}
+	_, exp := math.Frexp(m)
+	dx, dy = math.Ldexp(dx, -exp), math.Ldexp(dy, -exp)
+	p1, p2 := dx*dx, dy*dy
+	e1, e2 := math.FMA(dx, dx, -p1), math.FMA(dy, dy, -p2)
+	// two sum of the products
+	s := p1 + p2
+	bv := s - p1
+	e3 := (p1 - (s - bv)) + (p2 - bv)
+	return math.Ldexp(s+(e1+e2+e3), 2*exp)
+}
+
+// finalDistance returns the distance squared between (px, py) and (x, y) reported in results, see
+// Options.RobustDistance
+func (r *SimpleRTree) finalDistance(px, py, x, y float64) float64 {
+	if _, ok := r.source.(IntPoints); ok && isInt32(px) && isInt32(py) && isInt32(x) && isInt32(y) {
+		return integerSquaredDistance(px, py, x, y)
+	}
+	if r.options.RobustDistance {
+		return robustSquaredDistance(px, py, x, y)
+	}
+	return computeLeafDistance(px, py, x, y)
+}
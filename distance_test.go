@@ -0,0 +1,57 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// referenceSquaredDistance computes the distance squared with exact arithmetic and rounds it once
+func referenceSquaredDistance(px, py, x, y float64) float64 {
+	dx := new(big.Float).SetPrec(2000).Sub(big.NewFloat(x).SetPrec(2000), big.NewFloat(px).SetPrec(2000))
+	dy := new(big.Float).SetPrec(2000).Sub(big.NewFloat(y).SetPrec(2000), big.NewFloat(py).SetPrec(2000))
+	d := new(big.Float).SetPrec(2000).Mul(dx, dx)
+	d.Add(d, new(big.Float).SetPrec(2000).Mul(dy, dy))
+	f, _ := d.Float64()
+	return f
+}
+
+func TestRobustSquaredDistance(t *testing.T) {
+	naiveErrors := 0
+	for i := 0; i < 10000; i++ {
+		// large coordinates, differences are exact but their squares are not
+		px, py := 1e8+rand.Float64()*1e3, -1e8-rand.Float64()*1e3
+		x, y := 1e8+rand.Float64()*1e3, -1e8-rand.Float64()*1e3
+		expected := referenceSquaredDistance(px, py, x, y)
+		assert.Equal(t, expected, robustSquaredDistance(px, py, x, y))
+		if computeLeafDistance(px, py, x, y) != expected {
+			naiveErrors++
+		}
+	}
+	assert.True(t, naiveErrors > 0, "Naive squares lose precision")
+
+	// squares of the differences underflow or overflow separately
+	assert.Equal(t, referenceSquaredDistance(0, 0, 3e-170, 4e-170), robustSquaredDistance(0, 0, 3e-170, 4e-170))
+	assert.Equal(t, math.Inf(1), robustSquaredDistance(0, 0, 1e200, 0))
+	assert.Equal(t, 25., robustSquaredDistance(0, 0, 3, 4))
+	assert.Equal(t, 0., robustSquaredDistance(1, 1, 1, 1))
+}
+
+func TestSimpleRTree_RobustDistance(t *testing.T) {
+	const size = 10000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = 1e9 + rand.Float64()*1e4
+	}
+	r := NewWithOptions(Options{RobustDistance: true}).Load(points)
+	for i := 0; i < 1000; i++ {
+		x, y := 1e9+rand.Float64()*1e4, 1e9+rand.Float64()*1e4
+		x1, y1, d1 := r.FindNearestPoint(x, y)
+		_, _, naive := points.linearClosestPoint(x, y)
+		assert.Equal(t, referenceSquaredDistance(x1, y1, x, y), d1)
+		assert.InEpsilon(t, naive, d1, 1e-12)
+		assert.Equal(t, d1, r.SquaredDistance(x1, y1, x, y))
+	}
+}
@@ -0,0 +1,53 @@
+package SimpleRTree
+
+import (
+	"fmt"
+	"math"
+)
+
+// DownsampleRule selects the representative of the points of a cell in LoadDownsampled
+type DownsampleRule uint8
+
+const (
+	DownsampleFirst    DownsampleRule = iota // the first point of the cell in the input order
+	DownsampleCentroid                       // the mean of the points of the cell, which is not one of the input points in general
+)
+
+// LoadDownsampled builds the RTree with at most one point per cell of a grid of cellSize, for example to render
+// zoomed out overviews. Cells are aligned with the origin, the cell of a point is (floor(x / cellSize), floor(y / cellSize)).
+// The representative of each occupied cell is chosen with Options.DownsampleRule.
+//
+// As in LoadGrouped points are copied and the caller's array is not modified. Indexes in results refer to the
+// positions of the representatives in the tree. It panics if cellSize is not positive and finite
+func (r *SimpleRTree) LoadDownsampled(points FlatPoints, cellSize float64) *SimpleRTree {
+	if !(cellSize > 0) || math.IsInf(cellSize, 1) {
+		panic(fmt.Sprintf("invalid cell size %v", cellSize))
+	}
+	type cell struct {
+		position   int // position of the representative in sampled
+		n          int
+		sumX, sumY float64
+	}
+	cells := make(map[[2]float64]*cell)
+	sampled := make(FlatPoints, 0)
+	for i := 0; i < points.Len(); i++ {
+		x, y := points.GetPointAt(i)
+		key := [2]float64{math.Floor(x / cellSize), math.Floor(y / cellSize)}
+		c, ok := cells[key]
+		if !ok {
+			c = &cell{position: sampled.Len()}
+			cells[key] = c
+			sampled = append(sampled, x, y)
+		}
+		c.n++
+		c.sumX += x
+		c.sumY += y
+	}
+	if r.options.DownsampleRule == DownsampleCentroid {
+		for _, c := range cells {
+			sampled[2*c.position] = c.sumX / float64(c.n)
+			sampled[2*c.position+1] = c.sumY / float64(c.n)
+		}
+	}
+	return r.load(sampled, false)
+}
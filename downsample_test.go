@@ -0,0 +1,61 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSimpleRTree_LoadDownsampled(t *testing.T) {
+	const size, cellSize = 20000, 0.1
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()*2 - 1
+	}
+	original := append(FlatPoints(nil), points...)
+	cellOf := func(x, y float64) [2]float64 {
+		return [2]float64{math.Floor(x / cellSize), math.Floor(y / cellSize)}
+	}
+	// first point and centroid of every occupied cell
+	first := make(map[[2]float64][2]float64)
+	sums := make(map[[2]float64][3]float64)
+	for i := 0; i < points.Len(); i++ {
+		x, y := points.GetPointAt(i)
+		c := cellOf(x, y)
+		if _, ok := first[c]; !ok {
+			first[c] = [2]float64{x, y}
+		}
+		s := sums[c]
+		sums[c] = [3]float64{s[0] + x, s[1] + y, s[2] + 1}
+	}
+
+	for _, rule := range []DownsampleRule{DownsampleFirst, DownsampleCentroid} {
+		r := NewWithOptions(Options{DownsampleRule: rule}).LoadDownsampled(points, cellSize)
+		assert.Equal(t, original, points, "Caller's points are not modified")
+		sampled := r.LeafPointsInOrder()
+		assert.Equal(t, len(first), sampled.Len(), "One point per occupied cell")
+		seen := make(map[[2]float64]bool)
+		for i := 0; i < sampled.Len(); i++ {
+			x, y := sampled.GetPointAt(i)
+			c := cellOf(x, y)
+			assert.False(t, seen[c], "At most one point per cell")
+			seen[c] = true
+			if rule == DownsampleFirst {
+				assert.Equal(t, first[c], [2]float64{x, y})
+			} else {
+				s := sums[c]
+				assert.Equal(t, [2]float64{s[0] / s[2], s[1] / s[2]}, [2]float64{x, y})
+			}
+		}
+		_, _, d := r.FindNearestPoint(0.05, 0.05)
+		_, _, expected := sampled.linearClosestPoint(0.05, 0.05)
+		assert.Equal(t, expected, d)
+	}
+
+	r := New().LoadDownsampled(FlatPoints{}, cellSize)
+	_, _, _, found := r.FindNearestPointWithin(0, 0, 1)
+	assert.False(t, found)
+	assert.Panics(t, func() { New().LoadDownsampled(points, 0) })
+	assert.Panics(t, func() { New().LoadDownsampled(points, math.NaN()) })
+}
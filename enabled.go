@@ -0,0 +1,40 @@
+package SimpleRTree
+
+// SetEnabled enables or disables the point at position idx for nearest point queries, FindFarthestPoint,
+// FindExtremeInDirection and ExtremePoints, without rebuilding the tree.
+// Nodes are still pruned with the bboxes of all the points, disabled points are only skipped in the leaves, so queries
+// get slower as more points are disabled. Positions are those of Result.Index.
+// Rebuild enables every point again, RebuildRegion keeps them as they are.
+//
+// SetEnabled is not safe to call concurrently with queries
+func (r *SimpleRTree) SetEnabled(idx int, enabled bool) {
+	if r.disabled == nil {
+		if enabled {
+			return
+		}
+		r.disabled = make([]bool, r.getLen())
+	}
+	if r.disabled[idx] == !enabled {
+		return
+	}
+	r.disabled[idx] = !enabled
+	if enabled {
+		r.nDisabled--
+	} else {
+		r.nDisabled++
+	}
+	if r.cache != nil {
+		r.cache.clear()
+	}
+}
+
+// IsEnabled returns whether the point at position idx is considered by nearest point queries, see SetEnabled
+func (r *SimpleRTree) IsEnabled(idx int) bool {
+	return r.disabled == nil || !r.disabled[idx]
+}
+
+// enableAll drops the disabled points, positions are not valid anymore after the points are reordered
+func (r *SimpleRTree) enableAll() {
+	r.disabled = nil
+	r.nDisabled = 0
+}
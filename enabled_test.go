@@ -0,0 +1,90 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestSimpleRTree_SetEnabled(t *testing.T) {
+	const size = 5000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	for round := 0; round < 10; round++ {
+		// toggle a random subset, disabling most of the points in later rounds
+		for n := 0; n < size/2; n++ {
+			r.SetEnabled(rand.Intn(size), rand.Intn(10) >= round)
+		}
+		var enabledPoints FlatPoints
+		for i := 0; i < size; i++ {
+			if r.IsEnabled(i) {
+				x, y := points.GetPointAt(i)
+				enabledPoints = append(enabledPoints, x, y)
+			}
+		}
+		expected := New().Load(enabledPoints)
+		for n := 0; n < 100; n++ {
+			x, y := rand.Float64(), rand.Float64()
+			x1, y1, d1 := expected.FindNearestPoint(x, y)
+			x2, y2, d2 := r.FindNearestPoint(x, y)
+			assert.Equal(t, d1, d2)
+			assert.Equal(t, x1, x2)
+			assert.Equal(t, y1, y2)
+			res, found := r.findNearestPointWithin(x, y, 0.001, nil)
+			_, _, d3, expectedFound := expected.FindNearestPointWithin(x, y, 0.001)
+			assert.Equal(t, expectedFound, found)
+			if found {
+				assert.True(t, r.IsEnabled(res.Index))
+				assert.Equal(t, d3, res.Distance)
+			}
+
+			box := randomBBox(0.2)
+			var inBox []float64
+			for _, res := range r.FindNearestKInBox(5, x, y, box) {
+				assert.True(t, r.IsEnabled(res.Index))
+				inBox = append(inBox, res.Distance)
+			}
+			var expectedInBox []float64
+			for _, res := range expected.FindNearestKInBox(5, x, y, box) {
+				expectedInBox = append(expectedInBox, res.Distance)
+			}
+			assert.Equal(t, expectedInBox, inBox)
+
+			dx, dy := rand.Float64()-0.5, rand.Float64()-0.5
+			extreme, found := r.FindExtremeInDirection(dx, dy)
+			expectedExtreme, expectedFound := expected.FindExtremeInDirection(dx, dy)
+			assert.Equal(t, expectedFound, found)
+			if found {
+				assert.True(t, r.IsEnabled(extreme.Index))
+				assert.Equal(t, expectedExtreme.Distance, extreme.Distance)
+			}
+			farthest, found := r.FindFarthestPoint(x, y)
+			expectedFarthest, expectedFound := expected.FindFarthestPoint(x, y)
+			assert.Equal(t, expectedFound, found)
+			if found {
+				assert.True(t, r.IsEnabled(farthest.Index))
+				assert.Equal(t, expectedFarthest.Distance, farthest.Distance)
+			}
+		}
+	}
+	for i := 0; i < size; i++ {
+		r.SetEnabled(i, true)
+	}
+	assert.Equal(t, 0, r.nDisabled)
+}
+
+func TestSimpleRTree_SetEnabledAll(t *testing.T) {
+	r := New().Load(FlatPoints{0, 0, 1, 1})
+	r.SetEnabled(0, false)
+	r.SetEnabled(1, false)
+	_, found := r.findNearestPointWithin(0, 0, 10, nil)
+	assert.False(t, found)
+	assert.NoError(t, r.Rebuild(FlatPoints{0, 0, 1, 1}))
+	assert.True(t, r.IsEnabled(0))
+	assert.True(t, r.IsEnabled(1))
+	_, found = r.findNearestPointWithin(0, 0, 10, nil)
+	assert.True(t, found)
+}
@@ -0,0 +1,71 @@
+package SimpleRTree
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Errors returned by the functions that build or query a tree, possibly wrapped with more details.
+// Use errors.Is to check for them
+var (
+	ErrAlreadyBuilt        = errors.New("tree is static, cannot load twice")
+	ErrNotBuilt            = errors.New("tree has not been loaded yet")
+	ErrInvalidMaxEntries   = errors.New("invalid MAX_ENTRIES")
+	ErrNonFiniteCoordinate = errors.New("coordinate is not finite")
+	ErrOddPointsLength     = errors.New("odd number of coordinates in FlatPoints")
+	ErrUnsupportedVersion  = errors.New("unsupported binary format version")
+	ErrUnsupportedFlags    = errors.New("unsupported binary format flags")
+	ErrBBoxMismatch        = errors.New("bbox of node does not match its points")
+	ErrMaxHeightTooLow     = errors.New("too many points for MaxHeight")
+	ErrBuildMemoryExceeded = errors.New("build needs more memory than MaxBuildMemory")
+	ErrTooManyPoints       = errors.New("too many points for a tree")
+	ErrNoPoints            = errors.New("no points given")
+)
+
+// checkPoints returns an error if points has a dangling coordinate or a NaN or infinite one
+func checkPoints(points FlatPoints) error {
+	if len(points)%2 != 0 {
+		return fmt.Errorf("%w: %d", ErrOddPointsLength, len(points))
+	}
+	for i, v := range points {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("%w: point %d has %v", ErrNonFiniteCoordinate, i/2, v)
+		}
+	}
+	return nil
+}
+
+// checkInterfacePoints is checkPoints for any collection of points. Collections reordering other values together
+// with FlatPoints are checked through their points, so that a dangling coordinate is not silently dropped
+func checkInterfacePoints(points Interface) error {
+	switch p := points.(type) {
+	case FlatPoints:
+		return checkPoints(p)
+	case attrPoints:
+		return checkPoints(p.points)
+	case discs:
+		return checkPoints(p.centers)
+	}
+	reader, _ := points.(*fallibleReader)
+	for i := 0; i < points.Len(); i++ {
+		x, y := points.GetPointAt(i)
+		if reader != nil && reader.err != nil {
+			return reader.err
+		}
+		for _, v := range [2]float64{x, y} {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("%w: point %d has %v", ErrNonFiniteCoordinate, i, v)
+			}
+		}
+	}
+	return nil
+}
+
+// checkSize returns an error wrapping ErrTooManyPoints if a tree cannot index n points
+func checkSize(n int) error {
+	if n >= math.MaxInt32/int(node_size) {
+		return fmt.Errorf("%w: %d points, the maximum is %d", ErrTooManyPoints, n, math.MaxInt32/int(node_size)-1)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package SimpleRTree
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestSimpleRTree_Errors(t *testing.T) {
+	points := FlatPoints{0, 0, 1, 1, 2, 2, 3, 3}
+	assert.ErrorIs(t, New().Rebuild(points), ErrNotBuilt)
+	assert.ErrorIs(t, New().RebuildRegion(BBox{0, 0, 1, 1}, points), ErrNotBuilt)
+	_, err := New().MarshalBinary()
+	assert.ErrorIs(t, err, ErrNotBuilt)
+
+	r := New().Load(append(FlatPoints{}, points...))
+	assert.ErrorIs(t, r.Rebuild(FlatPoints{0, 0, 1}), ErrOddPointsLength)
+	assert.ErrorIs(t, r.Rebuild(FlatPoints{0, 0, math.NaN(), 1}), ErrNonFiniteCoordinate)
+	assert.ErrorIs(t, r.Rebuild(FlatPoints{0, math.Inf(-1)}), ErrNonFiniteCoordinate)
+	assert.ErrorIs(t, r.RebuildRegion(BBox{0, 0, 1, 1}, FlatPoints{0, 0, 1}), ErrOddPointsLength)
+	assert.ErrorIs(t, r.RebuildRegion(BBox{0, 0, 1, 1}, FlatPoints{0, 0, math.Inf(1), 1}), ErrNonFiniteCoordinate)
+
+	data, err := r.MarshalBinary()
+	assert.NoError(t, err)
+	assert.ErrorIs(t, r.UnmarshalBinary(data), ErrAlreadyBuilt)
+	corrupt := append([]byte{}, data...)
+	corrupt[7] = 1
+	assert.ErrorIs(t, New().UnmarshalBinary(corrupt), ErrInvalidMaxEntries)
+	corrupt = append([]byte{}, data[:len(data)-binaryChecksumSize]...)
+	for i := len(corrupt) - 8; i < len(corrupt); i++ {
+		corrupt[i] = 0xff // NaN
+	}
+	corrupt = appendChecksum(corrupt)
+	assert.ErrorIs(t, New().UnmarshalBinary(corrupt), ErrNonFiniteCoordinate)
+
+	defer func() {
+		err, ok := recover().(error)
+		assert.True(t, ok)
+		assert.True(t, errors.Is(err, ErrInvalidMaxEntries))
+	}()
+	NewWithOptions(Options{MAX_ENTRIES: MAX_POSSIBLE_SIZE + 1})
+}
+
+// hugePoints claims more points than a tree can index, without storing them
+type hugePoints struct{}
+
+func (hugePoints) Len() int                        { return math.MaxInt32 / int(node_size) }
+func (hugePoints) Swap(i, j int)                   {}
+func (hugePoints) GetPointAt(i int) (x, y float64) { return 0, 0 }
+
+// recoverError runs f and returns the error it panics with, nil if it does not panic with an error
+func recoverError(f func()) (err error) {
+	defer func() {
+		err, _ = recover().(error)
+	}()
+	f()
+	return nil
+}
+
+func TestSimpleRTree_LoadPanics(t *testing.T) {
+	r := New().Load(FlatPoints{0, 0, 1, 1})
+	err := recoverError(func() {
+		r.Load(FlatPoints{2, 2})
+	})
+	assert.True(t, errors.Is(err, ErrAlreadyBuilt))
+
+	err = recoverError(func() {
+		New().LoadInterface(hugePoints{})
+	})
+	assert.True(t, errors.Is(err, ErrTooManyPoints))
+	assert.EqualError(t, err, fmt.Sprintf("too many points for a tree: %d points, the maximum is %d", hugePoints{}.Len(), hugePoints{}.Len()-1))
+}
+
+func TestSimpleRTree_LoadChecksPoints(t *testing.T) {
+	assert.True(t, errors.Is(recoverError(func() {
+		New().Load(FlatPoints{0, 0, 1})
+	}), ErrOddPointsLength))
+	assert.True(t, errors.Is(recoverError(func() {
+		New().Load(FlatPoints{5})
+	}), ErrOddPointsLength), "A single coordinate is not an empty tree")
+	assert.True(t, errors.Is(recoverError(func() {
+		New().Load(FlatPoints{0, 0, math.NaN(), 1})
+	}), ErrNonFiniteCoordinate))
+	assert.True(t, errors.Is(recoverError(func() {
+		New().LoadWithTimestamps(FlatPoints{0, 0, 1}, []float64{0})
+	}), ErrOddPointsLength))
+	assert.True(t, errors.Is(recoverError(func() {
+		p := randomPlaces(10)
+		p[3].y = math.Inf(-1)
+		New().LoadInterface(p)
+	}), ErrNonFiniteCoordinate))
+	assert.NoError(t, recoverError(func() {
+		New().Load(FlatPoints{})
+	}))
+
+	r := New().Load(FlatPoints{0, 0, 1, 1})
+	assert.ErrorIs(t, r.Rebuild(FlatPoints{}), ErrNoPoints)
+}
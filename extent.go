@@ -0,0 +1,20 @@
+package SimpleRTree
+
+import "math"
+
+// DistanceToExtentBoundary returns the distance, not squared, between x and y and the closest side of the bbox of all
+// the points, for example for edge effect corrections of spatial statistics near the border of the data. Inside the
+// bbox it is the distance to the closest side, outside it is the distance to the bbox, and it is 0 on the sides.
+// Points that are disabled still count for the extent. On an empty tree it returns +Inf
+func (r *SimpleRTree) DistanceToExtentBoundary(x, y float64) float64 {
+	if !r.built || len(r.nodes) == 0 {
+		return math.Inf(1)
+	}
+	b := r.rootBBox().ToBBox()
+	if x >= b.MinX && x <= b.MaxX && y >= b.MinY && y <= b.MaxY {
+		return math.Min(math.Min(x-b.MinX, b.MaxX-x), math.Min(y-b.MinY, b.MaxY-y))
+	}
+	dx := math.Max(0, math.Max(b.MinX-x, x-b.MaxX))
+	dy := math.Max(0, math.Max(b.MinY-y, y-b.MaxY))
+	return math.Hypot(dx, dy)
+}
@@ -0,0 +1,41 @@
+package SimpleRTree
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRTree_DistanceToExtentBoundary(t *testing.T) {
+	// extent is [0, 4] x [0, 2]
+	r := New().Load(FlatPoints{0, 0, 4, 2, 1, 1, 3, 0.5})
+	testCases := []struct {
+		x, y, expected float64
+	}{
+		{2, 1, 1},     // center, closest to the top and bottom sides
+		{0.5, 1, 0.5}, // inside, closest to the left side
+		{3, 1.75, 0.25},
+		{0, 1, 0}, // on the sides and corners
+		{2, 2, 0},
+		{4, 0, 0},
+		{-3, 1, 3}, // outside facing a side
+		{2, 2.5, 0.5},
+		{7, 6, 5}, // outside facing a corner
+		{-3, -4, 5},
+	}
+	for _, tc := range testCases {
+		assert.InDelta(t, tc.expected, r.DistanceToExtentBoundary(tc.x, tc.y), 1e-12, "(%v, %v)", tc.x, tc.y)
+	}
+
+	// larger trees, whose root bbox is computed from its children
+	points := generateDataset(uniformDataset, 10000, 1)
+	r = New().Load(points)
+	b := r.rootBBox().ToBBox()
+	assert.InDelta(t, b.MaxX-0.99, r.DistanceToExtentBoundary(0.99, 0.5), 1e-12)
+	assert.InDelta(t, 1-b.MaxX, r.DistanceToExtentBoundary(1, 0.5), 1e-12)
+
+	assert.Equal(t, math.Inf(1), New().DistanceToExtentBoundary(0, 0))
+	assert.Equal(t, 0., New().Load(FlatPoints{1, 1}).DistanceToExtentBoundary(1, 1))
+	assert.Equal(t, 5., New().Load(FlatPoints{1, 1}).DistanceToExtentBoundary(4, 5))
+}
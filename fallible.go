@@ -0,0 +1,68 @@
+package SimpleRTree
+
+import "fmt"
+
+// FallibleInterface is an Interface whose points are read from a source that can fail, for example coordinates
+// decoded lazily from a file. LoadInterfaceErr and LoadInterface read the points with GetPointAtErr while checking
+// and building the tree, so the source does not need to keep the points it decoded. Queries read them with
+// GetPointAt, which cannot report errors, so after a successful load the source must keep returning the points
+type FallibleInterface interface {
+	Interface
+	GetPointAtErr(i int) (x, y float64, err error)
+}
+
+// LoadInterfaceErr is LoadInterface returning an error instead of panicking. If points implements FallibleInterface
+// and reading a point fails, before or during the build, it returns the error, wrapped with the position of the
+// point, and the tree is left empty, so it can be loaded again. It also returns the errors Load panics with, such as
+// ErrAlreadyBuilt, ErrOddPointsLength or ErrNonFiniteCoordinate, leaving the tree as it was
+func (r *SimpleRTree) LoadInterfaceErr(points Interface) (*SimpleRTree, error) {
+	fallible, ok := points.(FallibleInterface)
+	var reader *fallibleReader
+	if ok {
+		reader = &fallibleReader{FallibleInterface: fallible}
+		points = reader
+	}
+	if err := r.checkLoad(points); err != nil {
+		return r, err
+	}
+	isSorted, err := r.spillSort(points, false)
+	if err != nil {
+		return r, err
+	}
+	r.loadChecked(points, isSorted)
+	if reader == nil {
+		return r, nil
+	}
+	if reader.err != nil {
+		r.reset()
+		return r, reader.err
+	}
+	if r.source != nil {
+		r.source = fallible
+	}
+	return r, nil
+}
+
+// fallibleReader reads the points of a FallibleInterface with GetPointAtErr and keeps the first error, points that
+// fail are read as (0, 0)
+type fallibleReader struct {
+	FallibleInterface
+	err error
+}
+
+func (f *fallibleReader) GetPointAt(i int) (x, y float64) {
+	x, y, err := f.GetPointAtErr(i)
+	if err != nil && f.err == nil {
+		f.err = fmt.Errorf("reading point %d: %w", i, err)
+	}
+	return x, y
+}
+
+// reset empties a tree whose build failed, so that it can be loaded again
+func (r *SimpleRTree) reset() {
+	r.built = false
+	r.nodes = r.nodes[:0]
+	r.points, r.source, r.leafPoints = nil, nil, nil
+	r.height = 0
+	r.centroids, r.hilbertValues, r.orientedBoxes, r.circles = nil, nil, nil, nil
+}
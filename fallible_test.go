@@ -0,0 +1,80 @@
+package SimpleRTree
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errDecode = errors.New("decode failed")
+
+// fallibleFlatPoints fails to read the point at position failAt, and any point once failAfter reads are done if it
+// is set, as a source failing in the middle of the build
+type fallibleFlatPoints struct {
+	FlatPoints
+	failAt    int
+	failAfter int
+	reads     int
+}
+
+func (fp *fallibleFlatPoints) GetPointAtErr(i int) (x, y float64, err error) {
+	fp.reads++
+	if i == fp.failAt || fp.failAfter > 0 && fp.reads > fp.failAfter {
+		return 0, 0, errDecode
+	}
+	x, y = fp.GetPointAt(i)
+	return x, y, nil
+}
+
+func TestSimpleRTree_LoadInterfaceErr(t *testing.T) {
+	const size = 1000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	source := &fallibleFlatPoints{FlatPoints: append(FlatPoints(nil), points...), failAt: 700}
+	r, err := New().LoadInterfaceErr(source)
+	assert.ErrorIs(t, err, errDecode)
+	assert.Contains(t, err.Error(), "point 700")
+	assert.Equal(t, 701, source.reads, "Reading stops at the first error")
+	assert.Equal(t, points, source.FlatPoints, "Points are not reordered")
+	assert.Empty(t, r.Search(BBox{0, 0, 1, 1}))
+	_, _, _, found := r.FindNearestPointWithin(0.5, 0.5, 1)
+	assert.False(t, found)
+	assert.Panics(t, func() {
+		New().LoadInterface(source)
+	})
+
+	// the same tree can be loaded once the source is fixed
+	// errors during the build, after the points were checked, leave the tree empty too
+	source.failAt, source.failAfter, source.reads = -1, 2*size, 0
+	r, err = r.LoadInterfaceErr(source)
+	assert.ErrorIs(t, err, errDecode)
+	assert.True(t, source.reads > size, "Failed during the build")
+	assert.False(t, r.built)
+	assert.Empty(t, r.Search(BBox{0, 0, 1, 1}))
+
+	source.failAfter = 0
+	r, err = r.LoadInterfaceErr(source)
+	assert.NoError(t, err)
+	assert.Equal(t, source, r.source, "Queries read the source")
+	assert.Len(t, r.Search(BBox{0, 0, 1, 1}), size)
+	x, y := rand.Float64(), rand.Float64()
+	_, _, d := r.FindNearestPoint(x, y)
+	_, _, expected := points.linearClosestPoint(x, y)
+	assert.Equal(t, expected, d)
+
+	// a loaded tree keeps its points, the errors Load panics with are returned
+	_, err = r.LoadInterfaceErr(source)
+	assert.ErrorIs(t, err, ErrAlreadyBuilt)
+	assert.Len(t, r.Search(BBox{0, 0, 1, 1}), size)
+	_, err = New().LoadInterfaceErr(FlatPoints{0, 0, 1})
+	assert.ErrorIs(t, err, ErrOddPointsLength)
+
+	// infallible collections load as with LoadInterface
+	r, err = New().LoadInterfaceErr(append(FlatPoints(nil), points...))
+	assert.NoError(t, err)
+	assert.Len(t, r.Search(BBox{0, 0, 1, 1}), size)
+}
@@ -0,0 +1,124 @@
+package SimpleRTree
+
+import "math"
+
+// FindFarthestPoint returns the point that is furthest from x and y.
+// It is the dual of FindNearestPoint: nodes are visited in decreasing order of the distance to their farthest corner,
+// and the search stops as soon as no remaining node can contain a point further than the best one.
+// Distance in the result is squared, as in the rest of queries
+func (r *SimpleRTree) FindFarthestPoint(x, y float64) (res Result, found bool) {
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			return -computeFarthestDistance(bbox, x, y), true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return -d, true
+		},
+		func(i int, px, py, priority float64) bool {
+			res = Result{Index: i, X: px, Y: py, Distance: -priority}
+			found = true
+			return false
+		},
+	)
+	return
+}
+
+// computeFarthestDistance returns the distance squared from (x, y) to the farthest corner of the bbox, which is an
+// upper bound of the distance to any point inside it
+func computeFarthestDistance(bbox VectorBBox, x, y float64) float64 {
+	dx := maxFloat((x-bbox[VECTOR_BBOX_MIN_X])*(x-bbox[VECTOR_BBOX_MIN_X]), (x-bbox[VECTOR_BBOX_MAX_X])*(x-bbox[VECTOR_BBOX_MAX_X]))
+	dy := maxFloat((y-bbox[VECTOR_BBOX_MIN_Y])*(y-bbox[VECTOR_BBOX_MIN_Y]), (y-bbox[VECTOR_BBOX_MAX_Y])*(y-bbox[VECTOR_BBOX_MAX_Y]))
+	return dx + dy
+}
+
+// FindExtremeInDirection returns the point that is furthest in the direction (dx, dy), that is the one maximizing
+// the dot product of its coordinates with (dx, dy), as the support function of the convex hull of the points.
+// Distance in the result holds the dot product. Nodes are visited in decreasing order of the maximum projection of
+// the corners of their bbox. found is false if the tree is empty or the direction is zero
+func (r *SimpleRTree) FindExtremeInDirection(dx, dy float64) (res Result, found bool) {
+	if dx == 0 && dy == 0 {
+		return
+	}
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			return -computeMaxProjection(bbox, dx, dy), true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			return -(px*dx + py*dy), true
+		},
+		func(i int, px, py, priority float64) bool {
+			res = Result{Index: i, X: px, Y: py, Distance: -priority}
+			found = true
+			return false
+		},
+	)
+	return
+}
+
+// computeMaxProjection returns the maximum dot product of (dx, dy) with the corners of the bbox, which is an upper
+// bound of the projection of any point inside it
+func computeMaxProjection(bbox VectorBBox, dx, dy float64) float64 {
+	return maxFloat(bbox[VECTOR_BBOX_MIN_X]*dx, bbox[VECTOR_BBOX_MAX_X]*dx) +
+		maxFloat(bbox[VECTOR_BBOX_MIN_Y]*dy, bbox[VECTOR_BBOX_MAX_Y]*dy)
+}
+
+// ExtremePoints answers FindExtremeInDirection for several directions in a single traversal, for example the 8
+// compass directions to get candidate vertices of the convex hull. Results are aligned with directions, with the dot
+// product in Distance. A zero direction, or any direction when every point is disabled, gets a Result with Index -1.
+// A node is only descended while it can improve the extreme of some direction, so the descent is shared by the
+// directions whose extremes lie in the same subtrees. It returns nil if the tree is empty
+func (r *SimpleRTree) ExtremePoints(directions [][2]float64) []Result {
+	if !r.built || len(r.nodes) == 0 {
+		return nil
+	}
+	results := make([]Result, len(directions))
+	for d, dir := range directions {
+		results[d] = Result{Index: -1, Distance: math.Inf(-1)}
+		if dir[0] == 0 && dir[1] == 0 {
+			// no point can improve it, so it never keeps a node
+			results[d].Distance = math.Inf(1)
+		}
+	}
+	stack := make([]int, 1, 32)
+	for len(stack) > 0 {
+		n := &r.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if n.nodeType == preleaf_node {
+			start := n.firstPointIndex()
+			for i := start; i < start+int(n.nChildren); i++ {
+				if r.disabled != nil && r.disabled[i] {
+					continue
+				}
+				px, py := r.getPointAt(i)
+				for d, dir := range directions {
+					if projection := px*dir[0] + py*dir[1]; projection > results[d].Distance {
+						results[d] = Result{Index: i, X: px, Y: py, Distance: projection}
+					}
+				}
+			}
+			continue
+		}
+		first := n.firstChildIndex()
+		for i := first; i < first+int(n.nChildren); i++ {
+			for d, dir := range directions {
+				if computeMaxProjection(r.nodes[i].BBox, dir[0], dir[1]) > results[d].Distance {
+					stack = append(stack, i)
+					break
+				}
+			}
+		}
+	}
+	for d := range results {
+		if results[d].Index < 0 {
+			results[d].Distance = 0
+		}
+	}
+	return results
+}
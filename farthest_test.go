@@ -0,0 +1,131 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSimpleRTree_FindFarthestPoint(t *testing.T) {
+	for _, size := range []int{1, 7, 100, 20000} {
+		for _, treeType := range []TreeType{STR, HILBERT} {
+			points := make([]float64, size*2)
+			for i := 0; i < 2*size; i++ {
+				points[i] = rand.Float64()
+			}
+			fp := FlatPoints(points)
+			r := NewWithOptions(Options{TreeType: treeType}).Load(fp)
+			for i := 0; i < 100; i++ {
+				x, y := rand.Float64()*3-1, rand.Float64()*3-1
+				res, found := r.FindFarthestPoint(x, y)
+				assert.True(t, found)
+				expected := fp.linearFarthestDistance(x, y)
+				assert.Equal(t, expected, res.Distance)
+				px, py := fp.GetPointAt(res.Index)
+				assert.Equal(t, px, res.X)
+				assert.Equal(t, py, res.Y)
+				assert.Equal(t, expected, computeLeafDistance(px, py, x, y))
+			}
+		}
+	}
+	_, found := New().FindFarthestPoint(0, 0)
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_FindExtremeInDirection(t *testing.T) {
+	for _, size := range []int{1, 7, 100, 20000} {
+		for _, treeType := range []TreeType{STR, HILBERT} {
+			points := make([]float64, size*2)
+			for i := 0; i < 2*size; i++ {
+				points[i] = rand.Float64()*2 - 1
+			}
+			fp := FlatPoints(points)
+			r := NewWithOptions(Options{TreeType: treeType}).Load(fp)
+			for i := 0; i < 100; i++ {
+				dx, dy := rand.Float64()*2-1, rand.Float64()*2-1
+				res, found := r.FindExtremeInDirection(dx, dy)
+				assert.True(t, found)
+				expected := math.Inf(-1)
+				for j := 0; j < fp.Len(); j++ {
+					px, py := fp.GetPointAt(j)
+					expected = maxFloat(expected, px*dx+py*dy)
+				}
+				assert.Equal(t, expected, res.Distance)
+				px, py := fp.GetPointAt(res.Index)
+				assert.Equal(t, []float64{px, py}, []float64{res.X, res.Y})
+			}
+		}
+	}
+	r := New().Load(FlatPoints{0, 0, 1, 0, 0, 1})
+	res, _ := r.FindExtremeInDirection(1, 0)
+	assert.Equal(t, []float64{1, 0}, []float64{res.X, res.Y})
+	res, _ = r.FindExtremeInDirection(0, 1)
+	assert.Equal(t, []float64{0, 1}, []float64{res.X, res.Y})
+	_, found := r.FindExtremeInDirection(0, 0)
+	assert.False(t, found)
+	_, found = New().FindExtremeInDirection(1, 1)
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_ExtremePoints(t *testing.T) {
+	compass := [][2]float64{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+	for _, size := range []int{1, 7, 100, 20000} {
+		for _, treeType := range []TreeType{STR, HILBERT} {
+			points := make([]float64, size*2)
+			for i := 0; i < 2*size; i++ {
+				points[i] = rand.Float64()*2 - 1
+			}
+			fp := FlatPoints(points)
+			r := NewWithOptions(Options{TreeType: treeType}).Load(fp)
+			directions := append([][2]float64(nil), compass...)
+			for i := 0; i < 8; i++ {
+				directions = append(directions, [2]float64{rand.Float64()*2 - 1, rand.Float64()*2 - 1})
+			}
+			results := r.ExtremePoints(directions)
+			assert.Len(t, results, len(directions))
+			for d, dir := range directions {
+				expected := math.Inf(-1)
+				for j := 0; j < fp.Len(); j++ {
+					px, py := fp.GetPointAt(j)
+					expected = maxFloat(expected, px*dir[0]+py*dir[1])
+				}
+				assert.Equal(t, expected, results[d].Distance)
+				px, py := fp.GetPointAt(results[d].Index)
+				assert.Equal(t, []float64{px, py}, []float64{results[d].X, results[d].Y})
+			}
+		}
+	}
+	r := New().Load(FlatPoints{0, 0, 1, 0, 0, 1})
+	results := r.ExtremePoints([][2]float64{{1, 0}, {0, 0}, {0, 1}})
+	assert.Equal(t, []float64{1, 0}, []float64{results[0].X, results[0].Y})
+	assert.Equal(t, Result{Index: -1}, results[1])
+	assert.Equal(t, []float64{0, 1}, []float64{results[2].X, results[2].Y})
+	assert.Empty(t, r.ExtremePoints(nil))
+	assert.Nil(t, New().ExtremePoints(compass))
+
+	// disabled points are skipped as in FindExtremeInDirection
+	r = New().Load(generateDataset(uniformDataset, 5000, rand.Int63()))
+	for i := 0; i < 4000; i++ {
+		r.SetEnabled(rand.Intn(5000), false)
+	}
+	for d, res := range r.ExtremePoints(compass) {
+		expected, found := r.FindExtremeInDirection(compass[d][0], compass[d][1])
+		assert.True(t, found)
+		assert.True(t, r.IsEnabled(res.Index))
+		assert.Equal(t, expected.Distance, res.Distance)
+	}
+	r = New().Load(FlatPoints{0, 0, 1, 0})
+	r.SetEnabled(0, false)
+	r.SetEnabled(1, false)
+	assert.Equal(t, []Result{{Index: -1}}, r.ExtremePoints(compass[:1]))
+}
+
+func (fp FlatPoints) linearFarthestDistance(x, y float64) float64 {
+	d := -1.
+	for i := 0; i < fp.Len(); i++ {
+		px, py := fp.GetPointAt(i)
+		d = maxFloat(d, computeLeafDistance(px, py, x, y))
+	}
+	return d
+}
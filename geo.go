@@ -0,0 +1,183 @@
+package SimpleRTree
+
+import "math"
+
+// Geographic queries treat the points as longitude and latitude in degrees, x being the longitude, and measure great
+// circle distances on a sphere of radius EARTH_RADIUS. Unlike the rest of queries their distances are not squared,
+// they are given in Options.DistanceUnit, or as an order only proxy with HaversineComparison
+
+// EARTH_RADIUS is the mean radius of the earth in meters
+const EARTH_RADIUS = 6371008.8
+
+// DistanceUnit is the unit of the distances returned by geographic queries
+type DistanceUnit uint8
+
+const (
+	Meters DistanceUnit = iota
+	Kilometers
+	Miles               // international miles, 1609.344 meters
+	HaversineComparison // haversine of the central angle, between 0 and 1. It grows with the distance, so it orders and compares distances as meters do, but it is not proportional to them. It saves the arcsine and square root of the conversion
+)
+
+// fromHaverSin converts the haversine of a central angle to the unit
+func (u DistanceUnit) fromHaverSin(h float64) float64 {
+	if u == HaversineComparison {
+		return h
+	}
+	m := haverSinToMeters(h)
+	switch u {
+	case Kilometers:
+		return m / 1000
+	case Miles:
+		return m / 1609.344
+	}
+	return m
+}
+
+// FindNearestGeo returns the point with the shortest great circle distance to lng and lat, with the distance in
+// Options.DistanceUnit. Nodes are pruned with the exact distance to their bbox, which takes into account that
+// meridians converge and that longitudes wrap around the antimeridian
+func (r *SimpleRTree) FindNearestGeo(lng, lat float64) (res Result, found bool) {
+	cosLat := math.Cos(lat * math.Pi / 180)
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			return haverSinBoxDist(lng, lat, cosLat, bbox), true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			return haverSinDist(lng, lat, px, py, cosLat), true
+		},
+		func(i int, px, py, h float64) bool {
+			res = Result{Index: i, X: px, Y: py, Distance: r.options.DistanceUnit.fromHaverSin(h)}
+			found = true
+			return false
+		},
+	)
+	return
+}
+
+// FindNearestGeoWithBearing is FindNearestGeo together with the initial great circle bearing from lng and lat to the
+// point, in degrees in [0, 360) clockwise from north. It is the geographic counterpart of FindNearestWithBearing.
+// If the point is at the query coordinates bearing is 0
+func (r *SimpleRTree) FindNearestGeoWithBearing(lng, lat float64) (res Result, bearingDegrees float64, found bool) {
+	res, found = r.FindNearestGeo(lng, lat)
+	if !found {
+		return
+	}
+	bearingDegrees = initialBearing(lng, lat, res.X, res.Y)
+	return
+}
+
+// initialBearing returns the bearing in degrees at (lng1, lat1) of the great circle going to (lng2, lat2)
+func initialBearing(lng1, lat1, lng2, lat2 float64) float64 {
+	phi1, phi2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dLambda := (lng2 - lng1) * math.Pi / 180
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+	if y == 0 && x == 0 {
+		return 0
+	}
+	bearing := math.Atan2(y, x) * 180 / math.Pi
+	if bearing < 0 {
+		bearing += 360
+	}
+	return bearing
+}
+
+// GeoDistance returns the great circle distance between two points given as longitude and latitude in degrees,
+// in Options.DistanceUnit
+func (r *SimpleRTree) GeoDistance(lng1, lat1, lng2, lat2 float64) float64 {
+	h := haverSinDist(lng1, lat1, lng2, lat2, math.Cos(lat1*math.Pi/180))
+	return r.options.DistanceUnit.fromHaverSin(h)
+}
+
+// Haversine helpers follow https://github.com/mourner/geokdbush. Distances are kept as the haversine of the central
+// angle, which grows with the distance, and only converted at the end
+
+func haverSin(theta float64) float64 {
+	s := math.Sin(theta / 2)
+	return s * s
+}
+
+// haverSinToMeters converts the haversine of a central angle to meters
+func haverSinToMeters(h float64) float64 {
+	return 2 * EARTH_RADIUS * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
+
+func haverSinDistPartial(haverSinDLng, cosLat1, lat1, lat2 float64) float64 {
+	return cosLat1*math.Cos(lat2*math.Pi/180)*haverSinDLng + haverSin((lat1-lat2)*math.Pi/180)
+}
+
+func haverSinDist(lng1, lat1, lng2, lat2, cosLat1 float64) float64 {
+	return haverSinDistPartial(haverSin((lng1-lng2)*math.Pi/180), cosLat1, lat1, lat2)
+}
+
+// haverSinBoxDist returns the haversine of the central angle between lng, lat and the closest point of bbox
+func haverSinBoxDist(lng, lat, cosLat float64, bbox VectorBBox) float64 {
+	minLng, minLat := bbox[VECTOR_BBOX_MIN_X], bbox[VECTOR_BBOX_MIN_Y]
+	maxLng, maxLat := bbox[VECTOR_BBOX_MAX_X], bbox[VECTOR_BBOX_MAX_Y]
+	// between the meridians of the box the closest point is straight north or south
+	if lng >= minLng && lng <= maxLng {
+		if lat < minLat {
+			return haverSin((lat - minLat) * math.Pi / 180)
+		}
+		if lat > maxLat {
+			return haverSin((lat - maxLat) * math.Pi / 180)
+		}
+		return 0
+	}
+	// otherwise it is on the closest meridian, at the latitude where the great circle distance to it is minimum
+	haverSinDLng := math.Min(haverSin((minLng-lng)*math.Pi/180), haverSin((maxLng-lng)*math.Pi/180))
+	extremumLat := vertexLat(lat, haverSinDLng)
+	if extremumLat > minLat && extremumLat < maxLat {
+		return haverSinDistPartial(haverSinDLng, cosLat, lat, extremumLat)
+	}
+	return math.Min(
+		haverSinDistPartial(haverSinDLng, cosLat, lat, minLat),
+		haverSinDistPartial(haverSinDLng, cosLat, lat, maxLat),
+	)
+}
+
+// vertexLat returns the latitude of the point of a meridian closest to lat, haverSinDLng away in longitude
+func vertexLat(lat, haverSinDLng float64) float64 {
+	cosDLng := 1 - 2*haverSinDLng
+	if cosDLng <= 0 {
+		if lat > 0 {
+			return 90
+		}
+		return -90
+	}
+	return math.Atan(math.Tan(lat*math.Pi/180)/cosDLng) * 180 / math.Pi
+}
+
+// TileBBox returns the bbox in longitude and latitude of the tile z/x/y of the XYZ scheme used by web maps, also
+// known as slippy map tiles: at zoom z the Web Mercator square is split in 2**z by 2**z tiles, x grows to the east
+// from the antimeridian and y grows to the south from latitude 85.0511. ok is false if the tile does not exist
+func TileBBox(z, x, y int) (box BBox, ok bool) {
+	if z < 0 || z > 30 || x < 0 || y < 0 || x >= 1<<uint(z) || y >= 1<<uint(z) {
+		return BBox{}, false
+	}
+	n := float64(int(1) << uint(z))
+	tileLat := func(y float64) float64 {
+		return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+	}
+	return BBox{
+		MinX: float64(x)/n*360 - 180,
+		MinY: tileLat(float64(y + 1)),
+		MaxX: float64(x+1)/n*360 - 180,
+		MaxY: tileLat(float64(y)),
+	}, true
+}
+
+// SearchTile returns the indexes of the points, as longitude and latitude, inside the tile z/x/y, see TileBBox.
+// As in Search the boundary is included, so points on the edge between two tiles are returned for both.
+// It returns nil if the tile does not exist
+func (r *SimpleRTree) SearchTile(z, x, y int) []int {
+	box, ok := TileBBox(z, x, y)
+	if !ok {
+		return nil
+	}
+	return r.Search(box)
+}
@@ -0,0 +1,153 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSimpleRTree_GeoDistanceUnits(t *testing.T) {
+	// one degree along a meridian is an arc of EARTH_RADIUS * pi / 180
+	const degree = 111195.08023353292
+	testCases := []struct {
+		unit     DistanceUnit
+		expected float64
+	}{
+		{Meters, degree},
+		{Kilometers, degree / 1000},
+		{Miles, degree / 1609.344},
+	}
+	for _, tc := range testCases {
+		r := NewWithOptions(Options{DistanceUnit: tc.unit})
+		assert.InDelta(t, tc.expected, r.GeoDistance(10, 20, 10, 21), tc.expected*1e-12)
+		// a quarter of the equator
+		assert.InDelta(t, 90*tc.expected, r.GeoDistance(0, 0, 90, 0), tc.expected*1e-10)
+	}
+	// Paris to London, about 343.5 km
+	assert.InDelta(t, 343.5, NewWithOptions(Options{DistanceUnit: Kilometers}).GeoDistance(2.3522, 48.8566, -0.1278, 51.5074), 0.5)
+}
+
+func TestSimpleRTree_GeoDistanceComparison(t *testing.T) {
+	meters := New()
+	comparison := NewWithOptions(Options{DistanceUnit: HaversineComparison})
+	for n := 0; n < 1000; n++ {
+		lng, lat := rand.Float64()*360-180, rand.Float64()*180-90
+		var lngs, lats [2]float64
+		var dMeters, dComparison [2]float64
+		for i := range lngs {
+			lngs[i], lats[i] = rand.Float64()*360-180, rand.Float64()*180-90
+			dMeters[i] = meters.GeoDistance(lng, lat, lngs[i], lats[i])
+			dComparison[i] = comparison.GeoDistance(lng, lat, lngs[i], lats[i])
+			assert.True(t, dComparison[i] >= 0 && dComparison[i] <= 1)
+		}
+		// distances too close to tell apart in meters are not checked
+		if math.Abs(dMeters[0]-dMeters[1]) > 1e-6 {
+			assert.Equal(t, dMeters[0] < dMeters[1], dComparison[0] < dComparison[1])
+		}
+	}
+	assert.Zero(t, comparison.GeoDistance(10, 20, 10, 20))
+	// antipodes
+	assert.InDelta(t, 1, comparison.GeoDistance(0, 0, 180, 0), 1e-12)
+}
+
+func TestSimpleRTree_FindNearestGeo(t *testing.T) {
+	const size = 5000
+	points := make(FlatPoints, 0, size*2)
+	for i := 0; i < size; i++ {
+		points = append(points, rand.Float64()*360-180, rand.Float64()*170-85)
+	}
+	for _, unit := range []DistanceUnit{Meters, Kilometers, Miles, HaversineComparison} {
+		r := NewWithOptions(Options{DistanceUnit: unit}).Load(append(FlatPoints{}, points...))
+		for n := 0; n < 200; n++ {
+			// queries near the poles and the antimeridian included
+			lng, lat := rand.Float64()*360-180, rand.Float64()*180-90
+			res, found := r.FindNearestGeo(lng, lat)
+			assert.True(t, found)
+			expected := math.Inf(1)
+			for i := 0; i < points.Len(); i++ {
+				px, py := points.GetPointAt(i)
+				expected = math.Min(expected, r.GeoDistance(lng, lat, px, py))
+			}
+			assert.InDelta(t, expected, res.Distance, expected*1e-9)
+			assert.InDelta(t, res.Distance, r.GeoDistance(lng, lat, res.X, res.Y), expected*1e-9)
+		}
+	}
+	// across the antimeridian the closest point is on the other side
+	r := New().Load(FlatPoints{179.5, 0, 170, 0})
+	res, _ := r.FindNearestGeo(-179.5, 0)
+	assert.Equal(t, 179.5, res.X)
+	_, found := New().FindNearestGeo(0, 0)
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_FindNearestGeoWithBearing(t *testing.T) {
+	testCases := []struct {
+		queryLat float64
+		lng, lat float64
+		bearing  float64
+	}{
+		{0, 0, 1, 0},
+		{0, 1, 0, 90},
+		{0, 0, -1, 180},
+		{0, -1, 0, 270},
+		// great circles towards the east start heading north away from the equator
+		{60, 90, 60, math.Atan2(0.5, math.Sqrt(3)/4) * 180 / math.Pi},
+	}
+	for _, tc := range testCases {
+		r := New().Load(FlatPoints{tc.lng, tc.lat, 150, -70})
+		res, bearing, found := r.FindNearestGeoWithBearing(0, tc.queryLat)
+		assert.True(t, found)
+		assert.Equal(t, [2]float64{tc.lng, tc.lat}, [2]float64{res.X, res.Y})
+		assert.InDelta(t, r.GeoDistance(0, tc.queryLat, tc.lng, tc.lat), res.Distance, 1e-6)
+		assert.InDelta(t, tc.bearing, bearing, 1e-9, "Point %v %v", tc.lng, tc.lat)
+	}
+	// east across the antimeridian
+	r := New().Load(FlatPoints{-179.5, 0})
+	_, bearing, _ := r.FindNearestGeoWithBearing(179.5, 0)
+	assert.InDelta(t, 90, bearing, 1e-9)
+	_, bearing, _ = r.FindNearestGeoWithBearing(-179.5, 0)
+	assert.Zero(t, bearing)
+	_, _, found := New().FindNearestGeoWithBearing(0, 0)
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_SearchTile(t *testing.T) {
+	const maxLat = 85.0511287798066
+	box, ok := TileBBox(0, 0, 0)
+	assert.True(t, ok)
+	assert.InDeltaSlice(t, []float64{-180, -maxLat, 180, maxLat}, []float64{box.MinX, box.MinY, box.MaxX, box.MaxY}, 1e-9)
+	box, _ = TileBBox(1, 1, 0)
+	assert.InDeltaSlice(t, []float64{0, 0, 180, maxLat}, []float64{box.MinX, box.MinY, box.MaxX, box.MaxY}, 1e-9)
+	// Paris at zoom 10 is tile 518/352
+	box, _ = TileBBox(10, 518, 352)
+	assert.InDeltaSlice(t, []float64{2.109375, 48.69096039092549, 2.4609375, 48.922499263758255}, []float64{box.MinX, box.MinY, box.MaxX, box.MaxY}, 1e-9)
+	assert.True(t, box.containsPoint(2.3522, 48.8566))
+	for _, tile := range [][3]int{{-1, 0, 0}, {0, 1, 0}, {2, 0, 4}, {3, -1, 0}} {
+		_, ok := TileBBox(tile[0], tile[1], tile[2])
+		assert.False(t, ok)
+	}
+
+	const size = 5000
+	points := make(FlatPoints, 0, size*2)
+	for i := 0; i < size; i++ {
+		points = append(points, rand.Float64()*360-180, rand.Float64()*170-85)
+	}
+	r := New().Load(points)
+	for z := 0; z < 5; z++ {
+		total := 0
+		for x := 0; x < 1<<uint(z); x++ {
+			for y := 0; y < 1<<uint(z); y++ {
+				results := r.SearchTile(z, x, y)
+				box, _ := TileBBox(z, x, y)
+				sort.Ints(results)
+				assert.Equal(t, points.linearSearch(box), results)
+				total += len(results)
+			}
+		}
+		// random points are never on the edges of the tiles
+		assert.Equal(t, size, total)
+	}
+	assert.Nil(t, r.SearchTile(1, 2, 0))
+}
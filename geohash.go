@@ -28,7 +28,7 @@ func interleave(x, y uint32) uint64 {
 }
 
 type GeoHashSorter struct {
-	points FlatPoints
+	points Interface
 	hashes []uint64
 }
 
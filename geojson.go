@@ -0,0 +1,124 @@
+package SimpleRTree
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// GeoJSONFilter selects what WriteGeoJSON writes. The zero value writes the bboxes of all the nodes.
+// Height of a node is the number of levels of nodes below it plus one: nodes holding points have height 1 in
+// balanced trees and the root has the height of the tree
+type GeoJSONFilter struct {
+	LeavesOnly bool // Only write the leaves of the tree, that is the points, as Point features with their position in the tree
+	MinHeight  int  // Only write nodes with at least this height
+	MaxHeight  int  // Only write nodes with at most this height, zero means no limit. Set MinHeight and MaxHeight to the same value for a single level
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+}
+
+// WriteGeoJSON writes the tree to w as a GeoJSON FeatureCollection for visual debugging.
+// Nodes are written as Polygon features of their bboxes, with their height and number of children as properties.
+// The bbox of the root is not always computed, so the root is written with the bbox of its children
+func (r *SimpleRTree) WriteGeoJSON(w io.Writer, filter GeoJSONFilter) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(`{"type":"FeatureCollection","features":[`); err != nil {
+		return err
+	}
+	first := true
+	write := func(f geoJSONFeature) error {
+		if !first {
+			if err := bw.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		first = false
+		b, err := json.Marshal(f)
+		if err != nil {
+			return err
+		}
+		_, err = bw.Write(b)
+		return err
+	}
+	if r.built && len(r.nodes) > 0 {
+		if err := r.writeGeoJSONNode(0, r.height, filter, write); err != nil {
+			return err
+		}
+	}
+	if _, err := bw.WriteString("]}\n"); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (r *SimpleRTree) writeGeoJSONNode(i, height int, filter GeoJSONFilter, write func(f geoJSONFeature) error) error {
+	n := &r.nodes[i]
+	bbox := n.BBox
+	if i == 0 {
+		bbox = r.rootBBox()
+	}
+	if !filter.LeavesOnly && height >= filter.MinHeight && (filter.MaxHeight == 0 || height <= filter.MaxHeight) {
+		b := bbox.ToBBox()
+		err := write(geoJSONFeature{
+			Type:       "Feature",
+			Properties: map[string]interface{}{"height": height, "children": n.nChildren},
+			Geometry: geoJSONGeometry{
+				Type: "Polygon",
+				Coordinates: [][][2]float64{{
+					{b.MinX, b.MinY}, {b.MaxX, b.MinY}, {b.MaxX, b.MaxY}, {b.MinX, b.MaxY}, {b.MinX, b.MinY},
+				}},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if n.nodeType == preleaf_node {
+		if !filter.LeavesOnly {
+			return nil
+		}
+		start := n.firstPointIndex()
+		for j := start; j < start+int(n.nChildren); j++ {
+			x, y := r.getPointAt(j)
+			err := write(geoJSONFeature{
+				Type:       "Feature",
+				Properties: map[string]interface{}{"index": j},
+				Geometry:   geoJSONGeometry{Type: "Point", Coordinates: [2]float64{x, y}},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	first := n.firstChildIndex()
+	for j := first; j < first+int(n.nChildren); j++ {
+		if err := r.writeGeoJSONNode(j, height-1, filter, write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rootBBox returns the bbox of all the points, the root node does not always store it
+func (r *SimpleRTree) rootBBox() VectorBBox {
+	root := &r.nodes[0]
+	if root.nodeType == preleaf_node {
+		return root.BBox
+	}
+	first := root.firstChildIndex()
+	bbox := r.nodes[first].BBox
+	for j := first + 1; j < first+int(root.nChildren); j++ {
+		bbox = VectorBBoxExtend(bbox, r.nodes[j].BBox)
+	}
+	return bbox
+}
@@ -0,0 +1,74 @@
+package SimpleRTree
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+type geoJSONCollection struct {
+	Type     string `json:"type"`
+	Features []struct {
+		Properties map[string]float64 `json:"properties"`
+		Geometry   struct {
+			Type string `json:"type"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+func writeGeoJSON(t *testing.T, r *SimpleRTree, filter GeoJSONFilter) geoJSONCollection {
+	var buf bytes.Buffer
+	assert.NoError(t, r.WriteGeoJSON(&buf, filter))
+	var collection geoJSONCollection
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &collection))
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	return collection
+}
+
+// nodeHeights counts the nodes at each height
+func (r *SimpleRTree) nodeHeights(i, height int, counts map[int]int) {
+	counts[height]++
+	n := &r.nodes[i]
+	if n.nodeType == preleaf_node {
+		return
+	}
+	for j := n.firstChildIndex(); j < n.firstChildIndex()+int(n.nChildren); j++ {
+		r.nodeHeights(j, height-1, counts)
+	}
+}
+
+func TestSimpleRTree_WriteGeoJSON(t *testing.T) {
+	const size = 5000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	counts := make(map[int]int)
+	r.nodeHeights(0, r.height, counts)
+
+	all := writeGeoJSON(t, r, GeoJSONFilter{})
+	assert.Len(t, all.Features, len(r.nodes))
+
+	leaves := writeGeoJSON(t, r, GeoJSONFilter{LeavesOnly: true})
+	assert.Len(t, leaves.Features, points.Len())
+	for _, f := range leaves.Features {
+		assert.Equal(t, "Point", f.Geometry.Type)
+	}
+
+	for height := 1; height <= r.height; height++ {
+		level := writeGeoJSON(t, r, GeoJSONFilter{MinHeight: height, MaxHeight: height})
+		assert.Len(t, level.Features, counts[height], "Height %d", height)
+		for _, f := range level.Features {
+			assert.Equal(t, "Polygon", f.Geometry.Type)
+			assert.Equal(t, float64(height), f.Properties["height"])
+		}
+	}
+	upper := writeGeoJSON(t, r, GeoJSONFilter{MinHeight: 2})
+	assert.Len(t, upper.Features, len(r.nodes)-counts[1])
+
+	empty := writeGeoJSON(t, New(), GeoJSONFilter{})
+	assert.Empty(t, empty.Features)
+}
@@ -0,0 +1,83 @@
+package SimpleRTree
+
+import "math"
+
+// LoadGrouped builds the RTree collapsing points with identical coordinates into a single point, so that queries
+// on stacked data do not visit every copy. The members of each group can be retrieved with FindNearestGroup.
+// Indexes in results refer to the positions of the groups in the tree, not to points.
+//
+// Unlike Load, points are copied and the caller's array is not modified. Members are indexes in points.
+//
+// Members are stored as runs of consecutive indexes, so a group whose copies are contiguous in points, as in sorted
+// or snapped data, costs the same whatever its size. Copies scattered through points take one run each
+func (r *SimpleRTree) LoadGrouped(points FlatPoints) *SimpleRTree {
+	members := make(map[[2]float64]indexRuns)
+	// unique grows with the groups, stacked data has far fewer of them than points
+	var unique FlatPoints
+	for i := 0; i < points.Len(); i++ {
+		x, y := points.GetPointAt(i)
+		key := [2]float64{x, y}
+		runs, ok := members[key]
+		if !ok {
+			unique = append(unique, x, y)
+		}
+		members[key] = runs.add(i)
+	}
+	r.load(unique, false)
+	r.groups = make([]indexRuns, unique.Len())
+	for i := range r.groups {
+		x, y := unique.GetPointAt(i)
+		r.groups[i] = members[[2]float64{x, y}]
+	}
+	return r
+}
+
+// FindNearestGroup returns the coordinates of the closest point to x and y, the indexes of all the points
+// sharing them and the distance squared to them.
+// If the tree was loaded with LoadGrouped, members are indexes in the array given to it, in increasing order,
+// expanded from their runs on every call. Otherwise every point is its own group and members only contains its index
+func (r *SimpleRTree) FindNearestGroup(x, y float64) (coord [2]float64, members []int, d float64, found bool) {
+	res, found := r.findNearestPointWithin(x, y, math.Inf(1), nil)
+	if !found {
+		return
+	}
+	coord = [2]float64{res.X, res.Y}
+	if r.groups != nil {
+		members = r.groups[res.Index].expand()
+	} else {
+		members = []int{res.Index}
+	}
+	return coord, members, res.Distance, true
+}
+
+// indexRuns is a sorted set of indexes stored as runs of consecutive values, pairs of first index and length
+type indexRuns []int
+
+// add appends i, which must be greater than every index in the set, and returns the updated set
+func (runs indexRuns) add(i int) indexRuns {
+	if n := len(runs); n > 0 && runs[n-2]+runs[n-1] == i {
+		runs[n-1]++
+		return runs
+	}
+	return append(runs, i, 1)
+}
+
+// len returns the number of indexes in the set
+func (runs indexRuns) len() int {
+	n := 0
+	for k := 1; k < len(runs); k += 2 {
+		n += runs[k]
+	}
+	return n
+}
+
+// expand returns the indexes of the set in increasing order
+func (runs indexRuns) expand() []int {
+	indexes := make([]int, 0, runs.len())
+	for k := 0; k < len(runs); k += 2 {
+		for i := runs[k]; i < runs[k]+runs[k+1]; i++ {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
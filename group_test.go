@@ -0,0 +1,114 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSimpleRTree_FindNearestGroup(t *testing.T) {
+	const size = 20000
+	// heavily stacked points, on a grid of 20 x 20 positions
+	points := make(FlatPoints, 0, 2*size)
+	for i := 0; i < size; i++ {
+		points = append(points, float64(rand.Intn(20)), float64(rand.Intn(20)))
+	}
+	original := append(FlatPoints(nil), points...)
+	r := New().LoadGrouped(points)
+	assert.Equal(t, original, points, "Caller's points are not modified")
+	assert.Equal(t, 20*20, r.LeafPointsInOrder().Len())
+
+	seen := make([]bool, size)
+	for gx := 0; gx < 20; gx++ {
+		for gy := 0; gy < 20; gy++ {
+			x, y := float64(gx)+0.1, float64(gy)-0.1
+			coord, members, d, found := r.FindNearestGroup(x, y)
+			assert.True(t, found)
+			assert.Equal(t, [2]float64{float64(gx), float64(gy)}, coord)
+			assert.InDelta(t, 0.02, d, 1e-12)
+			var expected []int
+			for i := 0; i < points.Len(); i++ {
+				if px, py := points.GetPointAt(i); px == coord[0] && py == coord[1] {
+					expected = append(expected, i)
+				}
+			}
+			actual := append([]int(nil), members...)
+			sort.Ints(actual)
+			assert.Equal(t, expected, actual)
+			for _, m := range members {
+				assert.False(t, seen[m])
+				seen[m] = true
+			}
+		}
+	}
+	for i := range seen {
+		assert.True(t, seen[i], "Point %d in some group", i)
+	}
+
+	fp := FlatPoints{1, 1, 1, 1, 3, 3}
+	coord, members, d, found := New().Load(fp).FindNearestGroup(3, 2)
+	assert.True(t, found)
+	assert.Equal(t, [2]float64{3, 3}, coord)
+	assert.Equal(t, 1., d)
+	assert.Len(t, members, 1)
+	px, py := fp.GetPointAt(members[0])
+	assert.Equal(t, [2]float64{3, 3}, [2]float64{px, py})
+
+	_, _, _, found = New().LoadGrouped(FlatPoints{}).FindNearestGroup(0, 0)
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_FindNearestGroupRuns(t *testing.T) {
+	var runs indexRuns
+	for _, i := range []int{0, 1, 2, 5, 7, 8} {
+		runs = runs.add(i)
+	}
+	assert.Equal(t, indexRuns{0, 3, 5, 1, 7, 2}, runs)
+	assert.Equal(t, 6, runs.len())
+	assert.Equal(t, []int{0, 1, 2, 5, 7, 8}, runs.expand())
+
+	// contiguous copies take a single run whatever their number
+	const copies = 10000
+	var points FlatPoints
+	for _, p := range [][2]float64{{0, 0}, {1, 1}, {2, 2}, {0, 0}} {
+		for i := 0; i < copies; i++ {
+			points = append(points, p[0], p[1])
+		}
+	}
+	r := New().LoadGrouped(points)
+	for i, x := range []float64{0, 1, 2} {
+		coord, members, _, found := r.FindNearestGroup(x, x)
+		assert.True(t, found)
+		assert.Equal(t, [2]float64{x, x}, coord)
+		expected := make([]int, 0, 2*copies)
+		for j := i * copies; j < (i+1)*copies; j++ {
+			expected = append(expected, j)
+		}
+		if x == 0 {
+			for j := 3 * copies; j < 4*copies; j++ {
+				expected = append(expected, j)
+			}
+		}
+		assert.Equal(t, expected, members)
+	}
+	for _, runs := range r.groups {
+		assert.True(t, len(runs) <= 4)
+	}
+}
+
+func BenchmarkSimpleRTree_LoadGrouped(b *testing.B) {
+	// 1000 positions with 1000 contiguous copies each, as snapped tracks
+	var points FlatPoints
+	for p := 0; p < 1000; p++ {
+		x, y := rand.Float64(), rand.Float64()
+		for i := 0; i < 1000; i++ {
+			points = append(points, x, y)
+		}
+	}
+	var r *SimpleRTree
+	for n := 0; n < b.N; n++ {
+		r = New().LoadGrouped(points)
+	}
+	b.ReportMetric(float64(r.MemoryUsage()), "tree-bytes")
+}
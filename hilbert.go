@@ -0,0 +1,99 @@
+package SimpleRTree
+
+import (
+	"math"
+	"sort"
+)
+
+// HILBERT_ORDER is the order of the Hilbert curve used by HilbertSortKey: coordinates are quantized to a grid of
+// 2**HILBERT_ORDER cells per axis, so values fit in 2 * HILBERT_ORDER bits
+const HILBERT_ORDER = 16
+
+// HilbertSortKey returns a function mapping coordinates inside box to their value along a Hilbert curve of order
+// HILBERT_ORDER covering box, as in flatbush. The curve starts at the min corner of box, moves along y first and
+// ends at the corner with max x and min y. Coordinates outside of box are clamped to it.
+// It can be given to LoadWithSortKey to pack the tree along the curve
+func HilbertSortKey(box BBox) func(x, y float64) uint64 {
+	const maxCell = 1<<HILBERT_ORDER - 1
+	quantize := func(v, min, max float64) uint32 {
+		if !(max > min) {
+			return 0
+		}
+		return uint32(math.Round(math.Min(math.Max((v-min)/(max-min), 0), 1) * maxCell))
+	}
+	return func(x, y float64) uint64 {
+		return hilbertIndex(HILBERT_ORDER, quantize(x, box.MinX, box.MaxX), quantize(y, box.MinY, box.MaxY))
+	}
+}
+
+// hilbertIndex returns the position of the cell x, y along the Hilbert curve of the given order
+func hilbertIndex(order uint, x, y uint32) uint64 {
+	n := uint32(1) << order
+	var d uint64
+	for s := uint32(1) << (order - 1); s > 0; s >>= 1 {
+		var rx, ry uint32
+		if x&s > 0 {
+			rx = 1
+		}
+		if y&s > 0 {
+			ry = 1
+		}
+		d += uint64(s) * uint64(s) * uint64((3*rx)^ry)
+		// rotate the quadrant so that the curve inside it has the orientation of the whole curve
+		if ry == 0 {
+			if rx == 1 {
+				x, y = n-1-x, n-1-y
+			}
+			x, y = y, x
+		}
+	}
+	return d
+}
+
+// computeHilbertValues sets the Hilbert value of every point, along the curve covering the bbox of all the points
+func (r *SimpleRTree) computeHilbertValues() {
+	if cap(r.hilbertValues) >= r.getLen() {
+		r.hilbertValues = r.hilbertValues[:r.getLen()]
+	} else {
+		r.hilbertValues = make([]uint64, r.getLen())
+	}
+	if len(r.nodes) == 0 {
+		return
+	}
+	key := HilbertSortKey(r.rootBBox().ToBBox())
+	for i := range r.hilbertValues {
+		r.hilbertValues[i] = key(r.getPointAt(i))
+	}
+}
+
+// HilbertValue returns the value of the point at position idx along the Hilbert curve of HilbertSortKey over the
+// bbox of all the points, and whether the tree stores them, see Options.StoreHilbertValues.
+// Values depend on the bbox of the points, they are computed again on every build
+func (r *SimpleRTree) HilbertValue(idx int) (value uint64, ok bool) {
+	if r.hilbertValues == nil {
+		return 0, false
+	}
+	return r.hilbertValues[idx], true
+}
+
+// HilbertOrder returns the positions of the points leaf by leaf, in the order of the leaves, with the points of each
+// leaf sorted by their Hilbert value, see HilbertValue. Trees loaded with LoadWithSortKey and HilbertSortKey over the
+// bbox of the points are sorted along the curve, then it is the identity. It returns nil unless the tree was built
+// with Options.StoreHilbertValues
+func (r *SimpleRTree) HilbertOrder() []int {
+	if r.hilbertValues == nil || !r.built {
+		return nil
+	}
+	order := make([]int, 0, r.getLen())
+	for _, leaf := range r.cellNodes(0) {
+		start, end := r.nodePointRange(leaf)
+		for i := start; i < end; i++ {
+			order = append(order, i)
+		}
+		positions := order[len(order)-(end-start):]
+		sort.SliceStable(positions, func(a, b int) bool {
+			return r.hilbertValues[positions[a]] < r.hilbertValues[positions[b]]
+		})
+	}
+	return order
+}
@@ -0,0 +1,82 @@
+package SimpleRTree
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHilbertIndex(t *testing.T) {
+	// order 1 visits (0, 0), (0, 1), (1, 1), (1, 0)
+	assert.Equal(t, []uint64{0, 1, 2, 3}, []uint64{hilbertIndex(1, 0, 0), hilbertIndex(1, 0, 1), hilbertIndex(1, 1, 1), hilbertIndex(1, 1, 0)})
+	// consecutive values of the curve are neighbour cells, and every cell is visited once
+	const order = 4
+	const side = 1 << order
+	cells := make([][2]uint32, side*side)
+	seen := make(map[uint64]bool)
+	for x := uint32(0); x < side; x++ {
+		for y := uint32(0); y < side; y++ {
+			d := hilbertIndex(order, x, y)
+			assert.False(t, seen[d])
+			seen[d] = true
+			cells[d] = [2]uint32{x, y}
+		}
+	}
+	for d := 1; d < len(cells); d++ {
+		dx := int(cells[d][0]) - int(cells[d-1][0])
+		dy := int(cells[d][1]) - int(cells[d-1][1])
+		assert.Equal(t, 1, dx*dx+dy*dy, "Cells %v and %v are adjacent", cells[d-1], cells[d])
+	}
+	key := HilbertSortKey(BBox{0, 0, 1, 1})
+	assert.Equal(t, uint64(0), key(0, 0))
+	assert.Equal(t, uint64(1<<(2*HILBERT_ORDER)-1), key(1, 0))
+	assert.Equal(t, key(1, 1), key(5, 5), "Coordinates are clamped")
+}
+
+func TestSimpleRTree_HilbertOrder(t *testing.T) {
+	const size = 5000
+	for _, options := range []Options{{StoreHilbertValues: true}, {StoreHilbertValues: true, TreeType: HILBERT}, {StoreHilbertValues: true, MAX_ENTRIES: 3}} {
+		points := generateDataset(clusteredDataset, size, rand.Int63())
+		r := NewWithOptions(options).Load(points)
+		order := r.HilbertOrder()
+		sorted := append([]int(nil), order...)
+		sort.Ints(sorted)
+		for i := range sorted {
+			assert.Equal(t, i, sorted[i], "Order is a permutation")
+		}
+		key := HilbertSortKey(r.rootBBox().ToBBox())
+		for _, leaf := range r.cellNodes(0) {
+			start, end := r.nodePointRange(leaf)
+			for i := start; i < end; i++ {
+				value, ok := r.HilbertValue(order[i])
+				assert.True(t, ok)
+				assert.Equal(t, key(points.GetPointAt(order[i])), value)
+				assert.GreaterOrEqual(t, order[i], start, "Points stay in their leaf")
+				assert.Less(t, order[i], end)
+				if i > start {
+					previous, _ := r.HilbertValue(order[i-1])
+					assert.LessOrEqual(t, previous, value)
+				}
+			}
+		}
+	}
+
+	// packed along the same curve the order is the identity
+	points := generateDataset(uniformDataset, 1000, 1)
+	x0, y0 := points.GetPointAt(0)
+	box := VectorBBox{x0, y0, x0, y0}
+	for i := 1; i < points.Len(); i++ {
+		x, y := points.GetPointAt(i)
+		box = VectorBBoxExtend(box, VectorBBox{x, y, x, y})
+	}
+	r := NewWithOptions(Options{StoreHilbertValues: true}).LoadWithSortKey(points, HilbertSortKey(box.ToBBox()))
+	for i, idx := range r.HilbertOrder() {
+		assert.Equal(t, i, idx)
+	}
+
+	assert.Nil(t, New().Load(FlatPoints{0, 0, 1, 1}).HilbertOrder())
+	_, ok := New().Load(FlatPoints{0, 0}).HilbertValue(0)
+	assert.False(t, ok)
+}
@@ -0,0 +1,54 @@
+package SimpleRTree
+
+import "math"
+
+// IntPoints is an alternative input format for integer coordinates, for example raster or tile data.
+// As in FlatPoints x coordinates are stored in even positions and y coordinates in odd positions
+// []int32{0, 0, 2, 4} corresponds to the points (0, 0) and (2, 4)
+//
+// IntPoints implement Interface, so they can be indexed with LoadInterface without copying them into FlatPoints.
+// When the query coordinates are integers, leaf distances are computed in integer space and converted to float
+// only at the end, so they are exact up to the final rounding.
+//
+// Note: rtree is assumed to have sole access to the array, it will modify the underlying order and it
+// will return wrong results if the elements are modified
+type IntPoints []int32
+
+// maxExactIntDelta is the largest difference for which dx * dx + dy * dy cannot overflow an int64
+const maxExactIntDelta = 1 << 31
+
+func (ip IntPoints) Len() int {
+	return len(ip) / 2
+}
+
+func (ip IntPoints) Swap(i, j int) {
+	ip[2*i], ip[2*i+1], ip[2*j], ip[2*j+1] = ip[2*j], ip[2*j+1], ip[2*i], ip[2*i+1]
+}
+
+func (ip IntPoints) GetPointAt(i int) (x1, y1 float64) {
+	return float64(ip[2*i]), float64(ip[2*i+1])
+}
+
+// squaredDistanceTo returns the squared distance from the point at position i to (x, y)
+func (ip IntPoints) squaredDistanceTo(i int, x, y float64) float64 {
+	px, py := ip.GetPointAt(i)
+	return integerSquaredDistance(px, py, x, y)
+}
+
+// integerSquaredDistance returns the squared distance from (px, py) to (x, y), computed in integer space if all the
+// coordinates are int32 values
+func integerSquaredDistance(px, py, x, y float64) float64 {
+	if !isInt32(px) || !isInt32(py) || !isInt32(x) || !isInt32(y) {
+		return computeLeafDistance(px, py, x, y)
+	}
+	dx := int64(px) - int64(x)
+	dy := int64(py) - int64(y)
+	if dx >= maxExactIntDelta || dx <= -maxExactIntDelta || dy >= maxExactIntDelta || dy <= -maxExactIntDelta {
+		return computeLeafDistance(px, py, x, y)
+	}
+	return float64(dx*dx + dy*dy)
+}
+
+func isInt32(v float64) bool {
+	return v == math.Trunc(v) && v >= math.MinInt32 && v <= math.MaxInt32
+}
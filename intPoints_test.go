@@ -0,0 +1,67 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestIntPoints_FindNearestPoint(t *testing.T) {
+	const size = 20000
+	ints := make([]int32, size*2)
+	floats := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		ints[i] = int32(rand.Intn(10000) - 5000)
+		floats[i] = float64(ints[i])
+	}
+	ip := IntPoints(ints)
+	fp := FlatPoints(floats)
+	rInt := New().LoadInterface(ip)
+	rFloat := New().Load(fp)
+	for i := 0; i < 1000; i++ {
+		// integer queries use integer arithmetic, the others fall back to floats
+		x, y := float64(rand.Intn(12000)-6000), float64(rand.Intn(12000)-6000)
+		if i%2 == 0 {
+			x, y = x+rand.Float64(), y+rand.Float64()
+		}
+		x1, y1, d1 := rInt.FindNearestPoint(x, y)
+		x2, y2, d2 := rFloat.FindNearestPoint(x, y)
+		_, _, d3 := fp.linearClosestPoint(x, y)
+		assert.Equal(t, d2, d1, "distance")
+		assert.Equal(t, d3, d1, "linear distance")
+		// there might be ties on integer grids, so we check that the point is at the right distance
+		assert.Equal(t, d1, computeLeafDistance(x1, y1, x, y))
+		assert.Equal(t, d2, computeLeafDistance(x2, y2, x, y))
+	}
+}
+
+func TestIntPoints_SquaredDistanceToLargeCoordinates(t *testing.T) {
+	ip := IntPoints{-2147483648, 2147483647}
+	// differences overflow int64 when squared, so distance is computed with floats
+	d := ip.squaredDistanceTo(0, 2147483647, -2147483648)
+	px, py := ip.GetPointAt(0)
+	assert.Equal(t, computeLeafDistance(px, py, 2147483647, -2147483648), d)
+	// close by the difference is exact
+	ip = IntPoints{1 << 30, 1 << 30}
+	assert.Equal(t, float64(int64(3)*3+int64(4)*4), ip.squaredDistanceTo(0, 1<<30+3, 1<<30-4))
+}
+
+func TestIntPoints_SquaredDistance(t *testing.T) {
+	const size = 5000
+	ints := make([]int32, size*2)
+	for i := range ints {
+		ints[i] = int32(rand.Intn(1<<30) - 1<<29)
+	}
+	r := New().LoadInterface(IntPoints(ints))
+	for i := 0; i < 1000; i++ {
+		x, y := float64(rand.Intn(1<<30)-1<<29), float64(rand.Intn(1<<30)-1<<29)
+		if i%2 == 0 {
+			x, y = x+rand.Float64(), y+rand.Float64()
+		}
+		x1, y1, d1 := r.FindNearestPoint(x, y)
+		assert.Equal(t, d1, r.SquaredDistance(x, y, x1, y1))
+		assert.Equal(t, d1, r.SquaredDistance(x1, y1, x, y))
+	}
+	// integer math is exact where floats round
+	assert.Equal(t, float64(int64(1<<30+1)*(1<<30+1)+1), r.SquaredDistance(0, 0, 1<<30+1, 1))
+}
@@ -0,0 +1,109 @@
+package SimpleRTree
+
+import (
+	"math"
+	"unsafe"
+)
+
+// Stats describes the shape of a tree and the memory it holds, for monitoring and capacity planning
+type Stats struct {
+	Points         int // number of points, disabled ones included
+	DisabledPoints int // points disabled with SetEnabled
+	Nodes          int
+	LeafNodes      int // nodes holding points, EachLeaf calls leaves the points themselves
+	Height         int // number of levels of nodes, counted as in GeoJSONFilter
+	MemoryUsage    int // see MemoryUsage
+}
+
+// Stats returns the shape and memory usage of the tree. It is zero for trees that have not been loaded
+func (r *SimpleRTree) Stats() Stats {
+	if !r.built {
+		return Stats{}
+	}
+	s := Stats{
+		Points:         r.getLen(),
+		DisabledPoints: r.nDisabled,
+		Nodes:          len(r.nodes),
+		Height:         r.height,
+		MemoryUsage:    r.MemoryUsage(),
+	}
+	for i := range r.nodes {
+		if r.nodes[i].nodeType == preleaf_node {
+			s.LeafNodes++
+		}
+	}
+	return s
+}
+
+// MemoryUsage returns the approximate number of bytes held by the tree: nodes, points, search queues and the
+// optional per point and per node data, such as loads or centroids. Sizes are taken from the capacity of the slices,
+// which is what is allocated. Points of trees loaded from FlatPoints are counted even if the caller shares the array,
+// points of trees loaded with LoadInterface are not, their storage is up to the caller. Pools keep at least one
+// queue, the one counted, but can hold one per goroutine querying concurrently
+func (r *SimpleRTree) MemoryUsage() int {
+	size := int(unsafe.Sizeof(*r))
+	size += cap(r.nodes) * int(node_size)
+	size += (cap(r.points) + cap(r.leafPoints)) * 8
+	size += cap(r.sorterBuffer) * int(unsafe.Sizeof(int(0)))
+	size += r.height * r.options.MAX_ENTRIES * int(unsafe.Sizeof(searchQueueItem{}))
+	size += cap(r.disabled)
+	size += (cap(r.loads) + cap(r.minLoad) + cap(r.centroids) + cap(r.maxRadius) + cap(r.hilbertValues)) * 8
+	size += cap(r.orientedBoxes) * int(unsafe.Sizeof(orientedBox{}))
+	size += cap(r.circles) * int(unsafe.Sizeof(boundingCircle{}))
+	for _, group := range r.groups {
+		size += int(unsafe.Sizeof(group)) + cap(group)*int(unsafe.Sizeof(int(0)))
+	}
+	if r.cache != nil {
+		// entries are stored in a list element and a map bucket, with pointers and the key in both
+		size += r.cache.size * int(unsafe.Sizeof(queryCacheEntry{})+3*unsafe.Sizeof([2]float64{})+4*unsafe.Sizeof(uintptr(0)))
+	}
+	return size
+}
+
+// FractalDimensionEstimate returns a box counting estimate of the fractal dimension of the points, from the levels of
+// the tree: each level covers the points with its nodes, whose number grows as the scale of the nodes shrinks, and
+// the dimension is the slope of the log of the number of nodes against the log of the inverse of their mean
+// diagonal, fitted by least squares over the levels. Points spread over an area give about 2, points along a line
+// about 1 and clustered points something in between. It is a quick descriptor of the distribution rather than a
+// precise measure: trees have few levels, and at the scale of the upper ones several lines crossing already cover an
+// area. It returns NaN if the tree has fewer than two levels with nodes of non
+// zero size
+func (r *SimpleRTree) FractalDimensionEstimate() float64 {
+	if !r.built || len(r.nodes) == 0 {
+		return math.NaN()
+	}
+	var counts []int
+	var diagonals []float64
+	var walk func(i, depth int)
+	walk = func(i, depth int) {
+		if depth == len(counts) {
+			counts = append(counts, 0)
+			diagonals = append(diagonals, 0)
+		}
+		bbox := r.nodeBBox(i)
+		counts[depth]++
+		diagonals[depth] += math.Hypot(bbox[VECTOR_BBOX_MAX_X]-bbox[VECTOR_BBOX_MIN_X], bbox[VECTOR_BBOX_MAX_Y]-bbox[VECTOR_BBOX_MIN_Y])
+		n := &r.nodes[i]
+		if n.nodeType == preleaf_node {
+			return
+		}
+		for j := n.firstChildIndex(); j < n.firstChildIndex()+int(n.nChildren); j++ {
+			walk(j, depth+1)
+		}
+	}
+	walk(0, 0)
+
+	var sx, sy, sxx, sxy, levels float64
+	for depth, count := range counts {
+		meanDiagonal := diagonals[depth] / float64(count)
+		if meanDiagonal <= 0 {
+			continue
+		}
+		x, y := -math.Log(meanDiagonal), math.Log(float64(count))
+		sx, sy, sxx, sxy, levels = sx+x, sy+y, sxx+x*x, sxy+x*y, levels+1
+	}
+	if levels < 2 {
+		return math.NaN()
+	}
+	return (levels*sxy - sx*sy) / (levels*sxx - sx*sx)
+}
@@ -0,0 +1,65 @@
+package SimpleRTree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRTree_MemoryUsage(t *testing.T) {
+	usage := func(size int) int {
+		points := make(FlatPoints, size*2)
+		for i := range points {
+			points[i] = rand.Float64()
+		}
+		return New().Load(points).MemoryUsage()
+	}
+	small, large := usage(10000), usage(100000)
+	// points and nodes dominate, both are linear in the number of points
+	assert.InDelta(t, 10, float64(large)/float64(small), 0.5)
+	assert.Greater(t, small, 10000*16)
+
+	points := FlatPoints{0, 0, 1, 1, 2, 2}
+	r := New().Load(points)
+	base := r.MemoryUsage()
+	r.SetLoad(0, 1)
+	assert.Greater(t, r.MemoryUsage(), base)
+	assert.Equal(t, Stats{}, New().Stats())
+}
+
+func TestSimpleRTree_Stats(t *testing.T) {
+	const size = 1000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	r.SetEnabled(3, false)
+	s := r.Stats()
+	assert.Equal(t, size, s.Points)
+	assert.Equal(t, 1, s.DisabledPoints)
+	assert.Equal(t, len(r.nodes), s.Nodes)
+	assert.Equal(t, r.height, s.Height)
+	assert.Equal(t, r.MemoryUsage(), s.MemoryUsage)
+	assert.GreaterOrEqual(t, s.LeafNodes, (size+MAX_POSSIBLE_SIZE-1)/MAX_POSSIBLE_SIZE)
+	assert.Less(t, s.LeafNodes, s.Nodes)
+}
+
+func TestSimpleRTree_FractalDimensionEstimate(t *testing.T) {
+	for _, options := range []Options{{}, {TreeType: HILBERT}, {MAX_ENTRIES: 4}} {
+		uniform := NewWithOptions(options).Load(generateDataset(uniformDataset, 100000, 1))
+		assert.InDelta(t, 2, uniform.FractalDimensionEstimate(), 0.25, "%+v", options)
+		line := make(FlatPoints, 0, 2*100000)
+		for i := 0; i < 100000; i++ {
+			v := rand.Float64()
+			line = append(line, v, 0.5*v+0.2)
+		}
+		linear := NewWithOptions(options).Load(line)
+		assert.InDelta(t, 1, linear.FractalDimensionEstimate(), 0.25, "%+v", options)
+	}
+	assert.True(t, math.IsNaN(New().FractalDimensionEstimate()))
+	// every node is a point
+	assert.True(t, math.IsNaN(New().Load(generateDataset(singlePointDataset, 1000, 1)).FractalDimensionEstimate()))
+}
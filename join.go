@@ -0,0 +1,77 @@
+package SimpleRTree
+
+import "math"
+
+// JoinWithin calls fn for every pair of a point of r and a point of other at distance d or less, with the positions
+// of both points and their distance squared, for example to find the stores close to each customer. Distance d
+// follows the same rules as in FindPointsWithin, with the options of r.
+//
+// Both trees are traversed at the same time: pairs of nodes whose bboxes are farther than d are pruned together,
+// which is much faster than a FindPointsWithin query on other for every point of r. Order of the pairs is not
+// specified. r and other can be the same tree, then every pair is reported in both orders and every point is paired
+// with itself
+func (r *SimpleRTree) JoinWithin(other *SimpleRTree, d float64, fn func(idxA, idxB int, dist float64)) {
+	if !r.built || !other.built || len(r.nodes) == 0 || len(other.nodes) == 0 {
+		return
+	}
+	limitSquared, strict, ok := r.withinLimit(d)
+	if !ok {
+		return
+	}
+	type nodePair struct {
+		a, b int
+	}
+	stack := []nodePair{{0, 0}}
+	for len(stack) > 0 {
+		pair := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		na, nb := &r.nodes[pair.a], &other.nodes[pair.b]
+		aIsLeaf, bIsLeaf := na.nodeType == preleaf_node, nb.nodeType == preleaf_node
+		if aIsLeaf && bIsLeaf {
+			for i := na.firstPointIndex(); i < na.firstPointIndex()+int(na.nChildren); i++ {
+				ax, ay := r.getPointAt(i)
+				for j := nb.firstPointIndex(); j < nb.firstPointIndex()+int(nb.nChildren); j++ {
+					bx, by := other.getPointAt(j)
+					if distance := r.finalDistance(ax, ay, bx, by); isWithin(distance, limitSquared, strict) {
+						fn(i, j, distance)
+					}
+				}
+			}
+			continue
+		}
+		// descend into the node with the largest bbox, so that both sides shrink at the same pace
+		boxA, boxB := r.nodeBBox(pair.a), other.nodeBBox(pair.b)
+		if bIsLeaf || (!aIsLeaf && bboxArea(boxA) >= bboxArea(boxB)) {
+			for i := na.firstChildIndex(); i < na.firstChildIndex()+int(na.nChildren); i++ {
+				if bboxDistance(r.nodes[i].BBox, boxB) <= limitSquared {
+					stack = append(stack, nodePair{i, pair.b})
+				}
+			}
+		} else {
+			for j := nb.firstChildIndex(); j < nb.firstChildIndex()+int(nb.nChildren); j++ {
+				if bboxDistance(boxA, other.nodes[j].BBox) <= limitSquared {
+					stack = append(stack, nodePair{pair.a, j})
+				}
+			}
+		}
+	}
+}
+
+// nodeBBox returns the bbox of the node at position i, the root does not always store it
+func (r *SimpleRTree) nodeBBox(i int) VectorBBox {
+	if i == 0 {
+		return r.rootBBox()
+	}
+	return r.nodes[i].BBox
+}
+
+// bboxDistance returns the distance squared between the closest points of two bboxes, 0 if they intersect
+func bboxDistance(b1, b2 VectorBBox) float64 {
+	dx := math.Max(0, math.Max(b1[VECTOR_BBOX_MIN_X]-b2[VECTOR_BBOX_MAX_X], b2[VECTOR_BBOX_MIN_X]-b1[VECTOR_BBOX_MAX_X]))
+	dy := math.Max(0, math.Max(b1[VECTOR_BBOX_MIN_Y]-b2[VECTOR_BBOX_MAX_Y], b2[VECTOR_BBOX_MIN_Y]-b1[VECTOR_BBOX_MAX_Y]))
+	return dx*dx + dy*dy
+}
+
+func bboxArea(b VectorBBox) float64 {
+	return (b[VECTOR_BBOX_MAX_X] - b[VECTOR_BBOX_MIN_X]) * (b[VECTOR_BBOX_MAX_Y] - b[VECTOR_BBOX_MIN_Y])
+}
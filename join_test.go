@@ -0,0 +1,74 @@
+package SimpleRTree
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRTree_JoinWithin(t *testing.T) {
+	for _, kindA := range datasetKinds {
+		kindB := datasetKinds[rand.Intn(len(datasetKinds))]
+		pointsA := generateDataset(kindA, 1+rand.Intn(800), rand.Int63())
+		pointsB := generateDataset(kindB, 1+rand.Intn(800), rand.Int63())
+		a := New().Load(pointsA)
+		b := NewWithOptions(Options{TreeType: HILBERT, MAX_ENTRIES: 4}).Load(pointsB)
+		for _, d := range []float64{0, 0.01, 0.05, 0.3} {
+			var expected, pairs [][2]int
+			for i := 0; i < pointsA.Len(); i++ {
+				ax, ay := pointsA.GetPointAt(i)
+				for j := 0; j < pointsB.Len(); j++ {
+					bx, by := pointsB.GetPointAt(j)
+					if computeLeafDistance(ax, ay, bx, by) <= d*d {
+						expected = append(expected, [2]int{i, j})
+					}
+				}
+			}
+			a.JoinWithin(b, d, func(idxA, idxB int, dist float64) {
+				ax, ay := pointsA.GetPointAt(idxA)
+				bx, by := pointsB.GetPointAt(idxB)
+				assert.Equal(t, computeLeafDistance(ax, ay, bx, by), dist)
+				pairs = append(pairs, [2]int{idxA, idxB})
+			})
+			sortPairs := func(p [][2]int) {
+				sort.Slice(p, func(i, j int) bool {
+					return p[i][0] < p[j][0] || (p[i][0] == p[j][0] && p[i][1] < p[j][1])
+				})
+			}
+			sortPairs(expected)
+			sortPairs(pairs)
+			assert.Equal(t, expected, pairs, "%s with %s within %v", kindA, kindB, d)
+		}
+	}
+
+	// a self join pairs every point with itself
+	r := New().Load(FlatPoints{0, 0, 1, 0, 5, 5})
+	n := 0
+	r.JoinWithin(r, 1, func(idxA, idxB int, dist float64) {
+		n++
+	})
+	assert.Equal(t, 5, n)
+	New().JoinWithin(r, 1, func(int, int, float64) { t.Fail() })
+	r.JoinWithin(r, -1, func(int, int, float64) { t.Fail() })
+}
+
+func BenchmarkSimpleRTree_JoinWithin(b *testing.B) {
+	customers := New().Load(generateDataset(clusteredDataset, 20000, 1))
+	stores := New().Load(generateDataset(uniformDataset, 2000, 2))
+	const d = 0.01
+	b.Run("Join", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			customers.JoinWithin(stores, d, func(int, int, float64) {})
+		}
+	})
+	b.Run("QueryPerPoint", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for i := 0; i < customers.getLen(); i++ {
+				x, y := customers.getPointAt(i)
+				_ = stores.FindPointsWithin(x, y, d)
+			}
+		}
+	})
+}
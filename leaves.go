@@ -0,0 +1,72 @@
+package SimpleRTree
+
+import "math"
+
+// LeafPointsInOrder returns a copy of the points in the order of the leaves of the tree. Consecutive points are
+// spatially close, which is useful for example to transfer them progressively.
+// The copy can be modified or sorted freely without affecting the tree
+func (r *SimpleRTree) LeafPointsInOrder() FlatPoints {
+	if !r.built {
+		return FlatPoints{}
+	}
+	if r.points != nil {
+		return append(make(FlatPoints, 0, len(r.points)), r.points...)
+	}
+	points := make(FlatPoints, 0, 2*r.source.Len())
+	for i := 0; i < r.source.Len(); i++ {
+		x, y := r.source.GetPointAt(i)
+		points = append(points, x, y)
+	}
+	return points
+}
+
+// copyLeafPoints sets the flat copy of the points of a tree built over an Interface, see Options.LeafOrderCopy.
+// The build leaves the points of every leaf contiguous, so the order of the source is already the order of the leaves
+func (r *SimpleRTree) copyLeafPoints() {
+	if cap(r.leafPoints) >= 2*r.source.Len() {
+		r.leafPoints = r.leafPoints[:0]
+	} else {
+		r.leafPoints = make(FlatPoints, 0, 2*r.source.Len())
+	}
+	for i := 0; i < r.source.Len(); i++ {
+		x, y := r.source.GetPointAt(i)
+		r.leafPoints = append(r.leafPoints, x, y)
+	}
+}
+
+// EachLeaf calls fn with the position and the bbox of every leaf of the tree, in the order of the leaves, until fn
+// returns false. Leaves are points, so their bboxes are degenerate boxes with min and max at the point.
+// Nodes cover contiguous ranges of points, so the order of the leaves is the order of the positions and no
+// traversal is needed, it does not allocate
+func (r *SimpleRTree) EachLeaf(fn func(pointIdx int, box BBox) bool) {
+	if !r.built {
+		return
+	}
+	for i := 0; i < r.getLen(); i++ {
+		x, y := r.getPointAt(i)
+		if !fn(i, BBox{x, y, x, y}) {
+			return
+		}
+	}
+}
+
+// LeafNodeOf returns the position in the tree of the node holding the point at position idx, such as the Index of a
+// Result, so that callers building graphs over the tree can attach data to its nodes. Positions of nodes are stable
+// for a given build, as the keys of FindNearestKClustered. ok is false if idx is out of range
+func (r *SimpleRTree) LeafNodeOf(idx int) (node int, ok bool) {
+	if !r.built || len(r.nodes) == 0 || idx < 0 || idx >= r.getLen() {
+		return 0, false
+	}
+	return r.ancestorAt(idx, 0), true
+}
+
+// FindNearestPointWithNode returns the closest point to x and y, as FindNearestPoint, together with the position of
+// the node holding it, see LeafNodeOf
+func (r *SimpleRTree) FindNearestPointWithNode(x, y float64) (res Result, node int, found bool) {
+	res, found = r.findNearestPointWithin(x, y, math.Inf(1), nil)
+	if !found {
+		return
+	}
+	node, _ = r.LeafNodeOf(res.Index)
+	return res, node, true
+}
@@ -0,0 +1,235 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSimpleRTree_LeafPointsInOrder(t *testing.T) {
+	const size = 20000
+	points := make([]float64, size*2)
+	ip := make(IntPoints, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+		ip[i] = int32(rand.Intn(1000))
+	}
+	original := FlatPoints(append([]float64{}, points...))
+	r := New().Load(FlatPoints(points))
+	leafPoints := r.LeafPointsInOrder()
+	assert.Equal(t, size, leafPoints.Len())
+	assert.Equal(t, sortedPoints(original), sortedPoints(leafPoints))
+
+	// points follow the leaves
+	position := 0
+	for i := range r.nodes {
+		n := &r.nodes[i]
+		if n.nodeType != preleaf_node {
+			continue
+		}
+		assert.Equal(t, position, n.firstPointIndex(), "Leaves are consecutive")
+		for j := 0; j < int(n.nChildren); j++ {
+			assert.True(t, n.BBox.ToBBox().containsPoint(leafPoints.GetPointAt(position)))
+			position++
+		}
+	}
+
+	// it is a copy
+	leafPoints[0] = 5
+	assert.NotEqual(t, 5., r.points[0])
+
+	rInt := New().LoadInterface(ip)
+	leafPoints = rInt.LeafPointsInOrder()
+	assert.Equal(t, size, leafPoints.Len())
+	for i := 0; i < size; i++ {
+		x, y := ip.GetPointAt(i)
+		assert.Equal(t, x, leafPoints[2*i])
+		assert.Equal(t, y, leafPoints[2*i+1])
+	}
+	assert.Empty(t, New().LeafPointsInOrder())
+}
+
+func TestSimpleRTree_EachLeaf(t *testing.T) {
+	const size = 5000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	seen := make([]int, size)
+	previous := -1
+	r.EachLeaf(func(pointIdx int, box BBox) bool {
+		seen[pointIdx]++
+		assert.Equal(t, previous+1, pointIdx, "Leaves in order")
+		previous = pointIdx
+		x, y := points.GetPointAt(pointIdx)
+		assert.Equal(t, BBox{x, y, x, y}, box)
+		return true
+	})
+	for i := range seen {
+		assert.Equal(t, 1, seen[i], "Leaf %d visited once", i)
+	}
+	// leaves of each node are consecutive
+	for i := range r.nodes {
+		if n := &r.nodes[i]; n.nodeType == preleaf_node {
+			for j := n.firstPointIndex(); j < n.firstPointIndex()+int(n.nChildren); j++ {
+				assert.True(t, n.BBox.ToBBox().contains(BBox{points[2*j], points[2*j+1], points[2*j], points[2*j+1]}))
+			}
+		}
+	}
+
+	calls := 0
+	r.EachLeaf(func(pointIdx int, box BBox) bool {
+		calls++
+		return calls < 10
+	})
+	assert.Equal(t, 10, calls)
+	New().EachLeaf(func(pointIdx int, box BBox) bool {
+		t.Fail()
+		return true
+	})
+}
+
+func TestSimpleRTree_LeafNodeOf(t *testing.T) {
+	for _, options := range []Options{{}, {TreeType: HILBERT}, {MAX_ENTRIES: 2}} {
+		points := generateDataset(clusteredDataset, 5000, rand.Int63())
+		r := NewWithOptions(options).Load(points)
+		for i := 0; i < r.getLen(); i++ {
+			node, ok := r.LeafNodeOf(i)
+			assert.True(t, ok)
+			n := &r.nodes[node]
+			assert.True(t, n.nodeType == preleaf_node)
+			assert.True(t, n.firstPointIndex() <= i && i < n.firstPointIndex()+int(n.nChildren))
+		}
+		for n := 0; n < 100; n++ {
+			x, y := rand.Float64(), rand.Float64()
+			res, node, found := r.FindNearestPointWithNode(x, y)
+			assert.True(t, found)
+			_, _, d := r.FindNearestPoint(x, y)
+			assert.Equal(t, d, res.Distance)
+			expected, _ := r.LeafNodeOf(res.Index)
+			assert.Equal(t, expected, node)
+		}
+		_, ok := r.LeafNodeOf(r.getLen())
+		assert.False(t, ok)
+		_, ok = r.LeafNodeOf(-1)
+		assert.False(t, ok)
+	}
+	r := New().Load(FlatPoints{1, 1})
+	node, ok := r.LeafNodeOf(0)
+	assert.True(t, ok)
+	assert.Zero(t, node)
+	_, _, found := New().FindNearestPointWithNode(0, 0)
+	assert.False(t, found)
+}
+
+// places is a collection of records implementing Interface, as callers with their own point type would
+type places []struct {
+	id   int
+	x, y float64
+}
+
+func (p places) Len() int {
+	return len(p)
+}
+
+func (p places) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+}
+
+func (p places) GetPointAt(i int) (x, y float64) {
+	return p[i].x, p[i].y
+}
+
+func randomPlaces(n int) places {
+	p := make(places, n)
+	for i := range p {
+		p[i].id, p[i].x, p[i].y = i, rand.Float64(), rand.Float64()
+	}
+	return p
+}
+
+func TestSimpleRTree_LeafOrderCopy(t *testing.T) {
+	p := randomPlaces(20000)
+	ip := make(IntPoints, 2*20000)
+	for i := range ip {
+		ip[i] = rand.Int31n(1000)
+	}
+	sources := []func() Interface{
+		func() Interface { return append(places(nil), p...) },
+		func() Interface { return append(IntPoints(nil), ip...) },
+	}
+	for _, source := range sources {
+		plain := New().LoadInterface(source())
+		copied := NewWithOptions(Options{LeafOrderCopy: true}).LoadInterface(source())
+		assert.Equal(t, 2*copied.getLen(), len(copied.leafPoints))
+		assert.Equal(t, plain.MemoryUsage()+16*copied.getLen(), copied.MemoryUsage())
+		for n := 0; n < 200; n++ {
+			x, y := rand.Float64()*1000, rand.Float64()*1000
+			if _, ok := plain.source.(places); ok {
+				x, y = x/1000, y/1000
+			}
+			box := BBox{x, y, x + plain.rootBBox()[VECTOR_BBOX_MAX_X]/10, y + plain.rootBBox()[VECTOR_BBOX_MAX_Y]/10}
+			assert.Equal(t, plain.SearchPoints(box), copied.SearchPoints(box))
+			assert.Equal(t, plain.FindPointsWithin(x, y, box.MaxX-box.MinX), copied.FindPointsWithin(x, y, box.MaxX-box.MinX))
+			x1, y1, d1 := plain.FindNearestPoint(x, y)
+			x2, y2, d2 := copied.FindNearestPoint(x, y)
+			assert.Equal(t, [3]float64{x1, y1, d1}, [3]float64{x2, y2, d2})
+		}
+	}
+	// moves in place are picked up by RecomputeBBoxes
+	moved := append(places(nil), p...)
+	r := NewWithOptions(Options{LeafOrderCopy: true}).LoadInterface(moved)
+	for i := range moved {
+		moved[i].x += (rand.Float64() - 0.5) / 100
+		moved[i].y += (rand.Float64() - 0.5) / 100
+	}
+	assert.NoError(t, r.RecomputeBBoxes())
+	for i := range moved {
+		x, y := r.getPointAt(i)
+		assert.Equal(t, [2]float64{moved[i].x, moved[i].y}, [2]float64{x, y})
+	}
+	for n := 0; n < 200; n++ {
+		x, y := rand.Float64(), rand.Float64()
+		closest := math.Inf(1)
+		for i := range moved {
+			closest = math.Min(closest, computeLeafDistance(moved[i].x, moved[i].y, x, y))
+		}
+		_, _, d := r.FindNearestPoint(x, y)
+		assert.Equal(t, closest, d)
+		box := randomBBox(0.1)
+		var expected [][2]float64
+		for i := range moved {
+			if box.containsPoint(moved[i].x, moved[i].y) {
+				expected = append(expected, [2]float64{moved[i].x, moved[i].y})
+			}
+		}
+		var found [][2]float64
+		for _, i := range r.Search(box) {
+			x, y := r.getPointAt(i)
+			found = append(found, [2]float64{x, y})
+		}
+		assert.ElementsMatch(t, expected, found)
+	}
+
+	// trees over FlatPoints already scan their own array
+	r = NewWithOptions(Options{LeafOrderCopy: true}).Load(FlatPoints{0, 0, 1, 1})
+	assert.Nil(t, r.leafPoints)
+}
+
+func BenchmarkSimpleRTree_LeafOrderCopy(b *testing.B) {
+	p := randomPlaces(100000)
+	box := BBox{0.3, 0.3, 0.7, 0.7}
+	for _, copied := range []bool{false, true} {
+		r := NewWithOptions(Options{LeafOrderCopy: copied}).LoadInterface(append(places(nil), p...))
+		b.Run(fmt.Sprintf("Copy=%v", copied), func(b *testing.B) {
+			var out FlatPoints
+			for n := 0; n < b.N; n++ {
+				out = r.SearchInto(box, out[:0])
+			}
+			b.ReportMetric(float64(out.Len())*float64(b.N)/b.Elapsed().Seconds()/1e6, "Mpoints/s")
+		})
+	}
+}
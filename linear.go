@@ -0,0 +1,44 @@
+package SimpleRTree
+
+import "math"
+
+// useLinearScan returns whether the tree is not built and queries scan all the points, see
+// Options.LinearScanThreshold
+func (r *SimpleRTree) useLinearScan() bool {
+	return r.getLen() < r.options.LinearScanThreshold
+}
+
+// findNearestLinear is findNearestPointWithin scanning all the points. If visited is not nil, it is increased by the
+// number of points whose distance is computed
+func (r *SimpleRTree) findNearestLinear(x, y, dsquared float64, visited *int) (res Result, found bool) {
+	if visited != nil {
+		*visited += r.getLen() - r.nDisabled
+	}
+	res.Distance = math.Inf(1)
+	if r.points != nil && r.disabled == nil {
+		best := -1
+		for i := 0; i < len(r.points); i += 2 {
+			if d := computeLeafDistance(r.points[i], r.points[i+1], x, y); d < res.Distance {
+				res.Distance, best = d, i
+			}
+		}
+		if best < 0 || res.Distance > dsquared {
+			return Result{}, false
+		}
+		return Result{Index: best / 2, X: r.points[best], Y: r.points[best+1], Distance: res.Distance}, true
+	}
+	for i := 0; i < r.getLen(); i++ {
+		if r.disabled != nil && r.disabled[i] {
+			continue
+		}
+		px, py, d := r.pointDistance(i, x, y)
+		if d <= dsquared && d < res.Distance {
+			res = Result{Index: i, X: px, Y: py, Distance: d}
+			found = true
+		}
+	}
+	if !found {
+		return Result{}, false
+	}
+	return
+}
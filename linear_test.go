@@ -0,0 +1,110 @@
+package SimpleRTree
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSimpleRTree_LinearScan(t *testing.T) {
+	for _, size := range []int{1, 2, 5, 9, 10, 63, 64, 100} {
+		points := make(FlatPoints, size*2)
+		for i := range points {
+			points[i] = rand.Float64()
+		}
+		linear := NewWithOptions(Options{LinearScanThreshold: size + 1}).Load(append(FlatPoints{}, points...))
+		tree := NewWithOptions(Options{LinearScanThreshold: -1}).Load(append(FlatPoints{}, points...))
+		assert.True(t, linear.useLinearScan())
+		assert.False(t, tree.useLinearScan())
+		assert.False(t, New().Load(append(FlatPoints{}, points...)).useLinearScan(), "Off by default")
+		// the tree is not built, points keep their order under the root
+		assert.Len(t, linear.nodes, 1)
+		assert.True(t, linear.nodes[0].nodeType == preleaf_node)
+		assert.Equal(t, points, linear.points)
+		for n := 0; n < 100; n++ {
+			x, y := rand.Float64(), rand.Float64()
+			x1, y1, d1 := linear.FindNearestPoint(x, y)
+			x2, y2, d2 := tree.FindNearestPoint(x, y)
+			assert.Equal(t, []float64{x2, y2, d2}, []float64{x1, y1, d1})
+
+			limit := rand.Float64() * 0.05
+			x1, y1, d1, found1 := linear.FindNearestPointWithin(x, y, limit)
+			x2, y2, d2, found2 := tree.FindNearestPointWithin(x, y, limit)
+			assert.Equal(t, found2, found1)
+			assert.Equal(t, []float64{x2, y2, d2}, []float64{x1, y1, d1})
+
+			box := randomBBox(0.5)
+			r1, r2 := linear.Search(box), tree.Search(box)
+			sort.Ints(r1)
+			assert.Equal(t, points.linearSearch(box), r1, "Positions are those of the input")
+			assert.Len(t, r2, len(r1))
+			// the rest of queries traverse the single leaf
+			k1, k2 := linear.FindNearestKInBox(3, x, y, box), tree.FindNearestKInBox(3, x, y, box)
+			assert.Equal(t, len(k2), len(k1))
+			for i := range k1 {
+				assert.Equal(t, [3]float64{k2[i].X, k2[i].Y, k2[i].Distance}, [3]float64{k1[i].X, k1[i].Y, k1[i].Distance})
+				assert.Equal(t, [2]float64{k1[i].X, k1[i].Y}, [2]float64{points[2*k1[i].Index], points[2*k1[i].Index+1]})
+			}
+		}
+		// Rebuild does not build either
+		assert.NoError(t, linear.Rebuild(append(FlatPoints{}, points...)))
+		assert.Len(t, linear.nodes, 1)
+		assert.Equal(t, points, linear.points)
+		// disabled points are skipped in the scan too
+		linear.SetEnabled(0, false)
+		for i := 0; i < size; i++ {
+			if x, y := tree.getPointAt(i); x == points[0] && y == points[1] {
+				tree.SetEnabled(i, false)
+			}
+		}
+		for n := 0; n < 20; n++ {
+			x, y := rand.Float64(), rand.Float64()
+			_, _, d1, found1 := linear.FindNearestPointWithin(x, y, 1)
+			_, _, d2, found2 := tree.FindNearestPointWithin(x, y, 1)
+			assert.Equal(t, found2, found1)
+			assert.Equal(t, d2, d1)
+		}
+	}
+}
+
+func TestSimpleRTree_LinearScanThresholdLimit(t *testing.T) {
+	assert.NoError(t, Options{LinearScanThreshold: maxLeafSize + 1}.Validate())
+	assert.EqualError(t, Options{LinearScanThreshold: maxLeafSize + 2}.Validate(), "invalid LinearScanThreshold 129, it cannot exceed 128")
+	points := generateDataset(uniformDataset, maxLeafSize, rand.Int63())
+	r := NewWithOptions(Options{LinearScanThreshold: maxLeafSize + 1}).Load(append(FlatPoints{}, points...))
+	assert.Equal(t, maxLeafSize, int(r.nodes[0].nChildren))
+	x, y, d := r.FindNearestPoint(0.5, 0.5)
+	px, py, expected := points.linearClosestPoint(0.5, 0.5)
+	assert.Equal(t, [3]float64{px, py, expected}, [3]float64{x, y, d})
+}
+
+func BenchmarkSimpleRTree_LinearScan(b *testing.B) {
+	for _, size := range []int{4, 8, 16, 32, 64, 127} {
+		points := make(FlatPoints, size*2)
+		for i := range points {
+			points[i] = rand.Float64()
+		}
+		for _, threshold := range []int{0, size + 1} {
+			name := "Tree"
+			if threshold > 0 {
+				name = "Linear"
+			}
+			options := Options{LinearScanThreshold: threshold, UnsafeConcurrencyMode: true}
+			r := NewWithOptions(options).Load(append(FlatPoints{}, points...))
+			b.Run(fmt.Sprintf("%s/%d", name, size), func(b *testing.B) {
+				for n := 0; n < b.N; n++ {
+					r.FindNearestPoint(points[2*(n%size)]+0.01, points[2*(n%size)+1])
+				}
+			})
+			b.Run(fmt.Sprintf("Load/%s/%d", name, size), func(b *testing.B) {
+				buffer := make(FlatPoints, len(points))
+				for n := 0; n < b.N; n++ {
+					copy(buffer, points)
+					NewWithOptions(options).Load(buffer)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package SimpleRTree
+
+import (
+	"fmt"
+	"math"
+)
+
+// SetLoad sets the load of the point at position idx, for example the number of requests a server is handling, used
+// by FindNearestByLoadWeightedDistance. Loads start at 0 and must not be negative. Updating a load recomputes the
+// minimum load of the ancestors of the point, which costs O(height * MAX_ENTRIES), so loads can change between
+// queries without rebuilding. Positions are those of Result.Index.
+// Rebuild resets every load to 0, RebuildRegion keeps the load of every point.
+//
+// SetLoad is not safe to call concurrently with queries
+func (r *SimpleRTree) SetLoad(idx int, load float64) {
+	if !(load >= 0) || math.IsInf(load, 1) {
+		panic(fmt.Sprintf("invalid load %v", load))
+	}
+	if r.loads == nil {
+		if load == 0 {
+			return
+		}
+		r.loads = make([]float64, r.getLen())
+		r.minLoad = make([]float64, len(r.nodes))
+	}
+	r.loads[idx] = load
+	// nodes cover contiguous ranges of points, so the path is found descending into the child containing idx
+	path := []int{0}
+	for n := &r.nodes[0]; n.nodeType != preleaf_node; n = &r.nodes[path[len(path)-1]] {
+		for child := n.firstChildIndex(); child < n.firstChildIndex()+int(n.nChildren); child++ {
+			if _, end := r.nodePointRange(child); idx < end {
+				path = append(path, child)
+				break
+			}
+		}
+	}
+	for p := len(path) - 1; p >= 0; p-- {
+		n := &r.nodes[path[p]]
+		minLoad := math.Inf(1)
+		if n.nodeType == preleaf_node {
+			for j := n.firstPointIndex(); j < n.firstPointIndex()+int(n.nChildren); j++ {
+				minLoad = math.Min(minLoad, r.loads[j])
+			}
+		} else {
+			for j := n.firstChildIndex(); j < n.firstChildIndex()+int(n.nChildren); j++ {
+				minLoad = math.Min(minLoad, r.minLoad[j])
+			}
+		}
+		r.minLoad[path[p]] = minLoad
+	}
+}
+
+// GetLoad returns the load of the point at position idx, see SetLoad
+func (r *SimpleRTree) GetLoad(idx int) float64 {
+	if r.loads == nil {
+		return 0
+	}
+	return r.loads[idx]
+}
+
+// FindNearestByLoadWeightedDistance returns the point minimizing distance * (1 + load), where distance is the
+// euclidean distance from x and y and load the one set with SetLoad, together with the minimum value, so that less
+// loaded points are preferred over slightly closer busy ones. Distance of the result is squared as in the rest of
+// queries. Nodes are pruned with the minimum load of their points, so busy areas are skipped without visiting them
+func (r *SimpleRTree) FindNearestByLoadWeightedDistance(x, y float64) (res Result, score float64, found bool) {
+	r.bestFirstNodes(
+		func(i, height int) (float64, bool) {
+			mind, _ := computeDistances(r.nodes[i].BBox, x, y, r.insideFactor)
+			if r.loads == nil {
+				return math.Sqrt(mind), true
+			}
+			return math.Sqrt(mind) * (1 + r.minLoad[i]), true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return math.Sqrt(d) * (1 + r.GetLoad(i)), true
+		},
+		func(i int, px, py, priority float64) bool {
+			_, _, d := r.pointDistance(i, x, y)
+			res = Result{Index: i, X: px, Y: py, Distance: d}
+			score = priority
+			found = true
+			return false
+		},
+	)
+	return
+}
+
+// computeMinLoad sets the minimum load of the points under the node at position i and its descendants
+func (r *SimpleRTree) computeMinLoad(i int) float64 {
+	n := &r.nodes[i]
+	minLoad := math.Inf(1)
+	if n.nodeType == preleaf_node {
+		for j := n.firstPointIndex(); j < n.firstPointIndex()+int(n.nChildren); j++ {
+			minLoad = math.Min(minLoad, r.loads[j])
+		}
+	} else {
+		for j := n.firstChildIndex(); j < n.firstChildIndex()+int(n.nChildren); j++ {
+			minLoad = math.Min(minLoad, r.computeMinLoad(j))
+		}
+	}
+	r.minLoad[i] = minLoad
+	return minLoad
+}
+
+// resetLoads drops the loads, positions are not valid anymore after the points are reordered
+func (r *SimpleRTree) resetLoads() {
+	r.loads = nil
+	r.minLoad = nil
+}
@@ -0,0 +1,57 @@
+package SimpleRTree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRTree_FindNearestByLoadWeightedDistance(t *testing.T) {
+	const size = 3000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	loads := make([]float64, size)
+	for round := 0; round < 5; round++ {
+		// update a subset between queries, resetting some loads to 0
+		for n := 0; n < size/3; n++ {
+			idx := rand.Intn(size)
+			loads[idx] = float64(rand.Intn(4)) * rand.Float64() * 10
+			r.SetLoad(idx, loads[idx])
+		}
+		for n := 0; n < 50; n++ {
+			x, y := rand.Float64(), rand.Float64()
+			best, bestIdx := math.Inf(1), -1
+			for i := 0; i < size; i++ {
+				px, py := points.GetPointAt(i)
+				if score := math.Sqrt(computeLeafDistance(px, py, x, y)) * (1 + loads[i]); score < best {
+					best, bestIdx = score, i
+				}
+			}
+			res, score, found := r.FindNearestByLoadWeightedDistance(x, y)
+			assert.True(t, found)
+			assert.InDelta(t, best, score, 1e-12)
+			assert.Equal(t, bestIdx, res.Index)
+			px, py := points.GetPointAt(res.Index)
+			assert.Equal(t, computeLeafDistance(px, py, x, y), res.Distance)
+		}
+	}
+	assert.Equal(t, loads[7], r.GetLoad(7))
+	assert.Panics(t, func() { r.SetLoad(0, -1) })
+	assert.Panics(t, func() { r.SetLoad(0, math.NaN()) })
+
+	// without loads it is the nearest point
+	r = New().Load(FlatPoints{0, 0, 1, 0, 3, 0})
+	res, score, _ := r.FindNearestByLoadWeightedDistance(0.8, 0)
+	assert.Equal(t, 1., res.X)
+	assert.InDelta(t, 0.2, score, 1e-12)
+	r.SetLoad(res.Index, 4)
+	res, _, _ = r.FindNearestByLoadWeightedDistance(0.8, 0)
+	assert.Equal(t, 0., res.X)
+	assert.NoError(t, r.Rebuild(FlatPoints{0, 0, 1, 0, 3, 0}))
+	assert.Equal(t, 0., r.GetLoad(1))
+}
@@ -0,0 +1,40 @@
+package SimpleRTree
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxLeafSize is the largest number of points a leaf can hold, nChildren is an int8
+const maxLeafSize = math.MaxInt8
+
+// heightCap returns the height the tree of n points is limited to by Options.MaxHeight, 0 if it is not set, and an
+// error wrapping ErrMaxHeightTooLow if n points do not fit under it. Hilbert trees, and trees loaded with a sort key,
+// always have a root above the leaves, so their cap is at least 2
+func (r *SimpleRTree) heightCap(n int) (int, error) {
+	maxHeight := r.options.MaxHeight
+	if maxHeight == 0 {
+		return 0, nil
+	}
+	if (r.options.TreeType != STR || r.sortKey != nil) && maxHeight < 2 {
+		maxHeight = 2
+	}
+	if size := cappedLeafSize(n, r.options.MAX_ENTRIES, maxHeight); size > maxLeafSize {
+		return 0, fmt.Errorf("%w: %d points need %d points per leaf under height %d, leaves hold at most %d",
+			ErrMaxHeightTooLow, n, size, maxHeight, maxLeafSize)
+	}
+	return maxHeight, nil
+}
+
+// cappedLeafSize returns the number of points leaves must hold for n points to fit in a tree of maxHeight levels with
+// maxEntries children per node, and at least maxEntries
+func cappedLeafSize(n, maxEntries, maxHeight int) int {
+	capacity := 1
+	for h := 1; h < maxHeight && capacity < n; h++ {
+		capacity *= maxEntries
+	}
+	if size := (n + capacity - 1) / capacity; size > maxEntries {
+		return size
+	}
+	return maxEntries
+}
@@ -0,0 +1,123 @@
+package SimpleRTree
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// treeDepth returns the number of levels of nodes below the node at position i, itself included, and the largest
+// number of points of its leaves
+func (r *SimpleRTree) treeDepth(i int) (depth, maxLeaf int) {
+	n := &r.nodes[i]
+	if n.nodeType == preleaf_node {
+		return 1, int(n.nChildren)
+	}
+	for j := n.firstChildIndex(); j < n.firstChildIndex()+int(n.nChildren); j++ {
+		d, l := r.treeDepth(j)
+		depth, maxLeaf = maxInt(depth, d+1), maxInt(maxLeaf, l)
+	}
+	return
+}
+
+func TestSimpleRTree_MaxHeight(t *testing.T) {
+	const size = 20000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	reference := New().Load(append(FlatPoints(nil), points...))
+	natural, _ := reference.treeDepth(0)
+	testCases := []struct {
+		options Options
+		height  int
+	}{
+		{Options{}, natural},
+		{Options{MaxHeight: natural + 3}, natural},
+		{Options{MaxHeight: natural - 1}, natural - 1},
+		{Options{MaxHeight: 5, MAX_ENTRIES: 4}, 5},
+		{Options{MaxHeight: 5, MAX_ENTRIES: 4, BalancedLeaves: true}, 5},
+		{Options{MaxHeight: 5, MAX_ENTRIES: 4, MortonLeaves: true}, 5},
+		{Options{MaxHeight: 4, TreeType: HILBERT}, 4},
+	}
+	for _, tc := range testCases {
+		r := NewWithOptions(tc.options).Load(append(FlatPoints(nil), points...))
+		depth, maxLeaf := r.treeDepth(0)
+		assert.Equal(t, tc.height, depth, "%+v", tc.options)
+		assert.Equal(t, tc.height, r.height, "%+v", tc.options)
+		assert.True(t, maxLeaf <= maxLeafSize)
+		if tc.options.MaxHeight != 0 && tc.options.MaxHeight < natural {
+			assert.True(t, maxLeaf > r.options.MAX_ENTRIES, "%+v", tc.options)
+		}
+		assertBBoxesContainChildren(t, r)
+		for n := 0; n < 200; n++ {
+			x, y := rand.Float64(), rand.Float64()
+			_, _, d := points.linearClosestPoint(x, y)
+			res, found := r.findNearestPointWithin(x, y, math.Inf(1), nil)
+			assert.True(t, found)
+			assert.Equal(t, d, res.Distance, "%+v", tc.options)
+			box := randomBBox(0.1)
+			assert.Equal(t, sortedPoints(reference.SearchInto(box, nil)), sortedPoints(r.SearchInto(box, nil)))
+		}
+	}
+
+	// a single level holds up to maxLeafSize points in the root
+	r := NewWithOptions(Options{MaxHeight: 1}).Load(append(FlatPoints(nil), points[:2*maxLeafSize]...))
+	assert.True(t, r.nodes[0].nodeType == preleaf_node)
+	assert.Equal(t, maxLeafSize, int(r.nodes[0].nChildren))
+	assert.PanicsWithError(t, "too many points for MaxHeight: 128 points need 128 points per leaf under height 1, leaves hold at most 127", func() {
+		NewWithOptions(Options{MaxHeight: 1}).Load(append(FlatPoints(nil), points[:2*(maxLeafSize+1)]...))
+	})
+
+	// rebuilds keep the cap, and trees with large leaves survive serialization and partial rebuilds
+	r = NewWithOptions(Options{MaxHeight: 3}).Load(append(FlatPoints(nil), points[:2000]...))
+	assert.ErrorIs(t, r.Rebuild(append(FlatPoints(nil), points...)), ErrMaxHeightTooLow)
+	assert.NoError(t, r.Rebuild(append(FlatPoints(nil), points[:4000]...)))
+	depth, _ := r.treeDepth(0)
+	assert.Equal(t, 3, depth)
+	data, err := r.MarshalBinary()
+	assert.NoError(t, err)
+	restored := New()
+	assert.NoError(t, restored.UnmarshalBinary(data))
+	box := BBox{0.2, 0.2, 0.3, 0.3}
+	assert.Equal(t, sortedPoints(r.SearchInto(box, nil)), sortedPoints(restored.SearchInto(box, nil)))
+	moved := make(FlatPoints, 2*len(restored.Search(box)))
+	for i := range moved {
+		moved[i] = rand.Float64()
+	}
+	assert.NoError(t, restored.RebuildRegion(box, moved))
+	assertBBoxesContainChildren(t, restored)
+	depth, _ = restored.treeDepth(0)
+	assert.Equal(t, 3, depth)
+}
+
+func BenchmarkSimpleRTree_MaxHeight(b *testing.B) {
+	const size = 100000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	for _, maxHeight := range []int{0, 8, 7, 6} {
+		r := NewWithOptions(Options{MAX_ENTRIES: 4, MaxHeight: maxHeight}).Load(append(FlatPoints(nil), points...))
+		b.Run(fmt.Sprintf("MaxHeight=%d", maxHeight), func(b *testing.B) {
+			latencies := make([]time.Duration, b.N)
+			for n := 0; n < b.N; n++ {
+				x, y := rand.Float64(), rand.Float64()
+				start := time.Now()
+				r.FindNearestPoint(x, y)
+				latencies[n] = time.Since(start)
+			}
+			sort.Slice(latencies, func(i, j int) bool {
+				return latencies[i] < latencies[j]
+			})
+			b.ReportMetric(float64(r.height), "height")
+			b.ReportMetric(float64(latencies[b.N/2]), "p50-ns")
+			b.ReportMetric(float64(latencies[b.N*99/100]), "p99-ns")
+		})
+	}
+}
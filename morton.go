@@ -0,0 +1,52 @@
+package SimpleRTree
+
+import "math"
+
+// sortLeavesMorton sorts the points of the leaves in r.nodes[from:to] in Morton order. Coordinates are quantized
+// relative to the bbox of the leaf, so the order does not depend on the scale of the data. Since leaves keep the
+// same points their bboxes do not change.
+func (r *SimpleRTree) sortLeavesMorton(from, to int) {
+	var keys [maxLeafSize]uint64
+	for i := from; i < to; i++ {
+		n := &r.nodes[i]
+		if n.nodeType != preleaf_node {
+			continue
+		}
+		start := n.firstPointIndex()
+		count := int(n.nChildren)
+		for j := 0; j < count; j++ {
+			x, y := r.getPointAt(start + j)
+			keys[j] = leafMortonKey(n.BBox, x, y)
+		}
+		// leaves are small, insertion sort is enough
+		for j := 1; j < count; j++ {
+			for k := j; k > 0 && keys[k] < keys[k-1]; k-- {
+				keys[k], keys[k-1] = keys[k-1], keys[k]
+				r.swapPoints(start+k, start+k-1)
+			}
+		}
+	}
+}
+
+func (r *SimpleRTree) swapPoints(i, j int) {
+	if r.points != nil {
+		r.points.Swap(i, j)
+		return
+	}
+	r.source.Swap(i, j)
+}
+
+func leafMortonKey(bbox VectorBBox, x, y float64) uint64 {
+	return interleave(
+		quantizeUnit(x, bbox[VECTOR_BBOX_MIN_X], bbox[VECTOR_BBOX_MAX_X]),
+		quantizeUnit(y, bbox[VECTOR_BBOX_MIN_Y], bbox[VECTOR_BBOX_MAX_Y]),
+	)
+}
+
+// quantizeUnit maps v in [min, max] to [0, MaxUint32]
+func quantizeUnit(v, min, max float64) uint32 {
+	if max <= min {
+		return 0
+	}
+	return uint32((v - min) / (max - min) * math.MaxUint32)
+}
@@ -0,0 +1,67 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSimpleRTree_MortonLeaves(t *testing.T) {
+	const size = 20000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	fp := FlatPoints(points)
+	fp2 := FlatPoints(append([]float64{}, points...))
+	r := NewWithOptions(Options{MortonLeaves: true}).Load(fp)
+	plain := New().Load(fp2)
+	assert.Equal(t, plain.nodes, r.nodes, "Reordering within leaves does not change the nodes")
+	assertBBoxesContainChildren(t, r)
+	for i := range r.nodes {
+		n := &r.nodes[i]
+		if n.nodeType != preleaf_node {
+			continue
+		}
+		for j := n.firstPointIndex() + 1; j < n.firstPointIndex()+int(n.nChildren); j++ {
+			x0, y0 := fp.GetPointAt(j - 1)
+			x1, y1 := fp.GetPointAt(j)
+			assert.True(t, leafMortonKey(n.BBox, x0, y0) <= leafMortonKey(n.BBox, x1, y1))
+		}
+	}
+	for i := 0; i < 100; i++ {
+		x, y := rand.Float64(), rand.Float64()
+		x1, y1, _ := r.FindNearestPoint(x, y)
+		x2, y2, _ := fp.linearClosestPoint(x, y)
+		assert.Equal(t, x2, x1)
+		assert.Equal(t, y2, y1)
+		box := randomBBox(0.1)
+		results := r.Search(box)
+		sort.Ints(results)
+		assert.Equal(t, fp.linearSearch(box), results)
+	}
+}
+
+func BenchmarkSimpleRTree_SearchMortonLeaves(b *testing.B) {
+	const size = 1000000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	for _, bm := range []struct {
+		name    string
+		options Options
+	}{
+		{"STR", Options{}},
+		{"MortonLeaves", Options{MortonLeaves: true}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			r := NewWithOptions(bm.options).Load(FlatPoints(append([]float64{}, points...)))
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				_ = r.Search(BBox{0.25, 0.25, 0.75, 0.75})
+			}
+		})
+	}
+}
@@ -0,0 +1,605 @@
+package SimpleRTree
+
+import (
+	"math"
+	"math/rand"
+)
+
+// FindNearestWithBearing returns the closest point to x and y together with the bearing from (x, y) to it.
+// Bearing is given in degrees in [0, 360), measured clockwise from the positive y axis, so that
+// north (+y) is 0, east (+x) is 90, south is 180 and west is 270.
+// Coordinates are treated as planar, bearing is atan2 of the difference of coordinates. For longitudes and latitudes
+// use FindNearestGeoWithBearing, which returns the initial great circle bearing.
+// If the point is at the query coordinates bearing is 0
+func (r *SimpleRTree) FindNearestWithBearing(x, y float64) (res Result, bearingDegrees float64, found bool) {
+	res, found = r.findNearestPointWithin(x, y, math.Inf(1), nil)
+	if !found {
+		return
+	}
+	bearingDegrees = planarBearing(x, y, res.X, res.Y)
+	return
+}
+
+// planarBearing returns the compass bearing in degrees from (x, y) to (px, py)
+func planarBearing(x, y, px, py float64) float64 {
+	bearing := math.Atan2(px-x, py-y) * 180 / math.Pi
+	if bearing < 0 {
+		bearing += 360
+	}
+	return bearing
+}
+
+// FindNearestPointExcludingIdx returns the closest point to x and y other than the point at position selfIdx.
+// It is meant for queries on points of the index itself, where the closest point is the point itself.
+// Unlike discarding results at distance zero, it works when there are duplicated points
+func (r *SimpleRTree) FindNearestPointExcludingIdx(x, y float64, selfIdx int) (res Result, found bool) {
+	return r.findNearestAccepted(x, y, func(i int, px, py, d float64) bool {
+		return i != selfIdx
+	})
+}
+
+// findNearestAccepted returns the closest point to x and y among those for which accept returns true.
+// Points disabled with SetEnabled are never accepted
+func (r *SimpleRTree) findNearestAccepted(x, y float64, accept func(i int, px, py, d float64) bool) (res Result, found bool) {
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			mind, _ := computeDistances(bbox, x, y, r.insideFactor)
+			return mind, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return d, accept(i, px, py, d)
+		},
+		func(i int, px, py, d float64) bool {
+			res = Result{Index: i, X: px, Y: py, Distance: d}
+			found = true
+			return false
+		},
+	)
+	return
+}
+
+// FindNearestPointAvoiding returns the closest point to x and y that is not inside any of the blocked boxes,
+// boundary included. Nodes whose bbox is fully inside a blocked box are not visited.
+// Blocking is only membership of the points: the path from x and y to the point can cross blocked boxes, there is
+// no line of sight test
+func (r *SimpleRTree) FindNearestPointAvoiding(x, y float64, blocked []BBox) (res Result, found bool) {
+	isBlocked := func(box BBox) bool {
+		for _, b := range blocked {
+			if b.contains(box) {
+				return true
+			}
+		}
+		return false
+	}
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			if isBlocked(bbox.ToBBox()) {
+				return 0, false
+			}
+			mind, _ := computeDistances(bbox, x, y, r.insideFactor)
+			return mind, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] || isBlocked(BBox{px, py, px, py}) {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return d, true
+		},
+		func(i int, px, py, d float64) bool {
+			res = Result{Index: i, X: px, Y: py, Distance: d}
+			found = true
+			return false
+		},
+	)
+	return
+}
+
+// FindNearestPointUnique returns the closest point to x and y and whether it is clearly the closest one, that is,
+// whether no other point lies at a distance within epsilon of its distance. Epsilon is a plain distance, not a
+// squared one: the nearest point is ambiguous if the second nearest one is at most at sqrt(d) + epsilon, with d the
+// distance squared in the result. With epsilon zero, or negative, it is only ambiguous if there is a tie.
+// The search only continues until the second nearest point is reached, it is cheaper than a separate query
+func (r *SimpleRTree) FindNearestPointUnique(x, y, epsilon float64) (res Result, unique bool, found bool) {
+	epsilon = math.Max(epsilon, 0)
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			mind, _ := computeDistances(bbox, x, y, r.insideFactor)
+			return mind, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return d, true
+		},
+		func(i int, px, py, d float64) bool {
+			if !found {
+				res = Result{Index: i, X: px, Y: py, Distance: d}
+				found, unique = true, true
+				return true
+			}
+			limit := math.Sqrt(res.Distance) + epsilon
+			unique = d > limit*limit
+			return false
+		},
+	)
+	return
+}
+
+// FindNearestPointRandomized returns a point picked uniformly at random among the points whose distance to x and y
+// is at most the distance to the nearest one times 1 + relTol, so that ties and near ties are spread across the
+// candidates instead of always returning the same one, for example to balance load between equally good servers.
+// relTol is relative to the distance, not to the distance squared, negative values are treated as zero, which still
+// picks among exact ties. Candidates are gathered in increasing order of distance and the search stops at the first
+// point beyond the tolerance. The tree is traversed in a deterministic order, so with an rng with a fixed seed the
+// sequence of results is reproducible for the same tree and queries. rng must not be nil
+func (r *SimpleRTree) FindNearestPointRandomized(x, y, relTol float64, rng *rand.Rand) (res Result, found bool) {
+	relTol = math.Max(relTol, 0)
+	var candidates []Result
+	limit := math.Inf(1)
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			mind, _ := computeDistances(bbox, x, y, r.insideFactor)
+			return mind, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return d, true
+		},
+		func(i int, px, py, d float64) bool {
+			if d > limit {
+				return false
+			}
+			if len(candidates) == 0 {
+				// distances are squared, so the tolerance is squared too
+				limit = d * (1 + relTol) * (1 + relTol)
+			}
+			candidates = append(candidates, Result{Index: i, X: px, Y: py, Distance: d})
+			return true
+		},
+	)
+	if len(candidates) == 0 {
+		return
+	}
+	return candidates[rng.Intn(len(candidates))], true
+}
+
+// FindNearestPointProgressive returns the closest point to x and y and calls onImprove every time the search finds a
+// point closer than the previous ones, so that callers can show a good enough answer while a query over a slow index
+// is running. Candidates are the points of the leaves as they are expanded, so distances passed to onImprove never
+// increase, and the last call is always with the returned point
+func (r *SimpleRTree) FindNearestPointProgressive(x, y float64, onImprove func(Result)) (res Result, found bool) {
+	var best Result
+	improved := false
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			mind, _ := computeDistances(bbox, x, y, r.insideFactor)
+			return mind, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			if !improved || d < best.Distance {
+				best, improved = Result{Index: i, X: px, Y: py, Distance: d}, true
+				onImprove(best)
+			}
+			return d, true
+		},
+		func(i int, px, py, d float64) bool {
+			res = Result{Index: i, X: px, Y: py, Distance: d}
+			found = true
+			// the nearest point was already reported, unless it ties with an earlier candidate
+			if res.Index != best.Index {
+				onImprove(res)
+			}
+			return false
+		},
+	)
+	return
+}
+
+// FindNearestPointExpanding returns the closest point to x and y searching in a radius that starts at initialR and
+// doubles until a point is found or it reaches maxR, the last radius being maxR itself: initialR, 2 * initialR,
+// 4 * initialR... maxR. Radii are plain distances and points on the boundary of a radius are found in it.
+// radius is the radius of the step that found the point.
+//
+// The nearest point within a radius is the nearest point overall if it is inside it, so the steps do not need
+// separate searches: a single one bounded by maxR gives the same point, and the step is computed from its distance.
+// found is false if there is no point within maxR or initialR is not positive
+func (r *SimpleRTree) FindNearestPointExpanding(x, y, initialR, maxR float64) (res Result, radius float64, found bool) {
+	if initialR <= 0 || maxR < 0 || math.IsNaN(initialR) {
+		return
+	}
+	res, found = r.findNearestPointWithin(x, y, maxR*maxR, nil)
+	if !found {
+		return
+	}
+	if r.options.RobustDistance {
+		res.Distance = r.finalDistance(res.X, res.Y, x, y)
+	}
+	radius = math.Min(initialR, maxR)
+	for radius < maxR && radius*radius < res.Distance {
+		radius = math.Min(2*radius, maxR)
+	}
+	return res, radius, true
+}
+
+// FindNearestPointTransformed returns the closest point to x and y given in another coordinate system than the
+// points of the tree. fwd maps the query into the coordinates of the tree and inv maps the result back, so X and Y
+// of the result are in the coordinates of the query, while Distance is measured in the coordinates of the tree.
+// If either transform returns NaN or infinite coordinates, for example for a point outside of its domain,
+// found is false
+func (r *SimpleRTree) FindNearestPointTransformed(x, y float64, fwd, inv func(x, y float64) (float64, float64)) (res Result, found bool) {
+	tx, ty := fwd(x, y)
+	if !isFinite(tx) || !isFinite(ty) {
+		return
+	}
+	res, found = r.findNearestPointWithin(tx, ty, math.Inf(1), nil)
+	if !found {
+		return
+	}
+	if r.options.RobustDistance {
+		res.Distance = r.finalDistance(res.X, res.Y, tx, ty)
+	}
+	res.X, res.Y = inv(res.X, res.Y)
+	if !isFinite(res.X) || !isFinite(res.Y) {
+		return Result{}, false
+	}
+	return res, true
+}
+
+// FindNearestPointWarped returns the closest point to x and y under the metric warped by the linear transform a,
+// for data aligned with directions other than the axes, such as a diagonal street grid: the distance between p and
+// the query is |a (p - q)|^2, that is (p - q)^T M (p - q) with M = a^T a, a[i] being the rows of a. Distance in the
+// result is that warped distance squared. Unlike FindNearestPointTransformed the tree is not reprojected, only the
+// metric changes, and the points are returned in their own coordinates.
+//
+// Nodes are pruned with the exact minimum of the warped distance over their bbox, which is admissible for any a. For
+// the warped distance to be a metric a must be invertible, so that M is positive definite: a singular a puts points
+// differing along its kernel at distance zero, and results are then any of them
+func (r *SimpleRTree) FindNearestPointWarped(x, y float64, a [2][2]float64) (res Result, found bool) {
+	m := [3]float64{
+		a[0][0]*a[0][0] + a[1][0]*a[1][0],
+		a[0][0]*a[0][1] + a[1][0]*a[1][1],
+		a[0][1]*a[0][1] + a[1][1]*a[1][1],
+	}
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			return warpedBoxDistance(bbox, x, y, m), true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			return warpedDistance(px-x, py-y, m), true
+		},
+		func(i int, px, py, d float64) bool {
+			res = Result{Index: i, X: px, Y: py, Distance: d}
+			found = true
+			return false
+		},
+	)
+	return
+}
+
+// warpedDistance returns v^T M v for v = (dx, dy) and the symmetric matrix M given as m11, m12, m22
+func warpedDistance(dx, dy float64, m [3]float64) float64 {
+	return m[0]*dx*dx + 2*m[1]*dx*dy + m[2]*dy*dy
+}
+
+// warpedBoxDistance returns the minimum of the warped distance between (x, y) and the points of bbox. The warped
+// distance is convex, so if the query is outside the box the minimum lies on one of its sides, where it is a
+// quadratic in a single variable minimized in closed form
+func warpedBoxDistance(bbox VectorBBox, x, y float64, m [3]float64) float64 {
+	minX, maxX := bbox[VECTOR_BBOX_MIN_X]-x, bbox[VECTOR_BBOX_MAX_X]-x
+	minY, maxY := bbox[VECTOR_BBOX_MIN_Y]-y, bbox[VECTOR_BBOX_MAX_Y]-y
+	if minX <= 0 && maxX >= 0 && minY <= 0 && maxY >= 0 {
+		return 0
+	}
+	// side with dx fixed, dy minimizing m22 dy^2 + 2 m12 dx dy in [lo, hi], and the other way around
+	sideMin := func(fixed, lo, hi, cross, curvature float64, dyFree bool) float64 {
+		t := lo
+		if curvature > 0 {
+			t = math.Max(lo, math.Min(hi, -cross*fixed/curvature))
+		} else if cross*fixed < 0 {
+			t = hi
+		}
+		if dyFree {
+			return warpedDistance(fixed, t, m)
+		}
+		return warpedDistance(t, fixed, m)
+	}
+	return math.Min(
+		math.Min(sideMin(minX, minY, maxY, m[1], m[2], true), sideMin(maxX, minY, maxY, m[1], m[2], true)),
+		math.Min(sideMin(minY, minX, maxX, m[1], m[0], false), sideMin(maxY, minX, maxX, m[1], m[0], false)),
+	)
+}
+
+// FindNearestKInBox returns the k closest points to x and y among those inside box, boundary included,
+// in increasing order of distance. Nodes not overlapping box are not visited.
+// If there are fewer than k points inside box all of them are returned
+func (r *SimpleRTree) FindNearestKInBox(k int, x, y float64, box BBox) []Result {
+	if k <= 0 {
+		return nil
+	}
+	var results []Result
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			if !box.intersects(bbox.ToBBox()) {
+				return 0, false
+			}
+			mind, _ := computeDistances(bbox, x, y, r.insideFactor)
+			return mind, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if !box.containsPoint(px, py) || r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return d, true
+		},
+		func(i int, px, py, d float64) bool {
+			results = append(results, Result{Index: i, X: px, Y: py, Distance: d})
+			return len(results) < k
+		},
+	)
+	return results
+}
+
+// KNearestBBox returns the tight bbox of the k closest points to x and y and the number of points it covers, which
+// is less than k if the tree has fewer enabled points, for example to zoom a map to show the k nearest places.
+// Ties at the k-th distance are broken as in the order of the traversal. With no points it returns the same empty
+// box as BBoxOf
+func (r *SimpleRTree) KNearestBBox(k int, x, y float64) (BBox, int) {
+	vb := VectorBBox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	if k <= 0 {
+		return vb.ToBBox(), 0
+	}
+	n := 0
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			mind, _ := computeDistances(bbox, x, y, r.insideFactor)
+			return mind, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return d, true
+		},
+		func(i int, px, py, d float64) bool {
+			vb = VectorBBoxExtend(vb, VectorBBox{px, py, px, py})
+			n++
+			return n < k
+		},
+	)
+	return vb.ToBBox(), n
+}
+
+// FindNearestWeighted returns the point minimizing distance / weight(idx), where distance is the euclidean distance
+// from x and y and idx the position of the point, together with the minimum value.
+// Points with non positive weight are skipped.
+// maxWeight must be an upper bound of the weights, it is used to prune nodes: no point inside a node can score less
+// than its distance divided by maxWeight. If some weight exceeds it results can be wrong, if it is much larger than
+// the actual weights queries get slower
+func (r *SimpleRTree) FindNearestWeighted(x, y float64, weight func(idx int) float64, maxWeight float64) (res Result, score float64, found bool) {
+	if maxWeight <= 0 {
+		return
+	}
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			mind, _ := computeDistances(bbox, x, y, r.insideFactor)
+			return math.Sqrt(mind) / maxWeight, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			w := weight(i)
+			if w <= 0 {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return math.Sqrt(d) / w, true
+		},
+		func(i int, px, py, priority float64) bool {
+			_, _, d := r.pointDistance(i, x, y)
+			res = Result{Index: i, X: px, Y: py, Distance: d}
+			score = priority
+			found = true
+			return false
+		},
+	)
+	return
+}
+
+// Decision is returned by the callbacks of FindNearestPointFuncEx
+type Decision uint8
+
+const (
+	Accept Decision = iota // the point is a candidate
+	Reject                 // the point is skipped
+	Stop                   // the point is skipped and the search ends
+)
+
+// FindNearestPointFuncEx returns the closest point to x and y among those accepted by accept, which receives the
+// position of each point and its distance squared to x and y.
+// Points are evaluated leaf by leaf, in increasing order of the distance to the leaves, so roughly from the closest
+// to the farthest. When accept returns Stop the search ends and the closest point accepted until then is returned,
+// which may not be the closest accepted point overall. This allows searches that are satisfied with a good enough
+// point, for example accepting the first point under a threshold and stopping on the next call
+func (r *SimpleRTree) FindNearestPointFuncEx(x, y float64, accept func(idx int, d float64) Decision) (res Result, found bool) {
+	stopped := false
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			if stopped {
+				return 0, false
+			}
+			mind, _ := computeDistances(bbox, x, y, r.insideFactor)
+			return mind, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if stopped || (r.disabled != nil && r.disabled[i]) {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			switch accept(i, d) {
+			case Accept:
+				return d, true
+			case Stop:
+				stopped = true
+			}
+			return 0, false
+		},
+		func(i int, px, py, d float64) bool {
+			res = Result{Index: i, X: px, Y: py, Distance: d}
+			found = true
+			return false
+		},
+	)
+	return
+}
+
+// FindNearestApproxDepth is a coarse and fast approximation of the nearest point that does not descend below
+// maxDepth, the root being at depth 0. Among the nodes at maxDepth it finds the one whose bbox is closest to
+// x and y and returns the center of its bbox, with Index -1 and the distance squared to the center. If the tree was
+// built with Options.StoreCentroids it returns the centroid of the node instead, the mean of its points.
+// The center can be as far from the actual nearest point as the diagonal of the bbox, which shrinks by a factor of
+// about sqrt(MAX_ENTRIES) on each level. If leaves are reached before maxDepth, in particular if maxDepth is at
+// least the height of the tree, the exact nearest point is returned with its index.
+// On an empty tree it returns Index -1 and an infinite distance
+func (r *SimpleRTree) FindNearestApproxDepth(x, y float64, maxDepth int) Result {
+	res := Result{Index: -1, Distance: math.Inf(1)}
+	if !r.built || len(r.nodes) == 0 {
+		return res
+	}
+	q := make(traversalQueue, 0, r.height*r.options.MAX_ENTRIES+1)
+	q.push(traversalItem{index: 0, height: r.height, priority: math.Inf(-1)})
+	for len(q) > 0 {
+		item := q.pop()
+		if item.isPoint {
+			px, py := r.getPointAt(item.index)
+			return Result{Index: item.index, X: px, Y: py, Distance: item.priority}
+		}
+		// nodes are popped in increasing order of distance to their bbox, so the first one at maxDepth is the closest
+		if r.height-item.height >= maxDepth {
+			bbox := r.nodes[item.index].BBox
+			if item.index == 0 {
+				bbox = r.rootBBox()
+			}
+			b := bbox.ToBBox()
+			cx, cy := (b.MinX+b.MaxX)/2, (b.MinY+b.MaxY)/2
+			if x, y, ok := r.centroid(item.index); ok {
+				cx, cy = x, y
+			}
+			return Result{Index: -1, X: cx, Y: cy, Distance: computeLeafDistance(cx, cy, x, y)}
+		}
+		n := &r.nodes[item.index]
+		if n.nodeType == preleaf_node {
+			start := n.firstPointIndex()
+			for i := start; i < start+int(n.nChildren); i++ {
+				_, _, d := r.pointDistance(i, x, y)
+				q.push(traversalItem{index: i, isPoint: true, priority: d})
+			}
+			continue
+		}
+		first := n.firstChildIndex()
+		for i := first; i < first+int(n.nChildren); i++ {
+			mind, _ := computeDistances(r.nodes[i].BBox, x, y, r.insideFactor)
+			q.push(traversalItem{index: i, height: item.height - 1, priority: mind})
+		}
+	}
+	return res
+}
+
+// KthNearestDistance returns the distance squared from x and y to its k-th nearest point, k starting at 1, without
+// returning the points. It keeps the k smallest distances in a bounded max heap, whose top prunes the nodes once it
+// is full, so the only allocation is the heap itself.
+// found is false if k is not positive or there are fewer than k points
+func (r *SimpleRTree) KthNearestDistance(k int, x, y float64) (d float64, found bool) {
+	if k <= 0 || !r.built || len(r.nodes) == 0 {
+		return
+	}
+	h := make(distanceMaxHeap, 0, k)
+	stack := make([]int, 1, 32)
+	for len(stack) > 0 {
+		n := &r.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if len(h) == k && n != &r.nodes[0] {
+			if mind, _ := computeDistances(n.BBox, x, y, r.insideFactor); mind > h[0] {
+				continue
+			}
+		}
+		if n.nodeType == preleaf_node {
+			start := n.firstPointIndex()
+			for i := start; i < start+int(n.nChildren); i++ {
+				if r.disabled != nil && r.disabled[i] {
+					continue
+				}
+				_, _, pd := r.pointDistance(i, x, y)
+				if len(h) < k {
+					h.push(pd)
+				} else if pd < h[0] {
+					h.replaceTop(pd)
+				}
+			}
+			continue
+		}
+		first := n.firstChildIndex()
+		for i := first; i < first+int(n.nChildren); i++ {
+			stack = append(stack, i)
+		}
+	}
+	if len(h) < k {
+		return
+	}
+	return h[0], true
+}
+
+// distanceMaxHeap is a binary max heap of distances
+type distanceMaxHeap []float64
+
+func (h *distanceMaxHeap) push(d float64) {
+	*h = append(*h, d)
+	s := *h
+	for i := len(s) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if s[parent] >= s[i] {
+			break
+		}
+		s[parent], s[i] = s[i], s[parent]
+		i = parent
+	}
+}
+
+// replaceTop replaces the largest distance with d
+func (h distanceMaxHeap) replaceTop(d float64) {
+	h[0] = d
+	for i := 0; ; {
+		largest := i
+		left, right := 2*i+1, 2*i+2
+		if left < len(h) && h[left] > h[largest] {
+			largest = left
+		}
+		if right < len(h) && h[right] > h[largest] {
+			largest = right
+		}
+		if largest == i {
+			return
+		}
+		h[i], h[largest] = h[largest], h[i]
+		i = largest
+	}
+}
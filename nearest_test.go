@@ -0,0 +1,727 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSimpleRTree_FindNearestWithBearing(t *testing.T) {
+	testCases := []struct {
+		px, py  float64
+		bearing float64
+	}{
+		{0, 1, 0},
+		{1, 1, 45},
+		{1, 0, 90},
+		{0, -1, 180},
+		{-1, 0, 270},
+		{-1, 1, 315},
+	}
+	for _, tc := range testCases {
+		fp := FlatPoints{tc.px, tc.py, 100, 100, -100, 50}
+		r := New().Load(fp)
+		res, bearing, found := r.FindNearestWithBearing(0, 0)
+		assert.True(t, found)
+		assert.Equal(t, tc.px, res.X)
+		assert.Equal(t, tc.py, res.Y)
+		assert.Equal(t, tc.px*tc.px+tc.py*tc.py, res.Distance)
+		assert.InDelta(t, tc.bearing, bearing, 1e-9, "Point %v %v", tc.px, tc.py)
+		px, py := fp.GetPointAt(res.Index)
+		assert.Equal(t, tc.px, px)
+		assert.Equal(t, tc.py, py)
+	}
+	_, _, found := New().FindNearestWithBearing(0, 0)
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_FindNearestPointIndex(t *testing.T) {
+	const size = 20000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	fp := FlatPoints(points)
+	ip := make(IntPoints, size*2)
+	for i := range ip {
+		ip[i] = int32(rand.Intn(100000))
+	}
+	r := New().Load(fp)
+	rInt := New().LoadInterface(ip)
+	for i := 0; i < 1000; i++ {
+		x, y := rand.Float64(), rand.Float64()
+		res, found := r.findNearestPointWithin(x, y, 1, nil)
+		assert.True(t, found)
+		px, py := fp.GetPointAt(res.Index)
+		assert.Equal(t, res.X, px)
+		assert.Equal(t, res.Y, py)
+		res, found = rInt.findNearestPointWithin(x*100000, y*100000, 1e20, nil)
+		assert.True(t, found)
+		px, py = ip.GetPointAt(res.Index)
+		assert.Equal(t, res.X, px)
+		assert.Equal(t, res.Y, py)
+	}
+}
+
+func TestSimpleRTree_FindNearestPointExcludingIdx(t *testing.T) {
+	// query on a point with a duplicate and a close neighbour
+	fp := FlatPoints{0, 0, 5, 5, 1, 1, 5, 5, 5.5, 5, 10, 10}
+	r := New().Load(fp)
+	for i := 0; i < fp.Len(); i++ {
+		x, y := fp.GetPointAt(i)
+		if x != 5 || y != 5 {
+			continue
+		}
+		res, found := r.FindNearestPointExcludingIdx(x, y, i)
+		assert.True(t, found)
+		assert.NotEqual(t, i, res.Index)
+		assert.Equal(t, 5., res.X, "Duplicate is the nearest")
+		assert.Equal(t, 5., res.Y)
+		assert.Equal(t, 0., res.Distance)
+	}
+	for i := 0; i < fp.Len(); i++ {
+		x, y := fp.GetPointAt(i)
+		if x != 5.5 {
+			continue
+		}
+		res, found := r.FindNearestPointExcludingIdx(x, y, i)
+		assert.True(t, found)
+		assert.Equal(t, 5., res.X)
+		assert.Equal(t, 0.25, res.Distance)
+	}
+
+	const size = 5000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	fp = FlatPoints(points)
+	r = New().Load(fp)
+	for i := 0; i < size; i += 7 {
+		x, y := fp.GetPointAt(i)
+		res, found := r.FindNearestPointExcludingIdx(x, y, i)
+		assert.True(t, found)
+		expected := math.Inf(1)
+		for j := 0; j < size; j++ {
+			if j != i {
+				px, py := fp.GetPointAt(j)
+				expected = math.Min(expected, computeLeafDistance(px, py, x, y))
+			}
+		}
+		assert.Equal(t, expected, res.Distance)
+	}
+	_, found := New().Load(FlatPoints{1, 1}).FindNearestPointExcludingIdx(1, 1, 0)
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_FindNearestPointAvoiding(t *testing.T) {
+	const size = 5000
+	points := make([]float64, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	fp := FlatPoints(points)
+	r := New().Load(fp)
+	linear := func(x, y float64, blocked []BBox) (d float64, found bool) {
+		d = math.Inf(1)
+		for i := 0; i < fp.Len(); i++ {
+			px, py := fp.GetPointAt(i)
+			inside := false
+			for _, b := range blocked {
+				inside = inside || b.containsPoint(px, py)
+			}
+			if pd := (px-x)*(px-x) + (py-y)*(py-y); !inside && pd < d {
+				d, found = pd, true
+			}
+		}
+		return
+	}
+	for n := 0; n < 200; n++ {
+		// overlapping blocked regions
+		blocked := []BBox{randomBBox(0.3), randomBBox(0.3), randomBBox(0.3)}
+		x, y := rand.Float64(), rand.Float64()
+		res, found := r.FindNearestPointAvoiding(x, y, blocked)
+		expected, expectedFound := linear(x, y, blocked)
+		assert.Equal(t, expectedFound, found)
+		assert.Equal(t, expected, res.Distance)
+		for _, b := range blocked {
+			assert.False(t, b.containsPoint(res.X, res.Y))
+		}
+	}
+
+	// query surrounded by blocked cells of a grid, the nearest reachable point is outside the ring
+	blocked := []BBox{}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			blocked = append(blocked, BBox{0.3 + 0.1*float64(i), 0.3 + 0.1*float64(j), 0.4 + 0.1*float64(i), 0.4 + 0.1*float64(j)})
+		}
+	}
+	res, found := r.FindNearestPointAvoiding(0.45, 0.45, blocked)
+	expected, _ := linear(0.45, 0.45, blocked)
+	assert.True(t, found)
+	assert.Equal(t, expected, res.Distance)
+	assert.False(t, BBox{0.3, 0.3, 0.6, 0.6}.containsPoint(res.X, res.Y))
+
+	_, found = r.FindNearestPointAvoiding(0.5, 0.5, []BBox{{-1, -1, 2, 2}})
+	assert.False(t, found)
+	res, _ = r.FindNearestPointAvoiding(0.5, 0.5, nil)
+	_, _, d := r.FindNearestPoint(0.5, 0.5)
+	assert.Equal(t, d, res.Distance)
+}
+
+func TestSimpleRTree_FindNearestPointUnique(t *testing.T) {
+	r := New().Load(FlatPoints{0, 0, 10, 0, 0, 10.5, 20, 20, 20, 20})
+	res, unique, found := r.FindNearestPointUnique(1, 0, 0.5)
+	assert.True(t, found)
+	assert.True(t, unique)
+	assert.Equal(t, []float64{0, 0, 1}, []float64{res.X, res.Y, res.Distance})
+
+	// second point at distance 5.1, within 0.5 of 4.9
+	res, unique, found = r.FindNearestPointUnique(4.9, 0, 0.5)
+	assert.True(t, found)
+	assert.False(t, unique)
+	assert.Equal(t, []float64{0, 0}, []float64{res.X, res.Y})
+	_, unique, _ = r.FindNearestPointUnique(4.9, 0, 0.1)
+	assert.True(t, unique)
+
+	// duplicated points are never unique, a tie is ambiguous even with epsilon zero
+	res, unique, _ = r.FindNearestPointUnique(19, 19, 0)
+	assert.False(t, unique)
+	assert.Equal(t, []float64{20, 20}, []float64{res.X, res.Y})
+	// (0, 0) and (10, 0) are at the same distance
+	_, unique, _ = r.FindNearestPointUnique(5, 0, -1)
+	assert.False(t, unique)
+
+	// a single point is unique
+	res, unique, found = New().Load(FlatPoints{3, 3}).FindNearestPointUnique(0, 0, 100)
+	assert.True(t, found)
+	assert.True(t, unique)
+	assert.Equal(t, 18., res.Distance)
+	_, unique, found = New().FindNearestPointUnique(0, 0, 1)
+	assert.False(t, found)
+	assert.False(t, unique)
+
+	const size = 5000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r = New().Load(points)
+	for n := 0; n < 200; n++ {
+		x, y := rand.Float64(), rand.Float64()
+		eps := rand.Float64() * 0.01
+		distances := make([]float64, size)
+		for i := range distances {
+			px, py := points.GetPointAt(i)
+			distances[i] = (px-x)*(px-x) + (py-y)*(py-y)
+		}
+		sort.Float64s(distances)
+		res, unique, found := r.FindNearestPointUnique(x, y, eps)
+		assert.True(t, found)
+		assert.Equal(t, distances[0], res.Distance)
+		limit := math.Sqrt(distances[0]) + eps
+		assert.Equal(t, distances[1] > limit*limit, unique)
+	}
+}
+
+func TestSimpleRTree_FindNearestPointTransformed(t *testing.T) {
+	const size = 5000
+	// tree coordinates are the query coordinates rotated 30 degrees and shifted
+	sin, cos := math.Sincos(math.Pi / 6)
+	fwd := func(x, y float64) (float64, float64) {
+		return cos*x - sin*y + 100, sin*x + cos*y - 50
+	}
+	inv := func(x, y float64) (float64, float64) {
+		x, y = x-100, y+50
+		return cos*x + sin*y, -sin*x + cos*y
+	}
+	queryPoints := make(FlatPoints, 0, 2*size)
+	treePoints := make(FlatPoints, 0, 2*size)
+	for i := 0; i < size; i++ {
+		x, y := rand.Float64(), rand.Float64()
+		tx, ty := fwd(x, y)
+		queryPoints = append(queryPoints, x, y)
+		treePoints = append(treePoints, tx, ty)
+	}
+	r := New().Load(treePoints)
+	target := New().Load(queryPoints)
+	for n := 0; n < 200; n++ {
+		x, y := rand.Float64(), rand.Float64()
+		res, found := r.FindNearestPointTransformed(x, y, fwd, inv)
+		assert.True(t, found)
+		tx, ty := fwd(x, y)
+		px, py, d := r.FindNearestPoint(tx, ty)
+		assert.Equal(t, d, res.Distance)
+		ex, ey := inv(px, py)
+		assert.Equal(t, []float64{ex, ey}, []float64{res.X, res.Y})
+		// rotations preserve distances, so it matches a tree built in the coordinates of the query
+		qx, qy, qd := target.FindNearestPoint(x, y)
+		assert.InDelta(t, qd, res.Distance, 1e-9)
+		assert.InDelta(t, qx, res.X, 1e-9)
+		assert.InDelta(t, qy, res.Y, 1e-9)
+	}
+
+	failing := func(x, y float64) (float64, float64) {
+		return math.NaN(), y
+	}
+	_, found := r.FindNearestPointTransformed(0.5, 0.5, failing, inv)
+	assert.False(t, found)
+	_, found = r.FindNearestPointTransformed(0.5, 0.5, fwd, failing)
+	assert.False(t, found)
+	_, found = New().FindNearestPointTransformed(0.5, 0.5, fwd, inv)
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_FindNearestKInBox(t *testing.T) {
+	const size = 5000
+	points := make([]float64, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	fp := FlatPoints(points)
+	r := New().Load(fp)
+	for _, k := range []int{1, 5, 50, 10000} {
+		for n := 0; n < 200; n++ {
+			box := randomBBox(0.2)
+			x, y := rand.Float64(), rand.Float64()
+			// full nearest neighbours ordering, filtered by box
+			var expected []float64
+			for _, i := range fp.linearSearch(box) {
+				px, py := fp.GetPointAt(i)
+				expected = append(expected, (px-x)*(px-x)+(py-y)*(py-y))
+			}
+			sort.Float64s(expected)
+			if len(expected) > k {
+				expected = expected[:k]
+			}
+			results := r.FindNearestKInBox(k, x, y, box)
+			assert.Len(t, results, len(expected))
+			for j, res := range results {
+				assert.Equal(t, expected[j], res.Distance)
+				assert.True(t, box.containsPoint(res.X, res.Y))
+				px, py := fp.GetPointAt(res.Index)
+				assert.Equal(t, px, res.X)
+				assert.Equal(t, py, res.Y)
+			}
+		}
+	}
+	assert.Empty(t, r.FindNearestKInBox(0, 0.5, 0.5, BBox{0, 0, 1, 1}))
+	assert.Empty(t, r.FindNearestKInBox(3, 0.5, 0.5, BBox{2, 2, 3, 3}))
+	assert.Empty(t, New().FindNearestKInBox(3, 0.5, 0.5, BBox{0, 0, 1, 1}))
+}
+
+func TestSimpleRTree_KthNearestDistance(t *testing.T) {
+	const size = 5000
+	points := make([]float64, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	fp := FlatPoints(points)
+	r := New().Load(fp)
+	for n := 0; n < 200; n++ {
+		x, y := rand.Float64(), rand.Float64()
+		expected := make([]float64, size)
+		for i := range expected {
+			px, py := fp.GetPointAt(i)
+			expected[i] = (px-x)*(px-x) + (py-y)*(py-y)
+		}
+		sort.Float64s(expected)
+		for _, k := range []int{1, 2, 10, 100, size} {
+			d, found := r.KthNearestDistance(k, x, y)
+			assert.True(t, found)
+			assert.Equal(t, expected[k-1], d)
+		}
+	}
+	_, found := r.KthNearestDistance(size+1, 0.5, 0.5)
+	assert.False(t, found)
+	_, found = r.KthNearestDistance(0, 0.5, 0.5)
+	assert.False(t, found)
+	_, found = New().KthNearestDistance(1, 0.5, 0.5)
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_FindNearestWeighted(t *testing.T) {
+	const size = 5000
+	points := make([]float64, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	fp := FlatPoints(points)
+	r := New().Load(fp)
+	weights := make([]float64, size)
+	for i := range weights {
+		// a few high priority points and some skipped ones
+		switch rand.Intn(20) {
+		case 0:
+			weights[i] = 10
+		case 1:
+			weights[i] = 0
+		default:
+			weights[i] = 0.5 + rand.Float64()
+		}
+	}
+	weight := func(idx int) float64 {
+		return weights[idx]
+	}
+	for n := 0; n < 500; n++ {
+		x, y := rand.Float64()*1.4-0.2, rand.Float64()*1.4-0.2
+		expected := math.Inf(1)
+		for i := 0; i < fp.Len(); i++ {
+			if weights[i] <= 0 {
+				continue
+			}
+			px, py := fp.GetPointAt(i)
+			expected = math.Min(expected, math.Sqrt((px-x)*(px-x)+(py-y)*(py-y))/weights[i])
+		}
+		res, score, found := r.FindNearestWeighted(x, y, weight, 10)
+		assert.True(t, found)
+		assert.Equal(t, expected, score)
+		assert.Equal(t, math.Sqrt(res.Distance)/weights[res.Index], score)
+		px, py := fp.GetPointAt(res.Index)
+		assert.Equal(t, (px-x)*(px-x)+(py-y)*(py-y), res.Distance)
+	}
+	_, _, found := r.FindNearestWeighted(0, 0, func(int) float64 { return 0 }, 1)
+	assert.False(t, found)
+	_, _, found = r.FindNearestWeighted(0, 0, weight, 0)
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_FindNearestPointFuncEx(t *testing.T) {
+	const size = 5000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	for n := 0; n < 200; n++ {
+		x, y := rand.Float64(), rand.Float64()
+
+		// accepting every point is the nearest point
+		res, found := r.FindNearestPointFuncEx(x, y, func(idx int, d float64) Decision {
+			return Accept
+		})
+		_, _, d := r.FindNearestPoint(x, y)
+		assert.True(t, found)
+		assert.Equal(t, d, res.Distance)
+
+		// rejecting even positions is the nearest odd point
+		res, found = r.FindNearestPointFuncEx(x, y, func(idx int, d float64) Decision {
+			if idx%2 == 0 {
+				return Reject
+			}
+			return Accept
+		})
+		expected, _ := r.findNearestAccepted(x, y, func(i int, px, py, d float64) bool {
+			return i%2 == 1
+		})
+		assert.True(t, found)
+		assert.Equal(t, expected, res)
+
+		// the first point under the threshold is good enough, stop on the next call
+		const threshold = 0.01
+		var calls, accepted int
+		res, found = r.FindNearestPointFuncEx(x, y, func(idx int, d float64) Decision {
+			calls++
+			if accepted > 0 {
+				return Stop
+			}
+			if d < threshold*threshold {
+				accepted++
+				return Accept
+			}
+			return Reject
+		})
+		if found {
+			assert.True(t, res.Distance < threshold*threshold)
+			assert.Equal(t, 1, accepted)
+			assert.True(t, calls < size/10, "Search stopped early")
+		}
+	}
+	// stopping before accepting anything finds nothing
+	_, found := r.FindNearestPointFuncEx(0.5, 0.5, func(idx int, d float64) Decision {
+		return Stop
+	})
+	assert.False(t, found)
+	_, found = r.FindNearestPointFuncEx(0.5, 0.5, func(idx int, d float64) Decision {
+		return Reject
+	})
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_FindNearestApproxDepth(t *testing.T) {
+	const size = 10000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	maxHalfDiagonal := make([]float64, r.height)
+	var walk func(i, depth int)
+	walk = func(i, depth int) {
+		if depth >= r.height {
+			return
+		}
+		bbox := r.nodes[i].BBox
+		if i == 0 {
+			bbox = r.rootBBox()
+		}
+		b := bbox.ToBBox()
+		maxHalfDiagonal[depth] = math.Max(maxHalfDiagonal[depth], math.Hypot(b.MaxX-b.MinX, b.MaxY-b.MinY)/2)
+		if n := &r.nodes[i]; n.nodeType != preleaf_node {
+			for j := n.firstChildIndex(); j < n.firstChildIndex()+int(n.nChildren); j++ {
+				walk(j, depth+1)
+			}
+		}
+	}
+	walk(0, 0)
+	for n := 0; n < 200; n++ {
+		x, y := rand.Float64(), rand.Float64()
+		x1, y1, d1 := r.FindNearestPoint(x, y)
+		for _, depth := range []int{r.height, r.height + 1} {
+			res := r.FindNearestApproxDepth(x, y, depth)
+			assert.Equal(t, d1, res.Distance)
+			assert.Equal(t, [2]float64{x1, y1}, [2]float64{res.X, res.Y})
+			assert.True(t, res.Index >= 0)
+		}
+		// approximations get worse as the depth decreases, the error is bounded by the size of the cells
+		for depth := 0; depth < r.height; depth++ {
+			res := r.FindNearestApproxDepth(x, y, depth)
+			assert.Equal(t, -1, res.Index)
+			assert.Equal(t, (res.X-x)*(res.X-x)+(res.Y-y)*(res.Y-y), res.Distance)
+			// the closest cell is at most as far as the nearest point, and its center half its diagonal further
+			assert.True(t, math.Sqrt(res.Distance) <= math.Sqrt(d1)+maxHalfDiagonal[depth]+1e-12, "Depth %d", depth)
+		}
+	}
+	assert.Equal(t, -1, New().FindNearestApproxDepth(0, 0, 1).Index)
+}
+
+func TestSimpleRTree_FindNearestPointRandomized(t *testing.T) {
+	// four points at distance 1 of the origin, one slightly farther and one far
+	r := New().Load(FlatPoints{1, 0, 0, 1, -1, 0, 0, -1, 1.05, 0, 3, 3})
+	counts := make(map[[2]float64]int)
+	rng := rand.New(rand.NewSource(1))
+	const draws = 8000
+	for n := 0; n < draws; n++ {
+		res, found := r.FindNearestPointRandomized(0, 0, 0, rng)
+		assert.True(t, found)
+		assert.Equal(t, 1., res.Distance)
+		counts[[2]float64{res.X, res.Y}]++
+	}
+	assert.Len(t, counts, 4)
+	for _, c := range counts {
+		assert.InDelta(t, draws/4, c, draws/4*0.1)
+	}
+	// with a 10% tolerance 1.05 is a candidate too
+	counts = make(map[[2]float64]int)
+	for n := 0; n < draws; n++ {
+		res, _ := r.FindNearestPointRandomized(0, 0, 0.1, rng)
+		counts[[2]float64{res.X, res.Y}]++
+	}
+	assert.Len(t, counts, 5)
+	assert.InDelta(t, draws/5, counts[[2]float64{1.05, 0}], draws/5*0.1)
+
+	// results are reproducible with the same seed
+	sequence := func() []int {
+		rng := rand.New(rand.NewSource(42))
+		var indexes []int
+		for n := 0; n < 20; n++ {
+			res, _ := r.FindNearestPointRandomized(0, 0, 0.1, rng)
+			indexes = append(indexes, res.Index)
+		}
+		return indexes
+	}
+	assert.Equal(t, sequence(), sequence())
+
+	// without ties it is the nearest point
+	res, found := r.FindNearestPointRandomized(2.9, 3, -1, rng)
+	assert.True(t, found)
+	assert.Equal(t, []float64{3, 3}, []float64{res.X, res.Y})
+	_, found = New().FindNearestPointRandomized(0, 0, 0, rng)
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_FindNearestPointProgressive(t *testing.T) {
+	points := generateDataset(clusteredDataset, 20000, rand.Int63())
+	r := New().Load(points)
+	for n := 0; n < 200; n++ {
+		x, y := rand.Float64(), rand.Float64()
+		var improvements []Result
+		res, found := r.FindNearestPointProgressive(x, y, func(res Result) {
+			improvements = append(improvements, res)
+		})
+		assert.True(t, found)
+		_, _, d := points.linearClosestPoint(x, y)
+		assert.Equal(t, d, res.Distance)
+		assert.NotEmpty(t, improvements)
+		assert.Equal(t, res, improvements[len(improvements)-1])
+		for i := 1; i < len(improvements); i++ {
+			assert.LessOrEqual(t, improvements[i].Distance, improvements[i-1].Distance)
+		}
+	}
+	// ties report the returned point last
+	r = New().Load(FlatPoints{1, 0, -1, 0, 0, 1, 0, -1})
+	var last Result
+	res, _ := r.FindNearestPointProgressive(0, 0, func(res Result) { last = res })
+	assert.Equal(t, res, last)
+	_, found := New().FindNearestPointProgressive(0, 0, func(Result) { t.Fail() })
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_KNearestBBox(t *testing.T) {
+	const size = 2000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	original := append(FlatPoints(nil), points...)
+	r := New().Load(points)
+	for n := 0; n < 200; n++ {
+		x, y := rand.Float64(), rand.Float64()
+		k := 1 + rand.Intn(50)
+		distances := make([]float64, original.Len())
+		for i := range distances {
+			px, py := original.GetPointAt(i)
+			distances[i] = computeLeafDistance(px, py, x, y)
+		}
+		sorted := append([]float64(nil), distances...)
+		sort.Float64s(sorted)
+		expected := BBox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+		for i, d := range distances {
+			if d <= sorted[k-1] {
+				px, py := original.GetPointAt(i)
+				expected = expected.extend(BBox{px, py, px, py})
+			}
+		}
+		box, count := r.KNearestBBox(k, x, y)
+		assert.Equal(t, k, count)
+		assert.Equal(t, expected, box)
+	}
+
+	// fewer points than k
+	r = New().Load(FlatPoints{0, 0, 1, 2, 3, 1})
+	box, count := r.KNearestBBox(10, 0, 0)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, BBox{0, 0, 3, 2}, box)
+	box, count = r.KNearestBBox(2, 0, 0)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, BBox{0, 0, 1, 2}, box)
+	r.SetEnabled(0, false)
+	_, count = r.KNearestBBox(10, 0, 0)
+	assert.Equal(t, 2, count)
+	box, count = New().KNearestBBox(3, 0, 0)
+	assert.Zero(t, count)
+	assert.Equal(t, r.BBoxOf(nil), box)
+	_, count = r.KNearestBBox(0, 0, 0)
+	assert.Zero(t, count)
+}
+
+func TestSimpleRTree_FindNearestPointExpanding(t *testing.T) {
+	const size = 5000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	for n := 0; n < 500; n++ {
+		x, y := rand.Float64()*3-1, rand.Float64()*3-1
+		x1, y1, d1 := r.FindNearestPoint(x, y)
+		res, radius, found := r.FindNearestPointExpanding(x, y, 0.001, 10)
+		assert.True(t, found)
+		assert.Equal(t, [3]float64{x1, y1, d1}, [3]float64{res.X, res.Y, res.Distance})
+		// the radius is the first step of the schedule containing the point
+		assert.True(t, radius*radius >= res.Distance)
+		if radius > 0.001 {
+			assert.True(t, (radius/2)*(radius/2) < res.Distance)
+		}
+		assert.Zero(t, math.Mod(math.Log2(radius/0.001), 1))
+
+		if math.Sqrt(d1) > 0.01 {
+			_, _, found = r.FindNearestPointExpanding(x, y, 0.001, 0.01)
+			assert.False(t, found)
+		}
+	}
+
+	// the last step is maxR even if it is not a doubling of the previous one
+	r = New().Load(FlatPoints{0, 0})
+	res, radius, found := r.FindNearestPointExpanding(3, 0, 1, 3)
+	assert.True(t, found)
+	assert.Equal(t, 3., radius)
+	assert.Equal(t, 9., res.Distance)
+	_, radius, _ = r.FindNearestPointExpanding(1, 0, 1, 3)
+	assert.Equal(t, 1., radius)
+	_, radius, _ = r.FindNearestPointExpanding(0, 1.5, 1, 3)
+	assert.Equal(t, 2., radius)
+	_, radius, _ = r.FindNearestPointExpanding(0, 1, 5, 3)
+	assert.Equal(t, 3., radius)
+	_, _, found = r.FindNearestPointExpanding(0, 0, 0, 3)
+	assert.False(t, found)
+	_, _, found = New().FindNearestPointExpanding(0, 0, 1, 3)
+	assert.False(t, found)
+}
+
+func TestSimpleRTree_FindNearestPointWarped(t *testing.T) {
+	const size = 5000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	original := append(FlatPoints(nil), points...)
+	r := New().Load(points)
+	r.SetEnabled(0, false)
+	disabledX, disabledY := r.getPointAt(0)
+	for n := 0; n < 200; n++ {
+		// rotation, anisotropic scale and shear
+		angle := rand.Float64() * math.Pi
+		sx, sy, shear := 0.1+rand.Float64()*3, 0.1+rand.Float64()*3, rand.Float64()*2-1
+		c, s := math.Cos(angle), math.Sin(angle)
+		a := [2][2]float64{{sx * c, sx * (-s + shear*c)}, {sy * s, sy * (c + shear*s)}}
+		x, y := rand.Float64()*1.4-0.2, rand.Float64()*1.4-0.2
+		expected := math.Inf(1)
+		for i := 0; i < original.Len(); i++ {
+			px, py := original.GetPointAt(i)
+			if px == disabledX && py == disabledY {
+				continue
+			}
+			dx, dy := px-x, py-y
+			wx, wy := a[0][0]*dx+a[0][1]*dy, a[1][0]*dx+a[1][1]*dy
+			expected = math.Min(expected, wx*wx+wy*wy)
+		}
+		res, found := r.FindNearestPointWarped(x, y, a)
+		assert.True(t, found)
+		assert.InDelta(t, expected, res.Distance, expected*1e-9+1e-15)
+		px, py := r.getPointAt(res.Index)
+		assert.Equal(t, [2]float64{px, py}, [2]float64{res.X, res.Y})
+	}
+
+	// the box bound is the exact minimum, sampled points of the box are never closer
+	for n := 0; n < 1000; n++ {
+		a := [2][2]float64{{rand.NormFloat64(), rand.NormFloat64()}, {rand.NormFloat64(), rand.NormFloat64()}}
+		if n%10 == 0 {
+			// singular
+			a[1] = a[0]
+		}
+		m := [3]float64{a[0][0]*a[0][0] + a[1][0]*a[1][0], a[0][0]*a[0][1] + a[1][0]*a[1][1], a[0][1]*a[0][1] + a[1][1]*a[1][1]}
+		box := randomBBox(0.5)
+		x, y := rand.Float64()*2-0.5, rand.Float64()*2-0.5
+		bound := warpedBoxDistance(VectorBBox{box.MinX, box.MinY, box.MaxX, box.MaxY}, x, y, m)
+		sampled := math.Inf(1)
+		for i := 0; i <= 64; i++ {
+			for j := 0; j <= 64; j++ {
+				px := box.MinX + (box.MaxX-box.MinX)*float64(i)/64
+				py := box.MinY + (box.MaxY-box.MinY)*float64(j)/64
+				sampled = math.Min(sampled, warpedDistance(px-x, py-y, m))
+			}
+		}
+		assert.True(t, bound <= sampled*(1+1e-12)+1e-15, "bound %v above %v", bound, sampled)
+		assert.InDelta(t, sampled, bound, 0.02*sampled+1e-3)
+	}
+
+	// identity is the euclidean distance
+	res, _ := r.FindNearestPointWarped(0.5, 0.5, [2][2]float64{{1, 0}, {0, 1}})
+	x1, y1, d1 := r.FindNearestPoint(0.5, 0.5)
+	assert.Equal(t, [3]float64{x1, y1, d1}, [3]float64{res.X, res.Y, res.Distance})
+	_, found := New().FindNearestPointWarped(0, 0, [2][2]float64{{1, 0}, {0, 1}})
+	assert.False(t, found)
+}
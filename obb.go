@@ -0,0 +1,133 @@
+package SimpleRTree
+
+import "math"
+
+// orientedBox is a rectangle aligned with the unit vector (ux, uy) and its perpendicular (-uy, ux), centered on
+// (cx, cy) with half extents hu and hv along those axes
+type orientedBox struct {
+	cx, cy, ux, uy, hu, hv float64
+}
+
+// computeOrientedBoxes sets the oriented box of every leaf, see Options.OrientedLeaves. Boxes are aligned with the
+// principal axis of the points of the leaf, so that leaves of points along a diagonal get thin boxes instead of
+// squares. Entries of interior nodes are left empty, their bbox is the union of their children
+func (r *SimpleRTree) computeOrientedBoxes() {
+	if cap(r.orientedBoxes) >= len(r.nodes) {
+		r.orientedBoxes = r.orientedBoxes[:len(r.nodes)]
+	} else {
+		r.orientedBoxes = make([]orientedBox, len(r.nodes))
+	}
+	for i := range r.nodes {
+		n := &r.nodes[i]
+		if n.nodeType != preleaf_node {
+			r.orientedBoxes[i] = orientedBox{}
+			continue
+		}
+		r.orientedBoxes[i] = r.leafOrientedBox(n.firstPointIndex(), n.firstPointIndex()+int(n.nChildren))
+	}
+}
+
+// leafOrientedBox returns the oriented box of the points in the range [start, end)
+func (r *SimpleRTree) leafOrientedBox(start, end int) orientedBox {
+	var mx, my float64
+	for i := start; i < end; i++ {
+		x, y := r.getPointAt(i)
+		mx, my = mx+x, my+y
+	}
+	mx, my = mx/float64(end-start), my/float64(end-start)
+	var sxx, syy, sxy float64
+	for i := start; i < end; i++ {
+		x, y := r.getPointAt(i)
+		dx, dy := x-mx, y-my
+		sxx, syy, sxy = sxx+dx*dx, syy+dy*dy, sxy+dx*dy
+	}
+	angle := math.Atan2(2*sxy, sxx-syy) / 2
+	ux, uy := math.Cos(angle), math.Sin(angle)
+
+	minU, minV := math.Inf(1), math.Inf(1)
+	maxU, maxV := math.Inf(-1), math.Inf(-1)
+	for i := start; i < end; i++ {
+		x, y := r.getPointAt(i)
+		dx, dy := x-mx, y-my
+		u, v := dx*ux+dy*uy, dy*ux-dx*uy
+		minU, maxU = math.Min(minU, u), math.Max(maxU, u)
+		minV, maxV = math.Min(minV, v), math.Max(maxV, v)
+	}
+	cu, cv := (minU+maxU)/2, (minV+maxV)/2
+	box := orientedBox{
+		cx: mx + cu*ux - cv*uy,
+		cy: my + cu*uy + cv*ux,
+		ux: ux,
+		uy: uy,
+		hu: (maxU - minU) / 2,
+		hv: (maxV - minV) / 2,
+	}
+	// projections round, boxes are grown slightly so that points on their sides are never left outside
+	eps := 1e-9 * (math.Abs(box.cx) + math.Abs(box.cy) + box.hu + box.hv)
+	box.hu += eps
+	box.hv += eps
+	return box
+}
+
+// distance returns the distance squared between (x, y) and the closest point of the box, 0 inside it
+func (b *orientedBox) distance(x, y float64) float64 {
+	dx, dy := x-b.cx, y-b.cy
+	du := math.Max(0, math.Abs(dx*b.ux+dy*b.uy)-b.hu)
+	dv := math.Max(0, math.Abs(dy*b.ux-dx*b.uy)-b.hv)
+	return du*du + dv*dv
+}
+
+// intersects returns whether the box intersects bbox, boundary included. Two rectangles are disjoint if and only if
+// their projections on one of their axes are
+func (b *orientedBox) intersects(bbox BBox) bool {
+	au, av := math.Abs(b.ux), math.Abs(b.uy)
+	// axes of bbox
+	rx, ry := b.hu*au+b.hv*av, b.hu*av+b.hv*au
+	if b.cx+rx < bbox.MinX || b.cx-rx > bbox.MaxX || b.cy+ry < bbox.MinY || b.cy-ry > bbox.MaxY {
+		return false
+	}
+	// axes of the oriented box
+	wx, wy := (bbox.MaxX-bbox.MinX)/2, (bbox.MaxY-bbox.MinY)/2
+	dx, dy := (bbox.MinX+bbox.MaxX)/2-b.cx, (bbox.MinY+bbox.MaxY)/2-b.cy
+	if math.Abs(dx*b.ux+dy*b.uy) > b.hu+wx*au+wy*av {
+		return false
+	}
+	return math.Abs(dy*b.ux-dx*b.uy) <= b.hv+wx*av+wy*au
+}
+
+// findNearestTight implements findNearestPointWithin for trees with oriented leaves or bounding circles. Nodes are
+// pruned with the largest of the distances to their bbox, to their oriented box if they are leaves and to their
+// bounding circle
+func (r *SimpleRTree) findNearestTight(x, y, dsquared float64, visited *int) (res Result, found bool) {
+	r.bestFirstNodes(
+		func(i, height int) (float64, bool) {
+			if visited != nil {
+				*visited++
+			}
+			mind, _ := computeDistances(r.nodes[i].BBox, x, y, r.insideFactor)
+			if r.orientedBoxes != nil && r.nodes[i].nodeType == preleaf_node {
+				mind = math.Max(mind, r.orientedBoxes[i].distance(x, y))
+			}
+			if r.circles != nil {
+				mind = math.Max(mind, r.circles[i].distance(x, y))
+			}
+			return mind, mind <= dsquared
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if visited != nil {
+				*visited++
+			}
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return d, d <= dsquared
+		},
+		func(i int, px, py, d float64) bool {
+			res = Result{Index: i, X: px, Y: py, Distance: d}
+			found = true
+			return false
+		},
+	)
+	return
+}
@@ -0,0 +1,135 @@
+package SimpleRTree
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// diagonalPoints returns points scattered along a few diagonal lines, where axis aligned boxes of leaves are squares
+func diagonalPoints(n int) FlatPoints {
+	points := make(FlatPoints, 0, 2*n)
+	for i := 0; i < n; i++ {
+		t := rand.Float64()
+		offset := float64(rand.Intn(5)) / 5
+		noise := rand.NormFloat64() * 1e-4
+		points = append(points, t+noise, math.Mod(t+offset, 1)-noise)
+	}
+	return points
+}
+
+// polygonDistance returns the distance squared between (x, y) and the convex polygon of corners in counter clockwise
+// order, 0 inside it
+func polygonDistance(corners [4][2]float64, x, y float64) float64 {
+	inside := true
+	d := math.Inf(1)
+	for i := range corners {
+		a, b := corners[i], corners[(i+1)%4]
+		ex, ey := b[0]-a[0], b[1]-a[1]
+		if ex*(y-a[1])-ey*(x-a[0]) < 0 {
+			inside = false
+		}
+		t := math.Max(0, math.Min(1, ((x-a[0])*ex+(y-a[1])*ey)/(ex*ex+ey*ey)))
+		d = math.Min(d, computeLeafDistance(a[0]+t*ex, a[1]+t*ey, x, y))
+	}
+	if inside {
+		return 0
+	}
+	return d
+}
+
+func segmentsCross(a, b, c, d [2]float64) bool {
+	side := func(p, q, r [2]float64) float64 {
+		return (q[0]-p[0])*(r[1]-p[1]) - (q[1]-p[1])*(r[0]-p[0])
+	}
+	return side(a, b, c)*side(a, b, d) < 0 && side(c, d, a)*side(c, d, b) < 0
+}
+
+func TestSimpleRTree_OrientedBox(t *testing.T) {
+	for n := 0; n < 200; n++ {
+		b := orientedBox{cx: rand.Float64(), cy: rand.Float64(), hu: rand.Float64() * 0.3, hv: rand.Float64() * 0.1}
+		angle := rand.Float64() * 2 * math.Pi
+		b.ux, b.uy = math.Cos(angle), math.Sin(angle)
+		var corners [4][2]float64
+		for i, s := range [4][2]float64{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+			corners[i] = [2]float64{
+				b.cx + s[0]*b.hu*b.ux - s[1]*b.hv*b.uy,
+				b.cy + s[0]*b.hu*b.uy + s[1]*b.hv*b.ux,
+			}
+		}
+		for m := 0; m < 50; m++ {
+			x, y := rand.Float64()*1.6-0.3, rand.Float64()*1.6-0.3
+			assert.InDelta(t, polygonDistance(corners, x, y), b.distance(x, y), 1e-12)
+		}
+		for m := 0; m < 50; m++ {
+			box := randomBBox(0.3)
+			boxCorners := [4][2]float64{{box.MinX, box.MinY}, {box.MaxX, box.MinY}, {box.MaxX, box.MaxY}, {box.MinX, box.MaxY}}
+			expected := false
+			for i := range corners {
+				expected = expected || box.containsPoint(corners[i][0], corners[i][1]) ||
+					polygonDistance(corners, boxCorners[i][0], boxCorners[i][1]) == 0
+				for j := range boxCorners {
+					expected = expected || segmentsCross(corners[i], corners[(i+1)%4], boxCorners[j], boxCorners[(j+1)%4])
+				}
+			}
+			assert.Equal(t, expected, b.intersects(box), "%+v %+v", b, box)
+		}
+	}
+}
+
+func TestSimpleRTree_OrientedLeaves(t *testing.T) {
+	points := diagonalPoints(20000)
+	aabb := New().Load(append(FlatPoints(nil), points...))
+	obb := NewWithOptions(Options{OrientedLeaves: true}).Load(append(FlatPoints(nil), points...))
+	assertBBoxesContainChildren(t, obb)
+	for i := range obb.nodes {
+		n := &obb.nodes[i]
+		if n.nodeType != preleaf_node {
+			continue
+		}
+		for j := n.firstPointIndex(); j < n.firstPointIndex()+int(n.nChildren); j++ {
+			x, y := obb.getPointAt(j)
+			assert.Zero(t, obb.orientedBoxes[i].distance(x, y))
+		}
+	}
+
+	visitedAABB, visitedOBB := 0, 0
+	for n := 0; n < 1000; n++ {
+		x, y := rand.Float64(), rand.Float64()
+		_, _, d := points.linearClosestPoint(x, y)
+		aabb.findNearestPointWithin(x, y, math.Inf(1), &visitedAABB)
+		res, found := obb.findNearestPointWithin(x, y, math.Inf(1), &visitedOBB)
+		assert.True(t, found)
+		assert.Equal(t, d, res.Distance)
+		px, py := obb.getPointAt(res.Index)
+		assert.Equal(t, [2]float64{px, py}, [2]float64{res.X, res.Y})
+
+		box := randomBBox(0.1)
+		assert.Equal(t, sortedPoints(aabb.SearchInto(box, nil)), sortedPoints(obb.SearchInto(box, nil)))
+	}
+	assert.True(t, visitedOBB < visitedAABB, "oriented leaves visit %d nodes and points, bboxes %d", visitedOBB, visitedAABB)
+
+	_, found := obb.findNearestPointWithin(2, 2, 0.01, nil)
+	assert.False(t, found)
+	obb.SetEnabled(0, false)
+	x, y := obb.getPointAt(0)
+	res, _ := obb.findNearestPointWithin(x, y, math.Inf(1), nil)
+	assert.NotEqual(t, 0, res.Index)
+}
+
+func BenchmarkSimpleRTree_OrientedLeaves(b *testing.B) {
+	points := diagonalPoints(100000)
+	for _, oriented := range []bool{false, true} {
+		r := NewWithOptions(Options{OrientedLeaves: oriented}).Load(append(FlatPoints(nil), points...))
+		b.Run(fmt.Sprintf("Oriented=%v", oriented), func(b *testing.B) {
+			visited := 0
+			for n := 0; n < b.N; n++ {
+				r.findNearestPointWithin(rand.Float64(), rand.Float64(), math.Inf(1), &visited)
+			}
+			b.ReportMetric(float64(visited)/float64(b.N), "visits/op")
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package SimpleRTree
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Validate returns an error describing the first invalid option or combination of options found, or nil if there
+// is none. Zero values are always valid, they select the defaults. Load calls it and panics if the options are
+// invalid, callers can call it before to handle the error themselves
+func (o Options) Validate() error {
+	if o.MAX_ENTRIES != 0 && (o.MAX_ENTRIES < 2 || o.MAX_ENTRIES > MAX_POSSIBLE_SIZE) {
+		return fmt.Errorf("%w %d, it must be between 2 and %d", ErrInvalidMaxEntries, o.MAX_ENTRIES, MAX_POSSIBLE_SIZE)
+	}
+	if o.TreeType != STR && o.TreeType != HILBERT {
+		return fmt.Errorf("unknown tree type %d", o.TreeType)
+	}
+	if o.QueryAspectRatio < 0 || !isFinite(o.QueryAspectRatio) {
+		return fmt.Errorf("invalid QueryAspectRatio %v, it must be positive and finite", o.QueryAspectRatio)
+	}
+	if o.QueryAspectRatio != 0 && o.TreeType != STR {
+		return errors.New("QueryAspectRatio is only used by STR trees")
+	}
+	if o.QueryCache < 0 {
+		return fmt.Errorf("invalid QueryCache %d, it must not be negative", o.QueryCache)
+	}
+	if o.QueryCacheQuantum < 0 || !isFinite(o.QueryCacheQuantum) {
+		return fmt.Errorf("invalid QueryCacheQuantum %v, it must be positive and finite", o.QueryCacheQuantum)
+	}
+	if o.QueryCacheQuantum != 0 && o.QueryCache == 0 {
+		return errors.New("QueryCacheQuantum is set but QueryCache is not")
+	}
+	if math.IsNaN(o.InsideEpsilon) {
+		return errors.New("InsideEpsilon is NaN")
+	}
+	if !isFinite(o.WithinEpsilon) {
+		return fmt.Errorf("invalid WithinEpsilon %v, it must be finite", o.WithinEpsilon)
+	}
+	if o.DistanceUnit > HaversineComparison {
+		return fmt.Errorf("unknown DistanceUnit %d", o.DistanceUnit)
+	}
+	if o.DownsampleRule > DownsampleCentroid {
+		return fmt.Errorf("unknown DownsampleRule %d", o.DownsampleRule)
+	}
+	if o.EmptyResults > EmptyResultsMask {
+		return fmt.Errorf("unknown EmptyResults %d", o.EmptyResults)
+	}
+	if o.MaxHeight < 0 {
+		return fmt.Errorf("invalid MaxHeight %d, it must not be negative", o.MaxHeight)
+	}
+	if o.LinearScanThreshold > maxLeafSize+1 {
+		return fmt.Errorf("invalid LinearScanThreshold %d, it cannot exceed %d", o.LinearScanThreshold, maxLeafSize+1)
+	}
+	if o.MaxBuildMemory < 0 {
+		return fmt.Errorf("invalid MaxBuildMemory %d, it must not be negative", o.MaxBuildMemory)
+	}
+	return nil
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
@@ -0,0 +1,57 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestOptions_Validate(t *testing.T) {
+	valid := []Options{
+		{},
+		{MAX_ENTRIES: 2},
+		{MAX_ENTRIES: MAX_POSSIBLE_SIZE, TreeType: HILBERT},
+		{QueryAspectRatio: 4},
+		{QueryCache: 10, QueryCacheQuantum: 0.1},
+		{InsideEpsilon: -1, WithinEpsilon: -0.1},
+		{DistanceUnit: HaversineComparison, DownsampleRule: DownsampleCentroid, EmptyResults: EmptyResultsMask},
+		{MaxHeight: 3, MaxBuildMemory: 1 << 20},
+	}
+	for _, o := range valid {
+		assert.NoError(t, o.Validate(), "%+v", o)
+	}
+	testCases := []struct {
+		options Options
+		message string
+	}{
+		{Options{MAX_ENTRIES: 1}, "invalid MAX_ENTRIES 1, it must be between 2 and 9"},
+		{Options{MAX_ENTRIES: -3}, "invalid MAX_ENTRIES -3, it must be between 2 and 9"},
+		{Options{MAX_ENTRIES: MAX_POSSIBLE_SIZE + 1}, "invalid MAX_ENTRIES 10, it must be between 2 and 9"},
+		{Options{TreeType: 5}, "unknown tree type 5"},
+		{Options{QueryAspectRatio: -1}, "invalid QueryAspectRatio -1, it must be positive and finite"},
+		{Options{QueryAspectRatio: math.Inf(1)}, "invalid QueryAspectRatio +Inf, it must be positive and finite"},
+		{Options{QueryAspectRatio: 2, TreeType: HILBERT}, "QueryAspectRatio is only used by STR trees"},
+		{Options{QueryCache: -1}, "invalid QueryCache -1, it must not be negative"},
+		{Options{QueryCache: 1, QueryCacheQuantum: math.NaN()}, "invalid QueryCacheQuantum NaN, it must be positive and finite"},
+		{Options{QueryCacheQuantum: 0.1}, "QueryCacheQuantum is set but QueryCache is not"},
+		{Options{InsideEpsilon: math.NaN()}, "InsideEpsilon is NaN"},
+		{Options{WithinEpsilon: math.Inf(-1)}, "invalid WithinEpsilon -Inf, it must be finite"},
+		{Options{DistanceUnit: 7}, "unknown DistanceUnit 7"},
+		{Options{DownsampleRule: 7}, "unknown DownsampleRule 7"},
+		{Options{EmptyResults: 3}, "unknown EmptyResults 3"},
+		{Options{MaxHeight: -1}, "invalid MaxHeight -1, it must not be negative"},
+		{Options{MaxBuildMemory: -1}, "invalid MaxBuildMemory -1, it must not be negative"},
+	}
+	for _, tc := range testCases {
+		assert.EqualError(t, tc.options.Validate(), tc.message)
+	}
+	assert.ErrorIs(t, Options{MAX_ENTRIES: 1}.Validate(), ErrInvalidMaxEntries)
+
+	// Load panics with the error
+	assert.PanicsWithError(t, "unknown tree type 5", func() {
+		NewWithOptions(Options{TreeType: 5}).Load(FlatPoints{0, 0, 1, 1})
+	})
+	assert.NotPanics(t, func() {
+		NewWithOptions(Options{MAX_ENTRIES: 2}).Load(FlatPoints{0, 0, 1, 1})
+	})
+}
@@ -0,0 +1,140 @@
+package SimpleRTree
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+)
+
+// OutOfCoreBuilder builds a tree over a dataset that does not fit in memory, one chunk at a time. Each chunk is
+// loaded into its own tree and written to dir with MarshalBinary, only the bbox of its points is kept in memory.
+// Chunks can overlap, but queries prune better when each chunk covers a compact region, for example tiles of the data
+type OutOfCoreBuilder struct {
+	dir     string
+	options Options
+	chunks  []outOfCoreChunk
+}
+
+// outOfCoreChunk is a chunk written to disk and the bbox of its points
+type outOfCoreChunk struct {
+	path string
+	bbox VectorBBox
+}
+
+// OutOfCoreRTree queries the chunks written by an OutOfCoreBuilder. Chunks are read from disk when a query cannot
+// prune them with their bboxes and dropped after the query, so memory use is bounded by the size of a chunk.
+// It is safe for concurrent use
+type OutOfCoreRTree struct {
+	options Options
+	chunks  []outOfCoreChunk
+	loads   int64 // chunks read from disk, for tests
+}
+
+// NewOutOfCoreBuilder creates a builder writing its chunks into dir, which must exist. Options are used to build
+// every chunk
+func NewOutOfCoreBuilder(dir string, o Options) *OutOfCoreBuilder {
+	return &OutOfCoreBuilder{dir: dir, options: o}
+}
+
+// AddChunk builds a tree with points and writes it to disk. As in Load points are reordered, positions in results
+// refer to the reordered chunk. Empty chunks are ignored
+func (b *OutOfCoreBuilder) AddChunk(points FlatPoints) error {
+	if err := checkPoints(points); err != nil {
+		return err
+	}
+	if points.Len() == 0 {
+		return nil
+	}
+	r := NewWithOptions(b.options).Load(points)
+	data, err := r.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(b.dir, fmt.Sprintf("chunk-%06d.srtr", len(b.chunks)))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	b.chunks = append(b.chunks, outOfCoreChunk{path: path, bbox: r.rootBBox()})
+	return nil
+}
+
+// Finish returns the tree to query the chunks added so far
+func (b *OutOfCoreBuilder) Finish() *OutOfCoreRTree {
+	return &OutOfCoreRTree{options: b.options, chunks: append([]outOfCoreChunk{}, b.chunks...)}
+}
+
+// Len returns the number of chunks
+func (t *OutOfCoreRTree) Len() int {
+	return len(t.chunks)
+}
+
+// FindNearestPoint returns the closest point to x and y among all the chunks, together with the number of its chunk,
+// in the order they were added. Chunks are visited in increasing distance to their bbox, and the ones farther than
+// the closest point found so far are not read
+func (t *OutOfCoreRTree) FindNearestPoint(x, y float64) (chunk int, res Result, found bool, err error) {
+	order := make([]int, len(t.chunks))
+	distances := make([]float64, len(t.chunks))
+	for i := range t.chunks {
+		order[i] = i
+		distances[i], _ = computeDistances(t.chunks[i].bbox, x, y, 1)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return distances[order[i]] < distances[order[j]]
+	})
+	best := math.Inf(1)
+	for _, i := range order {
+		if found && distances[i] > best {
+			break
+		}
+		r, err := t.loadChunk(i)
+		if err != nil {
+			return 0, Result{}, false, err
+		}
+		if chunkRes, ok := r.findNearestPointWithin(x, y, math.Inf(1), nil); ok && (!found || chunkRes.Distance < best) {
+			chunk, res, found, best = i, chunkRes, true, chunkRes.Distance
+		}
+	}
+	if found && t.options.RobustDistance {
+		res.Distance = robustSquaredDistance(res.X, res.Y, x, y)
+	}
+	return
+}
+
+// Search returns the points of every chunk inside box, boundary included, grouped by chunk number.
+// Only chunks whose bbox intersects box are read
+func (t *OutOfCoreRTree) Search(box BBox) (map[int][]Result, error) {
+	results := make(map[int][]Result)
+	for i := range t.chunks {
+		if !box.intersects(t.chunks[i].bbox.ToBBox()) {
+			continue
+		}
+		r, err := t.loadChunk(i)
+		if err != nil {
+			return nil, err
+		}
+		if rs := r.SearchPoints(box); len(rs) > 0 {
+			results[i] = rs
+		}
+	}
+	return results, nil
+}
+
+// loadChunk reads chunk i from disk
+func (t *OutOfCoreRTree) loadChunk(i int) (*SimpleRTree, error) {
+	data, err := os.ReadFile(t.chunks[i].path)
+	if err != nil {
+		return nil, err
+	}
+	o := t.options
+	o.RTreePool = nil
+	o.QueryCache = 0
+	r := NewWithOptions(o)
+	if err := r.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("chunk %d: %w", i, err)
+	}
+	atomic.AddInt64(&t.loads, 1)
+	return r, nil
+}
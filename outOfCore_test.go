@@ -0,0 +1,81 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"sort"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSimpleRTree_OutOfCore(t *testing.T) {
+	const chunks, size = 4, 2000
+	builder := NewOutOfCoreBuilder(t.TempDir(), Options{})
+	chunkPoints := make([]FlatPoints, chunks)
+	var all FlatPoints
+	for c := 0; c < chunks; c++ {
+		// chunks are vertical strips, the last one overlaps the others
+		points := make(FlatPoints, 0, size*2)
+		for i := 0; i < size; i++ {
+			x := float64(c) + rand.Float64()
+			if c == chunks-1 {
+				x = rand.Float64() * chunks
+			}
+			points = append(points, x, rand.Float64())
+		}
+		all = append(all, points...)
+		assert.NoError(t, builder.AddChunk(points))
+		chunkPoints[c] = points
+	}
+	assert.NoError(t, builder.AddChunk(nil))
+	assert.ErrorIs(t, builder.AddChunk(FlatPoints{1}), ErrOddPointsLength)
+	tree := builder.Finish()
+	assert.Equal(t, chunks, tree.Len())
+	full := New().Load(append(FlatPoints{}, all...))
+
+	for n := 0; n < 200; n++ {
+		x, y := rand.Float64()*(chunks+1)-0.5, rand.Float64()*1.5-0.25
+		chunk, res, found, err := tree.FindNearestPoint(x, y)
+		assert.NoError(t, err)
+		assert.True(t, found)
+		_, _, d := full.FindNearestPoint(x, y)
+		assert.Equal(t, d, res.Distance)
+		px, py := chunkPoints[chunk].GetPointAt(res.Index)
+		assert.Equal(t, []float64{px, py}, []float64{res.X, res.Y})
+	}
+
+	// a query inside the first strip never reads the second and third ones
+	loads := atomic.LoadInt64(&tree.loads)
+	_, _, _, err := tree.FindNearestPoint(0.5, 0.5)
+	assert.NoError(t, err)
+	assert.True(t, atomic.LoadInt64(&tree.loads)-loads <= 2)
+
+	box := BBox{0.5, 0.2, 1.5, 0.4}
+	results, err := tree.Search(box)
+	assert.NoError(t, err)
+	assert.NotContains(t, results, 2)
+	var found, expected [][2]float64
+	for chunk, rs := range results {
+		for _, res := range rs {
+			px, py := chunkPoints[chunk].GetPointAt(res.Index)
+			assert.Equal(t, []float64{px, py}, []float64{res.X, res.Y})
+			found = append(found, [2]float64{res.X, res.Y})
+		}
+	}
+	for _, i := range full.Search(box) {
+		x, y := full.getPointAt(i)
+		expected = append(expected, [2]float64{x, y})
+	}
+	less := func(ps [][2]float64) func(i, j int) bool {
+		return func(i, j int) bool {
+			return ps[i][0] < ps[j][0] || ps[i][0] == ps[j][0] && ps[i][1] < ps[j][1]
+		}
+	}
+	sort.Slice(found, less(found))
+	sort.Slice(expected, less(expected))
+	assert.Equal(t, expected, found)
+
+	_, _, found2, err := NewOutOfCoreBuilder(t.TempDir(), Options{}).Finish().FindNearestPoint(0, 0)
+	assert.NoError(t, err)
+	assert.False(t, found2)
+}
@@ -0,0 +1,42 @@
+package SimpleRTree
+
+import "math"
+
+// SearchPolygon returns the indexes of the points inside polygon, given as its vertices in order.
+// Polygons are closed implicitly, the last vertex is joined to the first one, repeating the first vertex at the end
+// makes no difference. Inside is decided with the even-odd rule, so the areas of self intersecting polygons that are
+// covered an even number of times are outside. Points exactly on an edge may be in or out.
+// Polygons with less than 3 vertices contain no points.
+// Nodes are pruned with the bbox of the polygon. Order of the results is not specified
+func (r *SimpleRTree) SearchPolygon(polygon FlatPoints) []int {
+	var results []int
+	if polygon.Len() < 3 {
+		return results
+	}
+	box := BBox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for i := 0; i < polygon.Len(); i++ {
+		x, y := polygon.GetPointAt(i)
+		box = box.extend(BBox{x, y, x, y})
+	}
+	r.search(box, func(i int, x, y float64) {
+		if pointInPolygon(polygon, x, y) {
+			results = append(results, i)
+		}
+	})
+	return results
+}
+
+// pointInPolygon tells whether (x, y) is inside polygon with the even-odd rule, counting the edges crossed by a
+// ray from the point towards +x
+func pointInPolygon(polygon FlatPoints, x, y float64) bool {
+	inside := false
+	n := polygon.Len()
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		xi, yi := polygon.GetPointAt(i)
+		xj, yj := polygon.GetPointAt(j)
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,57 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func (fp FlatPoints) linearSearchPolygon(polygon FlatPoints) []int {
+	var indexes []int
+	for i := 0; i < fp.Len(); i++ {
+		if pointInPolygon(polygon, fp[2*i], fp[2*i+1]) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func TestSimpleRTree_SearchPolygon(t *testing.T) {
+	const size = 20000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	for n := 0; n < 50; n++ {
+		// random star shaped polygons, and random vertices which self intersect
+		cx, cy := rand.Float64(), rand.Float64()
+		var star, random FlatPoints
+		vertices := 3 + rand.Intn(10)
+		for i := 0; i < vertices; i++ {
+			angle := 2 * math.Pi * float64(i) / float64(vertices)
+			radius := 0.05 + rand.Float64()*0.3
+			star = append(star, cx+radius*math.Cos(angle), cy+radius*math.Sin(angle))
+			random = append(random, rand.Float64(), rand.Float64())
+		}
+		for _, polygon := range []FlatPoints{star, random} {
+			results := r.SearchPolygon(polygon)
+			sort.Ints(results)
+			assert.Equal(t, points.linearSearchPolygon(polygon), results)
+			// closing the polygon explicitly does not change the results
+			closed := append(append(FlatPoints(nil), polygon...), polygon[0], polygon[1])
+			closedResults := r.SearchPolygon(closed)
+			sort.Ints(closedResults)
+			assert.Equal(t, results, closedResults)
+		}
+	}
+
+	square := New().Load(FlatPoints{0.5, 0.5, 2, 2, 1.5, 0.5})
+	assert.Equal(t, []int{0}, square.SearchPolygon(FlatPoints{0, 0, 1, 0, 1, 1, 0, 1}))
+	// the square traced twice covers its area twice, which is outside with the even-odd rule
+	assert.Empty(t, square.SearchPolygon(FlatPoints{0, 0, 1, 0, 1, 1, 0, 1, 0, 0, 1, 0, 1, 1, 0, 1}))
+	assert.Empty(t, r.SearchPolygon(FlatPoints{0, 0, 1, 1}))
+	assert.Empty(t, New().SearchPolygon(FlatPoints{0, 0, 1, 0, 1, 1}))
+}
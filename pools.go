@@ -0,0 +1,49 @@
+package SimpleRTree
+
+import (
+	"runtime"
+	"sync"
+)
+
+// WarmPools allocates up front the buffers that queries take from the pools of the tree, a search queue per
+// processor, or the single queue of UnsafeConcurrencyMode, and one queue of Options.NewQueue if set, so that first
+// queries do not allocate them. Custom queues can still allocate as they grow. It is meant for benchmarks and
+// latency sensitive services that want reproducible conditions. Pools are emptied by the garbage collector, buffers
+// dropped by it are allocated again on demand. It is not safe to call concurrently with queries
+func (r *SimpleRTree) WarmPools() {
+	if !r.built || len(r.nodes) == 0 {
+		return
+	}
+	if r.options.UnsafeConcurrencyMode {
+		if size := r.height * r.options.MAX_ENTRIES; cap(r.unsafeQueue) < size {
+			r.unsafeQueue = make(searchQueue, size)
+		}
+	} else {
+		queues := make([]interface{}, runtime.GOMAXPROCS(0))
+		for i := range queues {
+			queues[i] = r.queuePool.Get()
+		}
+		for _, q := range queues {
+			r.queuePool.Put(q)
+		}
+	}
+	if r.options.NewQueue != nil {
+		if r.options.UnsafeConcurrencyMode {
+			if r.unsafeCustomQueue == nil {
+				r.unsafeCustomQueue = r.options.NewQueue()
+			}
+		} else {
+			r.customQueuePool.Put(r.customQueuePool.Get())
+		}
+	}
+}
+
+// ReleasePools drops the buffers held by the pools of the tree, so that their memory can be reclaimed and the next
+// queries start cold, as right after Load. Queries allocate them again on demand, call WarmPools to allocate them
+// up front. It is not safe to call concurrently with queries
+func (r *SimpleRTree) ReleasePools() {
+	r.queuePool = sync.Pool{New: r.queuePool.New}
+	r.customQueuePool = sync.Pool{New: r.customQueuePool.New}
+	r.unsafeQueue = nil
+	r.unsafeCustomQueue = nil
+}
@@ -0,0 +1,53 @@
+package SimpleRTree
+
+import (
+	"math/rand"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRTree_WarmPools(t *testing.T) {
+	// the garbage collector empties pools
+	defer debug.SetGCPercent(debug.SetGCPercent(-1))
+	points := generateDataset(uniformDataset, 5000, 1)
+	for _, options := range []Options{{}, {UnsafeConcurrencyMode: true}} {
+		// AllocsPerRun makes a first run to warm up, every run queries a different tree so that each query is the
+		// first one after WarmPools
+		const runs = 10
+		trees := make([]*SimpleRTree, runs+1)
+		for i := range trees {
+			trees[i] = NewWithOptions(options).Load(append(FlatPoints(nil), points...))
+			trees[i].ReleasePools()
+			trees[i].WarmPools()
+		}
+		next := 0
+		allocs := testing.AllocsPerRun(runs, func() {
+			trees[next].FindNearestPoint(rand.Float64(), rand.Float64())
+			next++
+		})
+		assert.Zero(t, allocs, "%+v", options)
+
+		// released pools allocate again, and results do not change
+		r := trees[0]
+		x, y := rand.Float64(), rand.Float64()
+		x1, y1, d1 := r.FindNearestPoint(x, y)
+		allocs = testing.AllocsPerRun(runs, func() {
+			r.ReleasePools()
+			r.FindNearestPoint(rand.Float64(), rand.Float64())
+		})
+		assert.NotZero(t, allocs, "%+v", options)
+		x2, y2, d2 := r.FindNearestPoint(x, y)
+		assert.Equal(t, [3]float64{x1, y1, d1}, [3]float64{x2, y2, d2})
+	}
+	// custom queues are created again after a release
+	r := NewWithOptions(Options{NewQueue: newRadixQueue}).Load(append(FlatPoints(nil), points...))
+	r.WarmPools()
+	x1, y1, d1 := r.FindNearestPoint(0.5, 0.5)
+	r.ReleasePools()
+	x2, y2, d2 := r.FindNearestPoint(0.5, 0.5)
+	assert.Equal(t, [3]float64{x1, y1, d1}, [3]float64{x2, y2, d2})
+	New().WarmPools()
+	New().ReleasePools()
+}
@@ -0,0 +1,63 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestSimpleRTree_Prefetch(t *testing.T) {
+	New().Prefetch()
+	const size = 1000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	r.Prefetch()
+	ip := make(IntPoints, size*2)
+	for i := range ip {
+		ip[i] = rand.Int31n(1000)
+	}
+	ri := New().LoadInterface(ip)
+	ri.Prefetch()
+	for i := 0; i < 100; i++ {
+		x, y := rand.Float64(), rand.Float64()
+		_, _, d := r.FindNearestPoint(x, y)
+		_, _, expected := points.linearClosestPoint(x, y)
+		assert.Equal(t, expected, d)
+	}
+}
+
+func BenchmarkSimpleRTree_FirstQueryPrefetch(b *testing.B) {
+	const size = 1000000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	// bigger than the caches, writing it evicts the tree
+	evict := make([]byte, 64<<20)
+	for _, prefetch := range []bool{false, true} {
+		name := "Cold"
+		if prefetch {
+			name = "Prefetch"
+		}
+		b.Run(name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				b.StopTimer()
+				for i := 0; i < len(evict); i += 64 {
+					evict[i]++
+				}
+				if prefetch {
+					r.Prefetch()
+				}
+				// the first queries after the warm up, on different parts of the tree
+				b.StartTimer()
+				for i := 0; i < 20; i++ {
+					r.FindNearestPoint(rand.Float64(), rand.Float64())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package SimpleRTree
+
+// QueueItem is a node or a point waiting to be visited by a nearest point query.
+// Queues only need to look at Distance, the rest of the item is opaque
+type QueueItem struct {
+	Distance float64
+	index    int // position in nodes, or in points if isPoint is set
+	isPoint  bool
+}
+
+// Queue is the priority queue used by nearest point queries. It can be replaced with Options.NewQueue,
+// for example to experiment with queues specialized for bounded distances.
+// Distances of popped items never decrease during a query, so monotone priority queues such as radix heaps can be used.
+type Queue interface {
+	Push(item QueueItem)
+	// Pop removes and returns the item with the smallest Distance
+	Pop() QueueItem
+	Len() int
+	// Reset empties the queue. It is called before every query, so that memory can be reused
+	Reset()
+}
+
+// findNearestPointWithinQueue is the same search as findNearestPointWithin but using the queue provided in the options
+func (r *SimpleRTree) findNearestPointWithinQueue(x, y, dsquared float64, visited *int) (res Result, found bool) {
+	var minItem QueueItem
+	distanceLowerBound := 0.
+	distanceUpperBound := dsquared
+	var q Queue
+	if r.options.UnsafeConcurrencyMode {
+		if r.unsafeCustomQueue == nil {
+			r.unsafeCustomQueue = r.options.NewQueue()
+		}
+		q = r.unsafeCustomQueue
+	} else {
+		q = r.customQueuePool.Get().(Queue)
+		defer r.customQueuePool.Put(q)
+	}
+	q.Reset()
+	q.Push(QueueItem{index: 0})
+
+	for q.Len() > 0 {
+		item := q.Pop()
+		if found && item.Distance > distanceLowerBound {
+			break
+		}
+		if item.isPoint {
+			distanceLowerBound = item.Distance
+			minItem = item
+			found = true
+			continue
+		}
+		node := &r.nodes[item.index]
+		if visited != nil {
+			*visited += int(node.nChildren)
+		}
+		if node.nodeType == preleaf_node {
+			start := node.firstPointIndex()
+			for i := start + int(node.nChildren) - 1; i >= start; i-- {
+				_, _, d := r.pointDistance(i, x, y)
+				if d <= distanceUpperBound {
+					q.Push(QueueItem{Distance: d, index: i, isPoint: true})
+					distanceUpperBound = d
+				}
+			}
+			continue
+		}
+		first := node.firstChildIndex()
+		for i := first; i < first+int(node.nChildren); i++ {
+			mind, maxd := computeDistances(r.nodes[i].BBox, x, y, r.insideFactor)
+			if mind <= distanceUpperBound {
+				q.Push(QueueItem{Distance: mind, index: i})
+				if maxd < distanceUpperBound {
+					distanceUpperBound = maxd
+				}
+			}
+		}
+	}
+	if !found {
+		return
+	}
+	px, py := r.getPointAt(minItem.index)
+	res = Result{Index: minItem.index, X: px, Y: py, Distance: distanceUpperBound}
+	return
+}
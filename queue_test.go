@@ -0,0 +1,122 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+// radixQueue is a radix heap over the bits of the distances, valid because popped distances never decrease
+type radixQueue struct {
+	buckets [65][]QueueItem
+	last    uint64
+	size    int
+}
+
+func (q *radixQueue) bucket(key uint64) int {
+	if key <= q.last {
+		return 0
+	}
+	return bits.Len64(key ^ q.last)
+}
+
+func (q *radixQueue) Push(item QueueItem) {
+	b := q.bucket(math.Float64bits(item.Distance))
+	q.buckets[b] = append(q.buckets[b], item)
+	q.size++
+}
+
+func (q *radixQueue) Pop() QueueItem {
+	if len(q.buckets[0]) == 0 {
+		i := 1
+		for len(q.buckets[i]) == 0 {
+			i++
+		}
+		minKey := uint64(math.MaxUint64)
+		for _, item := range q.buckets[i] {
+			if k := math.Float64bits(item.Distance); k < minKey {
+				minKey = k
+			}
+		}
+		q.last = minKey
+		for _, item := range q.buckets[i] {
+			b := q.bucket(math.Float64bits(item.Distance))
+			q.buckets[b] = append(q.buckets[b], item)
+		}
+		q.buckets[i] = q.buckets[i][:0]
+	}
+	b0 := q.buckets[0]
+	item := b0[len(b0)-1]
+	q.buckets[0] = b0[:len(b0)-1]
+	q.size--
+	return item
+}
+
+func (q *radixQueue) Len() int {
+	return q.size
+}
+
+func (q *radixQueue) Reset() {
+	for i := range q.buckets {
+		q.buckets[i] = q.buckets[i][:0]
+	}
+	q.last = 0
+	q.size = 0
+}
+
+func newRadixQueue() Queue {
+	return &radixQueue{}
+}
+
+func TestSimpleRTree_NewQueue(t *testing.T) {
+	const size = 10000
+	points := make([]float64, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	for _, unsafeMode := range []bool{false, true} {
+		r := New().Load(FlatPoints(append([]float64(nil), points...)))
+		rq := NewWithOptions(Options{NewQueue: newRadixQueue, UnsafeConcurrencyMode: unsafeMode}).Load(FlatPoints(append([]float64(nil), points...)))
+		for i := 0; i < 1000; i++ {
+			x, y := rand.Float64()*1.2-0.1, rand.Float64()*1.2-0.1
+			x1, y1, d1 := r.FindNearestPoint(x, y)
+			x2, y2, d2 := rq.FindNearestPoint(x, y)
+			assert.Equal(t, x1, x2)
+			assert.Equal(t, y1, y2)
+			assert.Equal(t, d1, d2)
+			res1, found1 := r.findNearestPointWithin(x, y, 0.0001, nil)
+			res2, found2 := rq.findNearestPointWithin(x, y, 0.0001, nil)
+			assert.Equal(t, found1, found2)
+			assert.Equal(t, res1, res2)
+		}
+	}
+	_, found := NewWithOptions(Options{NewQueue: newRadixQueue}).Load(FlatPoints{}).findNearestPointWithin(0, 0, math.Inf(1), nil)
+	assert.False(t, found)
+}
+
+func BenchmarkSimpleRTree_FindNearestPointQueue(b *testing.B) {
+	const size = 100000
+	points := make([]float64, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	benchmarks := []struct {
+		name    string
+		options Options
+	}{
+		{"Default", Options{UnsafeConcurrencyMode: true}},
+		{"Radix", Options{UnsafeConcurrencyMode: true, NewQueue: newRadixQueue}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			r := NewWithOptions(bm.options).Load(FlatPoints(append([]float64(nil), points...)))
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				x, y := rand.Float64(), rand.Float64()
+				_, _, _ = r.FindNearestPoint(x, y)
+			}
+		})
+	}
+}
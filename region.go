@@ -0,0 +1,261 @@
+package SimpleRTree
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// RebuildRegion replaces the coordinates of the points inside box with newPoints and rebuilds only the part of the
+// tree that contains them. It is meant for datasets where changes are local, rebuilding the whole tree would be wasteful.
+//
+// newPoints must have as many points as there are inside box, that is len(r.Search(box)), and they can lie anywhere.
+// The i-th new point gives its coordinates to the i-th point inside box in increasing order of position.
+// As in Load, the points of the rebuilt subtree are reordered, so indexes obtained before the call are no longer valid.
+// The state set per point, whether it is enabled, its load, its attributes and its timestamp, moves with it, so
+// every point keeps its own, including the points given new coordinates.
+// The rebuilt subtree is the deepest one whose points include all the points inside box, so regions spanning
+// big parts of the tree rebuild big subtrees, in the worst case the whole tree.
+// Bounding boxes of the ancestors of the subtree are recomputed, the rest of the tree is left untouched.
+//
+// Only STR trees built from FlatPoints, and not with LoadGrouped, can be partially rebuilt. RebuildRegion is not safe to call concurrently with queries.
+func (r *SimpleRTree) RebuildRegion(box BBox, newPoints FlatPoints) error {
+	if !r.built {
+		return fmt.Errorf("%w, use Load instead", ErrNotBuilt)
+	}
+	if r.options.TreeType != STR || r.sortKey != nil || r.points == nil {
+		return errors.New("only STR trees built from FlatPoints can be rebuilt by regions")
+	}
+	if r.groups != nil {
+		// members are indexes in the array given to LoadGrouped, which moved points no longer match
+		return errors.New("trees loaded with LoadGrouped cannot be rebuilt by regions")
+	}
+	if err := checkPoints(newPoints); err != nil {
+		return err
+	}
+	indexes := r.Search(box)
+	if len(indexes) != newPoints.Len() {
+		return fmt.Errorf("region contains %d points but %d new points were given", len(indexes), newPoints.Len())
+	}
+	if len(indexes) == 0 {
+		return nil
+	}
+	sort.Ints(indexes)
+	minIndex, maxIndex := indexes[0], indexes[len(indexes)-1]
+
+	// descend to the deepest node that contains all the points of the region
+	path := []int{0}
+	height := r.height
+	for {
+		n := &r.nodes[path[len(path)-1]]
+		if n.nodeType == preleaf_node {
+			break
+		}
+		next := -1
+		for i := n.firstChildIndex(); i < n.firstChildIndex()+int(n.nChildren); i++ {
+			start, end := r.nodePointRange(i)
+			if start <= minIndex && maxIndex < end {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			break
+		}
+		path = append(path, next)
+		height--
+	}
+
+	if r.cache != nil {
+		r.cache.clear()
+	}
+	for i, index := range indexes {
+		r.points[2*index], r.points[2*index+1] = newPoints.GetPointAt(i)
+	}
+
+	// partial rebuilds do not report progress
+	progress := r.options.Progress
+	r.options.Progress = nil
+	defer func() {
+		r.options.Progress = progress
+	}()
+
+	subtreeIndex := path[len(path)-1]
+	subtree := &r.nodes[subtreeIndex]
+	start, end := r.nodePointRange(subtreeIndex)
+	// the points of the subtree are sorted together with their original positions, so that their per point state
+	// can follow them
+	var order []float64
+	if r.hasPointState() {
+		order = make([]float64, r.getLen())
+		for i := start; i < end; i++ {
+			order[i] = float64(i)
+		}
+		r.points, r.source = nil, attrPoints{points: r.points, values: [][]float64{order}}
+	}
+	nc := nodeConstruct{height: height, start: uint32(start), end: uint32(end)}
+	if subtree.nodeType == preleaf_node {
+		r.setLeafNode(subtree, nc)
+	} else {
+		// descendants of a node are stored contiguously after its first child and their number only depends on the
+		// number of points and the height, so building again overwrites exactly the same nodes
+		nNodes := len(r.nodes)
+		firstChild := subtree.firstChildIndex()
+		r.nodes = r.nodes[:firstChild]
+		r.buildNodeDownwards(subtreeIndex, nc, false)
+		if r.options.MortonLeaves {
+			r.sortLeavesMorton(firstChild, len(r.nodes))
+		}
+		r.nodes = r.nodes[:nNodes]
+	}
+	if r.options.MortonLeaves && subtree.nodeType == preleaf_node {
+		r.sortLeavesMorton(subtreeIndex, subtreeIndex+1)
+	}
+
+	if order != nil {
+		r.points, r.source = r.source.(attrPoints).points, nil
+		r.permutePointState(order, start, end)
+	}
+
+	// bboxes of the ancestors must stay tight, queries rely on points touching every side of a bbox
+	for i := len(path) - 2; i >= 0; i-- {
+		n := &r.nodes[path[i]]
+		first := n.firstChildIndex()
+		bbox := r.nodes[first].BBox
+		for j := first + 1; j < first+int(n.nChildren); j++ {
+			bbox = VectorBBoxExtend(bbox, r.nodes[j].BBox)
+		}
+		n.BBox = bbox
+	}
+	if r.loads != nil {
+		r.computeMinLoad(0)
+	}
+	if r.options.StoreCentroids {
+		r.computeCentroids()
+	}
+	if r.options.StoreHilbertValues {
+		r.computeHilbertValues()
+	}
+	if r.options.OrientedLeaves {
+		r.computeOrientedBoxes()
+	}
+	if r.options.BoundingCircles {
+		r.computeBoundingCircles()
+	}
+	if r.options.VerifyBBoxes {
+		return r.verifyBBoxes()
+	}
+	return nil
+}
+
+// hasPointState returns whether the tree keeps values per point position that must follow the points when they are
+// reordered: disabled points, loads, attributes or timestamps
+func (r *SimpleRTree) hasPointState() bool {
+	return r.disabled != nil || r.loads != nil || r.attrs != nil || r.timestamps != nil
+}
+
+// permutePointState reorders the per point values in [start, end) after the points, the point now at position i was
+// at position order[i]
+func (r *SimpleRTree) permutePointState(order []float64, start, end int) {
+	permute := func(values []float64) {
+		previous := append([]float64(nil), values[start:end]...)
+		for i := start; i < end; i++ {
+			values[i] = previous[int(order[i])-start]
+		}
+	}
+	for _, values := range r.attrs {
+		permute(values)
+	}
+	if r.timestamps != nil {
+		permute(r.timestamps)
+	}
+	if r.loads != nil {
+		permute(r.loads)
+	}
+	if r.disabled != nil {
+		previous := append([]bool(nil), r.disabled[start:end]...)
+		for i := start; i < end; i++ {
+			r.disabled[i] = previous[int(order[i])-start]
+		}
+	}
+}
+
+// RecomputeBBoxes recomputes the bounding boxes of every node from the current coordinates of the points, keeping the
+// structure of the tree and the order of the points. It is meant for points that move slightly, such as jittery
+// positions: the caller updates the coordinates in place in the points given to Load or LoadInterface, at the
+// positions of Result.Index, and calls RecomputeBBoxes, which costs a single pass over points and nodes instead of sorting again.
+//
+// The caller is responsible for the moves being small. Results stay correct for any move, but points are not moved
+// between leaves, so points travelling far from their original cell make boxes grow and overlap and queries slow
+// down. Rebuild the tree when that happens.
+//
+// RecomputeBBoxes is not safe to call concurrently with queries
+func (r *SimpleRTree) RecomputeBBoxes() error {
+	if !r.built {
+		return ErrNotBuilt
+	}
+	if len(r.nodes) == 0 {
+		return nil
+	}
+	if r.points != nil {
+		if err := checkPoints(r.points); err != nil {
+			return err
+		}
+	}
+	if r.leafPoints != nil {
+		// the copy of Options.LeafOrderCopy still holds the old coordinates
+		r.copyLeafPoints()
+	}
+	r.recomputeBBox(0)
+	if r.cache != nil {
+		r.cache.clear()
+	}
+	if r.options.StoreCentroids {
+		r.computeCentroids()
+	}
+	if r.options.StoreHilbertValues {
+		r.computeHilbertValues()
+	}
+	if r.options.OrientedLeaves {
+		r.computeOrientedBoxes()
+	}
+	if r.options.BoundingCircles {
+		r.computeBoundingCircles()
+	}
+	return nil
+}
+
+// recomputeBBox sets the bbox of the node at position i and its descendants from the points under them
+func (r *SimpleRTree) recomputeBBox(i int) VectorBBox {
+	n := &r.nodes[i]
+	if n.nodeType == preleaf_node {
+		start := n.firstPointIndex()
+		x, y := r.getPointAt(start)
+		bbox := VectorBBox{x, y, x, y}
+		for j := start + 1; j < start+int(n.nChildren); j++ {
+			x, y := r.getPointAt(j)
+			bbox = VectorBBoxExtend(bbox, VectorBBox{x, y, x, y})
+		}
+		n.BBox = bbox
+		return bbox
+	}
+	first := n.firstChildIndex()
+	bbox := r.recomputeBBox(first)
+	for j := first + 1; j < first+int(n.nChildren); j++ {
+		bbox = VectorBBoxExtend(bbox, r.recomputeBBox(j))
+	}
+	n.BBox = bbox
+	return bbox
+}
+
+// nodePointRange returns the range [start, end) of the points under the node at position i of r.nodes
+func (r *SimpleRTree) nodePointRange(i int) (start, end int) {
+	first, last := &r.nodes[i], &r.nodes[i]
+	for first.nodeType != preleaf_node {
+		first = &r.nodes[first.firstChildIndex()]
+	}
+	for last.nodeType != preleaf_node {
+		last = &r.nodes[last.firstChildIndex()+int(last.nChildren)-1]
+	}
+	return first.firstPointIndex(), last.firstPointIndex() + int(last.nChildren)
+}
@@ -0,0 +1,184 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSimpleRTree_RebuildRegion(t *testing.T) {
+	const size = 20000
+	for _, options := range []Options{{}, {MortonLeaves: true}, {MAX_ENTRIES: 4}} {
+		points := make([]float64, size*2)
+		for i := 0; i < 2*size; i++ {
+			points[i] = rand.Float64()
+		}
+		fp := FlatPoints(points)
+		r := NewWithOptions(options).Load(fp)
+		for i := 0; i < 10; i++ {
+			box := randomBBox(0.05)
+			nodes := append([]rNode{}, r.nodes...)
+			indexes := r.Search(box)
+			newPoints := make(FlatPoints, 0, 2*len(indexes))
+			for range indexes {
+				// points move a bit, some of them outside of the region
+				newPoints = append(newPoints, box.MinX+rand.Float64()*0.06, box.MinY+rand.Float64()*0.06)
+			}
+			expected := append([]float64{}, newPoints...)
+			for j := 0; j < fp.Len(); j++ {
+				if x, y := fp.GetPointAt(j); !box.containsPoint(x, y) {
+					expected = append(expected, x, y)
+				}
+			}
+			assert.NoError(t, r.RebuildRegion(box, newPoints))
+			assert.Len(t, r.nodes, len(nodes))
+			assertBBoxesContainChildren(t, r)
+			assert.Equal(t, sortedPoints(expected), sortedPoints(fp), "Points in the region are replaced")
+
+			full := NewWithOptions(options).Load(FlatPoints(append([]float64{}, points...)))
+			for j := 0; j < 100; j++ {
+				x, y := rand.Float64(), rand.Float64()
+				_, _, d1 := r.FindNearestPoint(x, y)
+				_, _, d2 := full.FindNearestPoint(x, y)
+				_, _, d3 := fp.linearClosestPoint(x, y)
+				assert.Equal(t, d2, d1)
+				assert.Equal(t, d3, d1)
+			}
+			searchBox := randomBBox(0.2)
+			results := r.Search(searchBox)
+			sort.Ints(results)
+			assert.Equal(t, fp.linearSearch(searchBox), results)
+		}
+	}
+}
+
+func TestSimpleRTree_RebuildRegionPointState(t *testing.T) {
+	const size = 5000
+	for _, options := range []Options{{}, {MortonLeaves: true}, {MAX_ENTRIES: 4}} {
+		points := generateDataset(uniformDataset, size, rand.Int63())
+		ids := make([]float64, size)
+		for i := range ids {
+			ids[i] = float64(i)
+		}
+		original := append(FlatPoints{}, points...)
+		r := NewWithOptions(options).LoadWithAttrs(points, map[string][]float64{"id": ids})
+		// every point gets state derived from its id
+		for i := 0; i < size; i++ {
+			id := int(ids[i])
+			r.SetLoad(i, float64(id%10))
+			r.SetEnabled(i, id%7 != 0)
+		}
+		box := randomBBox(0.1)
+		indexes := r.Search(box)
+		sort.Ints(indexes)
+		newPoints := generateDataset(uniformDataset, len(indexes), rand.Int63())
+		moved := make(map[int]int)
+		for i, index := range indexes {
+			moved[int(ids[index])] = i
+		}
+		assert.NoError(t, r.RebuildRegion(box, newPoints))
+
+		for i := 0; i < size; i++ {
+			id := int(r.Attrs(i)["id"])
+			x, y := original.GetPointAt(id)
+			if j, ok := moved[id]; ok {
+				x, y = newPoints.GetPointAt(j)
+			}
+			assert.Equal(t, [2]float64{x, y}, [2]float64{points[2*i], points[2*i+1]}, "Point %d", id)
+			assert.Equal(t, float64(id%10), r.GetLoad(i))
+			assert.Equal(t, id%7 != 0, r.IsEnabled(i))
+		}
+		// minimum loads of the nodes follow the moved loads
+		for n := 0; n < 100; n++ {
+			x, y := rand.Float64(), rand.Float64()
+			_, score, found := r.FindNearestByLoadWeightedDistance(x, y)
+			assert.True(t, found)
+			expected := math.Inf(1)
+			for i := 0; i < size; i++ {
+				if r.IsEnabled(i) {
+					_, _, d := r.pointDistance(i, x, y)
+					expected = math.Min(expected, math.Sqrt(d)*(1+r.GetLoad(i)))
+				}
+			}
+			assert.Equal(t, expected, score)
+		}
+	}
+
+	points := generateDataset(uniformDataset, 2000, rand.Int63())
+	timestamps := make([]float64, 2000)
+	for i := range timestamps {
+		timestamps[i] = float64(i)
+	}
+	original := append(FlatPoints{}, points...)
+	r := New().LoadWithTimestamps(points, timestamps)
+	box := randomBBox(0.1)
+	inside := len(r.Search(box))
+	newPoints := generateDataset(uniformDataset, inside, rand.Int63())
+	assert.NoError(t, r.RebuildRegion(box, newPoints))
+	for i := 0; i < 2000; i++ {
+		timestamp, ok := r.Timestamp(i)
+		assert.True(t, ok)
+		if x, y := original.GetPointAt(int(timestamp)); !box.containsPoint(x, y) {
+			assert.Equal(t, [2]float64{x, y}, [2]float64{points[2*i], points[2*i+1]})
+		}
+	}
+}
+
+func TestSimpleRTree_RebuildRegionErrors(t *testing.T) {
+	assert.Error(t, New().RebuildRegion(BBox{0, 0, 1, 1}, FlatPoints{}), "Tree not loaded")
+	r := NewWithOptions(Options{TreeType: HILBERT}).Load(FlatPoints{0, 0, 1, 1})
+	assert.Error(t, r.RebuildRegion(BBox{0, 0, 1, 1}, FlatPoints{0, 0, 1, 1}), "Hilbert tree")
+	r = New().LoadGrouped(FlatPoints{0, 0, 0, 0, 1, 1})
+	assert.EqualError(t, r.RebuildRegion(BBox{-1, -1, 0.5, 0.5}, FlatPoints{2, 2}), "trees loaded with LoadGrouped cannot be rebuilt by regions")
+	coord, members, _, _ := r.FindNearestGroup(0, 0)
+	assert.Equal(t, [2]float64{0, 0}, coord)
+	assert.Equal(t, []int{0, 1}, members)
+	r = New().Load(FlatPoints{0, 0, 1, 1, 2, 2})
+	assert.Error(t, r.RebuildRegion(BBox{0, 0, 1, 1}, FlatPoints{0, 0}), "Different number of points")
+	assert.NoError(t, r.RebuildRegion(BBox{0.5, 0.5, 1.5, 1.5}, FlatPoints{3, 3}))
+	x, y, _ := r.FindNearestPoint(4, 4)
+	assert.Equal(t, 3., x)
+	assert.Equal(t, 3., y)
+}
+
+func sortedPoints(fp FlatPoints) [][2]float64 {
+	sorted := make([][2]float64, fp.Len())
+	for i := range sorted {
+		sorted[i][0], sorted[i][1] = fp.GetPointAt(i)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i][0] < sorted[j][0] || (sorted[i][0] == sorted[j][0] && sorted[i][1] < sorted[j][1])
+	})
+	return sorted
+}
+
+func TestSimpleRTree_RecomputeBBoxes(t *testing.T) {
+	const size = 10000
+	for _, options := range []Options{{}, {TreeType: HILBERT}, {StoreCentroids: true}} {
+		points := make(FlatPoints, size*2)
+		for i := range points {
+			points[i] = rand.Float64()
+		}
+		r := NewWithOptions(options).Load(points)
+		for round := 0; round < 5; round++ {
+			for i := range points {
+				points[i] += (rand.Float64() - 0.5) * 0.002
+			}
+			assert.NoError(t, r.RecomputeBBoxes())
+			assertBBoxesContainChildren(t, r)
+			for j := 0; j < 100; j++ {
+				x, y := rand.Float64(), rand.Float64()
+				_, _, d1 := r.FindNearestPoint(x, y)
+				_, _, d2 := points.linearClosestPoint(x, y)
+				assert.Equal(t, d2, d1)
+			}
+			searchBox := randomBBox(0.2)
+			results := r.Search(searchBox)
+			sort.Ints(results)
+			assert.Equal(t, points.linearSearch(searchBox), results)
+		}
+	}
+	assert.ErrorIs(t, New().RecomputeBBoxes(), ErrNotBuilt)
+}
@@ -0,0 +1,22 @@
+package SimpleRTree
+
+import "sort"
+
+// SortResultsByDistance sorts rs in increasing order of distance. Results at the same distance are sorted by index,
+// so that the order does not depend on the query that produced them
+func SortResultsByDistance(rs []Result) {
+	sort.SliceStable(rs, func(i, j int) bool {
+		if rs[i].Distance != rs[j].Distance {
+			return rs[i].Distance < rs[j].Distance
+		}
+		return rs[i].Index < rs[j].Index
+	})
+}
+
+// SortResultsByIndex sorts rs in increasing order of index, which is also the order of the leaves of the tree.
+// Results with the same index keep their relative order
+func SortResultsByIndex(rs []Result) {
+	sort.SliceStable(rs, func(i, j int) bool {
+		return rs[i].Index < rs[j].Index
+	})
+}
@@ -0,0 +1,59 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestSortResultsByDistance(t *testing.T) {
+	rs := []Result{
+		{Index: 5, Distance: 2},
+		{Index: 3, Distance: 1},
+		{Index: 9, Distance: 1},
+		{Index: 1, Distance: 1},
+		{Index: 0, Distance: 0.5},
+	}
+	SortResultsByDistance(rs)
+	assert.Equal(t, []Result{
+		{Index: 0, Distance: 0.5},
+		{Index: 1, Distance: 1},
+		{Index: 3, Distance: 1},
+		{Index: 9, Distance: 1},
+		{Index: 5, Distance: 2},
+	}, rs)
+
+	// equal distances give the same order whatever the input order
+	shuffled := make([]Result, 100)
+	for i := range shuffled {
+		shuffled[i] = Result{Index: i, Distance: float64(i % 3)}
+	}
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	SortResultsByDistance(shuffled)
+	for i := 1; i < len(shuffled); i++ {
+		a, b := shuffled[i-1], shuffled[i]
+		assert.True(t, a.Distance < b.Distance || (a.Distance == b.Distance && a.Index < b.Index))
+	}
+}
+
+func TestSortResultsByIndex(t *testing.T) {
+	rs := []Result{
+		{Index: 2, X: 1},
+		{Index: 1, X: 2},
+		{Index: 2, X: 3},
+		{Index: 0, X: 4},
+		{Index: 2, X: 5},
+	}
+	SortResultsByIndex(rs)
+	// results with the same index are kept in their order
+	assert.Equal(t, []Result{
+		{Index: 0, X: 4},
+		{Index: 1, X: 2},
+		{Index: 2, X: 1},
+		{Index: 2, X: 3},
+		{Index: 2, X: 5},
+	}, rs)
+	SortResultsByIndex(nil)
+}
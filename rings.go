@@ -0,0 +1,45 @@
+package SimpleRTree
+
+import "math"
+
+// NearestRings delivers the points in batches of increasing distance to x and y, ring i holding the points at a
+// distance in [i * ringWidth, (i + 1) * ringWidth). Within a ring points are in increasing order of distance.
+// Only rings with points are delivered, fn receives the number of the ring and its points and can return false to
+// stop the search, rings further away are not explored. The slice passed to fn is reused for the next ring
+func (r *SimpleRTree) NearestRings(x, y, ringWidth float64, fn func(ring int, rs []Result) bool) {
+	if ringWidth <= 0 || math.IsNaN(ringWidth) {
+		return
+	}
+	var batch []Result
+	current := 0
+	stopped := false
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			mind, _ := computeDistances(bbox, x, y, r.insideFactor)
+			return mind, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.disabled != nil && r.disabled[i] {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return d, true
+		},
+		func(i int, px, py, d float64) bool {
+			ring := int(math.Sqrt(d) / ringWidth)
+			if ring != current && len(batch) > 0 {
+				if !fn(current, batch) {
+					stopped = true
+					return false
+				}
+				batch = batch[:0]
+			}
+			current = ring
+			batch = append(batch, Result{Index: i, X: px, Y: py, Distance: d})
+			return true
+		},
+	)
+	if !stopped && len(batch) > 0 {
+		fn(current, batch)
+	}
+}
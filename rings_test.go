@@ -0,0 +1,62 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSimpleRTree_NearestRings(t *testing.T) {
+	const size = 2000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(points)
+	for n := 0; n < 20; n++ {
+		x, y := rand.Float64(), rand.Float64()
+		const width = 0.05
+		expected := make(map[int]int)
+		for i := 0; i < points.Len(); i++ {
+			px, py := points.GetPointAt(i)
+			expected[int(math.Sqrt((px-x)*(px-x)+(py-y)*(py-y))/width)]++
+		}
+		seen := make([]bool, size)
+		previousRing := -1
+		r.NearestRings(x, y, width, func(ring int, rs []Result) bool {
+			assert.True(t, ring > previousRing, "Rings in increasing order")
+			previousRing = ring
+			assert.Equal(t, expected[ring], len(rs), "Ring %d complete", ring)
+			for j, res := range rs {
+				assert.False(t, seen[res.Index])
+				seen[res.Index] = true
+				d := math.Sqrt(res.Distance)
+				assert.True(t, float64(ring)*width <= d && d < float64(ring+1)*width, "Point in band")
+				if j > 0 {
+					assert.True(t, rs[j-1].Distance <= res.Distance)
+				}
+			}
+			return true
+		})
+		for i := range seen {
+			assert.True(t, seen[i])
+		}
+	}
+
+	// stopping
+	calls := 0
+	r.NearestRings(0.5, 0.5, 0.01, func(ring int, rs []Result) bool {
+		calls++
+		return calls < 3
+	})
+	assert.Equal(t, 3, calls)
+	r.NearestRings(0.5, 0.5, 0, func(ring int, rs []Result) bool {
+		t.Fail()
+		return true
+	})
+	New().NearestRings(0.5, 0.5, 1, func(ring int, rs []Result) bool {
+		t.Fail()
+		return true
+	})
+}
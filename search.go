@@ -0,0 +1,186 @@
+package SimpleRTree
+
+import (
+	"context"
+	"math"
+)
+
+// Search returns the indexes of the points inside box, boundary included.
+// Indexes refer to the order of the points after the build, see Result.
+// Order of the results is not specified.
+// Prefer SearchPoints if coordinates are needed. If they are not, Search is faster, results take 8 bytes instead of 32.
+func (r *SimpleRTree) Search(box BBox) []int {
+	var results []int
+	r.search(box, func(i int, x, y float64) {
+		results = append(results, i)
+	})
+	return results
+}
+
+// SearchPoints returns the points inside box, boundary included, with their coordinates, so that callers do not
+// need to look them up. Distance is not set in the results.
+// Order of the results is not specified
+func (r *SimpleRTree) SearchPoints(box BBox) []Result {
+	var results []Result
+	r.search(box, func(i int, x, y float64) {
+		results = append(results, Result{Index: i, X: x, Y: y})
+	})
+	return results
+}
+
+// SearchInto appends the coordinates of the points inside box to out, boundary included, and returns the extended
+// slice, in the same order as SearchPoints. It allocates nothing once out has enough capacity, so passing out[:0]
+// of a previous call avoids garbage when results are handed to code expecting flat coordinates, such as renderers
+func (r *SimpleRTree) SearchInto(box BBox, out FlatPoints) FlatPoints {
+	r.search(box, func(i int, x, y float64) {
+		out = append(out, x, y)
+	})
+	return out
+}
+
+// BBoxOf returns the tight bbox of the points at the given positions, for example the results of Search.
+// If indices is empty it returns a box with min coordinates +Inf and max coordinates -Inf, which contains nothing
+// and is the identity when extended with other boxes
+func (r *SimpleRTree) BBoxOf(indices []int) BBox {
+	vb := VectorBBox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, i := range indices {
+		x, y := r.getPointAt(i)
+		vb = VectorBBoxExtend(vb, VectorBBox{x, y, x, y})
+	}
+	return vb.ToBBox()
+}
+
+// search calls fn for every point inside box
+func (r *SimpleRTree) search(box BBox, fn func(i int, x, y float64)) {
+	r.searchContext(context.Background(), box, fn)
+}
+
+// searchContext is search stopping with the error of ctx once it is done, see SearchContext
+func (r *SimpleRTree) searchContext(ctx context.Context, box BBox, fn func(i int, x, y float64)) error {
+	if !r.built || len(r.nodes) == 0 {
+		return nil
+	}
+	if r.useLinearScan() {
+		for i := 0; i < r.getLen(); i++ {
+			if x, y := r.getPointAt(i); box.containsPoint(x, y) {
+				fn(i, x, y)
+			}
+		}
+		return nil
+	}
+	stack := make([]int, 1, 32)
+	for visited := 1; len(stack) > 0; visited++ {
+		if visited%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		n := &r.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if n.nodeType == preleaf_node {
+			start := n.firstPointIndex()
+			for i := start; i < start+int(n.nChildren); i++ {
+				if x, y := r.getPointAt(i); box.containsPoint(x, y) {
+					fn(i, x, y)
+				}
+			}
+			continue
+		}
+		first := n.firstChildIndex()
+		for i := first; i < first+int(n.nChildren); i++ {
+			if r.nodeIntersects(i, box) {
+				stack = append(stack, i)
+			}
+		}
+	}
+	return nil
+}
+
+// nodeIntersects returns whether the node at position i can hold points inside box, from its bbox and, for leaves of
+// trees with Options.OrientedLeaves, its oriented box
+func (r *SimpleRTree) nodeIntersects(i int, box BBox) bool {
+	if !box.intersects(r.nodes[i].BBox.ToBBox()) {
+		return false
+	}
+	return r.orientedBoxes == nil || r.nodes[i].nodeType != preleaf_node || r.orientedBoxes[i].intersects(box)
+}
+
+// SearchWithinBoxAndRadius returns the indexes of the points that are both inside box and at distance d or less
+// from cx and cy. Nodes are pruned with both constraints at the same time, so it is faster than intersecting
+// the results of Search and FindPointsWithin. Distance d follows the same rules as in FindPointsWithin.
+// Order of the results is not specified
+func (r *SimpleRTree) SearchWithinBoxAndRadius(box BBox, cx, cy, d float64) []int {
+	var results []int
+	if !r.built || len(r.nodes) == 0 {
+		return results
+	}
+	limitSquared, strict, ok := r.withinLimit(d)
+	if !ok {
+		return results
+	}
+	stack := make([]int, 1, 32)
+	for len(stack) > 0 {
+		n := &r.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if n.nodeType == preleaf_node {
+			start := n.firstPointIndex()
+			for i := start; i < start+int(n.nChildren); i++ {
+				px, py, distance := r.pointDistance(i, cx, cy)
+				if box.containsPoint(px, py) && isWithin(distance, limitSquared, strict) {
+					results = append(results, i)
+				}
+			}
+			continue
+		}
+		first := n.firstChildIndex()
+		for i := first; i < first+int(n.nChildren); i++ {
+			childBBox := r.nodes[i].BBox
+			if !box.intersects(childBBox.ToBBox()) {
+				continue
+			}
+			if mind, _ := computeDistances(childBBox, cx, cy, r.insideFactor); mind <= limitSquared {
+				stack = append(stack, i)
+			}
+		}
+	}
+	return results
+}
+
+// Has returns whether a point with exactly the coordinates x and y is indexed.
+// Only nodes whose bbox contains the coordinates are visited, so it is cheaper than a nearest point query
+func (r *SimpleRTree) Has(x, y float64) bool {
+	return r.HasEpsilon(x, y, 0)
+}
+
+// HasEpsilon returns whether there is a point at distance eps or less from x and y.
+// With eps zero it is the same as Has, negative eps never match
+func (r *SimpleRTree) HasEpsilon(x, y, eps float64) bool {
+	if !r.built || len(r.nodes) == 0 || eps < 0 {
+		return false
+	}
+	box := BBox{x - eps, y - eps, x + eps, y + eps}
+	epsSquared := eps * eps
+	stack := make([]int, 1, 32)
+	for len(stack) > 0 {
+		n := &r.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if n.nodeType == preleaf_node {
+			start := n.firstPointIndex()
+			for i := start; i < start+int(n.nChildren); i++ {
+				px, py, d := r.pointDistance(i, x, y)
+				// for eps zero containment is an exact comparison, distance could underflow to zero
+				if box.containsPoint(px, py) && (eps == 0 || d <= epsSquared) {
+					return true
+				}
+			}
+			continue
+		}
+		first := n.firstChildIndex()
+		for i := first; i < first+int(n.nChildren); i++ {
+			if r.nodeIntersects(i, box) {
+				stack = append(stack, i)
+			}
+		}
+	}
+	return false
+}
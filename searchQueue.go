@@ -2,13 +2,28 @@ package SimpleRTree
 
 
 type searchQueueItem struct {
-	node   uintptr   // if nil item carries node
+	node   uintptr   // address of the node, or for points the value returned by pointQueueNode
 	px, py float64 // points are not stored in nodes so we need to track them explicitely
 	distance float64
 }
 
 type searchQueue []searchQueueItem
 
+// pointQueueNode tags the offset in bytes of a point in the points array, so it can be told apart from node addresses.
+// Nodes contain floats, so their addresses are aligned and never have the lowest bit set
+func pointQueueNode(offset uintptr) uintptr {
+	return offset<<1 | 1
+}
+
+func isPointQueueNode(node uintptr) bool {
+	return node&1 == 1
+}
+
+// pointQueueIndex returns the index of the point stored with pointQueueNode
+func pointQueueIndex(node uintptr) int {
+	return int((node >> 1) / flat_point_size)
+}
+
 func (sq searchQueue) Len() int {
 	return len(sq)
 }
@@ -0,0 +1,291 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSimpleRTree_Search(t *testing.T) {
+	const size = 20000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	fp := FlatPoints(points)
+	r := New().Load(fp)
+	for i := 0; i < 100; i++ {
+		box := randomBBox(0.2)
+		results := r.Search(box)
+		sort.Ints(results)
+		assert.Equal(t, fp.linearSearch(box), results)
+	}
+	assert.Empty(t, r.Search(BBox{2, 2, 3, 3}))
+}
+
+func TestSimpleRTree_SearchPoints(t *testing.T) {
+	const size = 20000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	fp := FlatPoints(points)
+	r := New().Load(fp)
+	for i := 0; i < 100; i++ {
+		box := randomBBox(0.2)
+		indexes := r.Search(box)
+		results := r.SearchPoints(box)
+		assert.Len(t, results, len(indexes))
+		for j, res := range results {
+			assert.Equal(t, indexes[j], res.Index)
+			px, py := fp.GetPointAt(res.Index)
+			assert.Equal(t, px, res.X)
+			assert.Equal(t, py, res.Y)
+		}
+	}
+}
+
+func TestSimpleRTree_SearchInto(t *testing.T) {
+	const size = 5000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(FlatPoints(points))
+	var out FlatPoints
+	for i := 0; i < 20; i++ {
+		x, y := rand.Float64(), rand.Float64()
+		box := BBox{x, y, x + rand.Float64()/4, y + rand.Float64()/4}
+		out = r.SearchInto(box, out[:0])
+		results := r.SearchPoints(box)
+		assert.Equal(t, len(results), out.Len())
+		for j, res := range results {
+			x, y := out.GetPointAt(j)
+			assert.Equal(t, res.X, x)
+			assert.Equal(t, res.Y, y)
+		}
+	}
+	// results are appended after the existing points
+	prefix := FlatPoints{-1, -1}
+	box := BBox{0, 0, 1, 1}
+	out = r.SearchInto(box, prefix)
+	assert.Equal(t, size+1, out.Len())
+	x, y := out.GetPointAt(0)
+	assert.Equal(t, []float64{-1, -1}, []float64{x, y})
+	assert.Equal(t, FlatPoints(nil), New().Load(FlatPoints{}).SearchInto(box, nil))
+}
+
+func BenchmarkSimpleRTree_Search(b *testing.B) {
+	const size = 100000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	r := New().Load(FlatPoints(points))
+	box := BBox{0.4, 0.4, 0.6, 0.6}
+	b.Run("Indexes", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			_ = r.Search(box)
+		}
+	})
+	b.Run("Points", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			_ = r.SearchPoints(box)
+		}
+	})
+	b.Run("Flat", func(b *testing.B) {
+		b.ReportAllocs()
+		var out FlatPoints
+		for n := 0; n < b.N; n++ {
+			out = r.SearchInto(box, out[:0])
+		}
+	})
+}
+
+func TestSimpleRTree_SearchQueryAspectRatio(t *testing.T) {
+	const size = 20000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	for _, ratio := range []float64{0.1, 0.25, 4, 10, 1e300} {
+		fp := FlatPoints(append([]float64(nil), points...))
+		r := NewWithOptions(Options{QueryAspectRatio: ratio}).Load(fp)
+		assertBBoxesContainChildren(t, r)
+		for i := 0; i < 50; i++ {
+			box := randomBBox(0.2)
+			results := r.Search(box)
+			sort.Ints(results)
+			assert.Equal(t, fp.linearSearch(box), results)
+			x, y := rand.Float64(), rand.Float64()
+			_, _, d := r.FindNearestPoint(x, y)
+			_, _, expected := fp.linearClosestPoint(x, y)
+			assert.Equal(t, expected, d)
+		}
+	}
+}
+
+// searchVisits returns the number of nodes visited by Search
+func (r *SimpleRTree) searchVisits(box BBox) int {
+	visits := 0
+	stack := []int{0}
+	for len(stack) > 0 {
+		n := &r.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		visits++
+		if n.nodeType == preleaf_node {
+			continue
+		}
+		for i := n.firstChildIndex(); i < n.firstChildIndex()+int(n.nChildren); i++ {
+			if box.intersects(r.nodes[i].BBox.ToBBox()) {
+				stack = append(stack, i)
+			}
+		}
+	}
+	return visits
+}
+
+func BenchmarkSimpleRTree_SearchQueryAspectRatio(b *testing.B) {
+	const size = 100000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	// wide viewports, 8 times wider than tall
+	boxes := make([]BBox, 1000)
+	for i := range boxes {
+		x, y := rand.Float64(), rand.Float64()
+		boxes[i] = BBox{x, y, x + 0.2, y + 0.025}
+	}
+	benchmarks := []struct {
+		name  string
+		ratio float64
+	}{
+		{"Square", 0},
+		{"Matched", 8},
+		{"Mismatched", 1. / 8},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			r := NewWithOptions(Options{QueryAspectRatio: bm.ratio}).Load(FlatPoints(append([]float64(nil), points...)))
+			visits := 0
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				box := boxes[n%len(boxes)]
+				visits += r.searchVisits(box)
+				_ = r.Search(box)
+			}
+			b.ReportMetric(float64(visits)/float64(b.N), "nodes/op")
+		})
+	}
+}
+
+func TestSimpleRTree_SearchWithinBoxAndRadius(t *testing.T) {
+	const size = 20000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	fp := FlatPoints(points)
+	r := New().Load(fp)
+	for i := 0; i < 100; i++ {
+		box := randomBBox(0.2)
+		cx, cy := (box.MinX+box.MaxX)/2, (box.MinY+box.MaxY)/2
+		d := rand.Float64() * 0.15
+		inBox := map[int]bool{}
+		for _, idx := range r.Search(box) {
+			inBox[idx] = true
+		}
+		expected := []int{}
+		for _, res := range r.FindPointsWithin(cx, cy, d) {
+			if inBox[res.Index] {
+				expected = append(expected, res.Index)
+			}
+		}
+		sort.Ints(expected)
+		results := r.SearchWithinBoxAndRadius(box, cx, cy, d)
+		sort.Ints(results)
+		if len(expected) == 0 {
+			assert.Empty(t, results)
+			continue
+		}
+		assert.Equal(t, expected, results)
+	}
+}
+
+func TestSimpleRTree_BBoxOf(t *testing.T) {
+	const size = 20000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	fp := FlatPoints(points)
+	r := New().Load(fp)
+	for i := 0; i < 100; i++ {
+		indexes := r.Search(randomBBox(0.2))
+		if len(indexes) == 0 {
+			continue
+		}
+		expected := BBox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+		for _, j := range indexes {
+			x, y := fp.GetPointAt(j)
+			expected.MinX = math.Min(expected.MinX, x)
+			expected.MinY = math.Min(expected.MinY, y)
+			expected.MaxX = math.Max(expected.MaxX, x)
+			expected.MaxY = math.Max(expected.MaxY, y)
+		}
+		assert.Equal(t, expected, r.BBoxOf(indexes))
+	}
+	x, y := fp.GetPointAt(3)
+	assert.Equal(t, BBox{x, y, x, y}, r.BBoxOf([]int{3}))
+	empty := r.BBoxOf(nil)
+	assert.Equal(t, BBox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}, empty)
+	assert.Equal(t, BBox{0, 1, 2, 3}, empty.extend(BBox{0, 1, 2, 3}))
+}
+
+func TestSimpleRTree_Has(t *testing.T) {
+	const size = 10000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	fp := FlatPoints(points)
+	r := New().Load(fp)
+	for i := 0; i < fp.Len(); i++ {
+		x, y := fp.GetPointAt(i)
+		assert.True(t, r.Has(x, y))
+		assert.True(t, r.HasEpsilon(x, y, 1e-9))
+		// next representable coordinates are not indexed, but they are within epsilon
+		nx := math.Nextafter(x, 2)
+		assert.False(t, r.Has(nx, y))
+		assert.True(t, r.HasEpsilon(nx, y, 1e-9))
+		assert.False(t, r.HasEpsilon(x, y, -1))
+	}
+	assert.False(t, r.Has(2, 2))
+	assert.False(t, r.HasEpsilon(1.5, 0.5, 0.4))
+	assert.True(t, r.HasEpsilon(1.5, 0.5, 0.6))
+	// inside the bbox of the diagonal but not within distance of any point
+	d := New().Load(FlatPoints{0, 0, 1, 1})
+	assert.False(t, d.HasEpsilon(0, 1, 0.9))
+	assert.True(t, d.HasEpsilon(0, 1, 1))
+	assert.False(t, New().Has(0, 0))
+}
+
+func randomBBox(maxSide float64) BBox {
+	x, y := rand.Float64(), rand.Float64()
+	return BBox{x, y, x + rand.Float64()*maxSide, y + rand.Float64()*maxSide}
+}
+
+func (fp FlatPoints) linearSearch(box BBox) []int {
+	var indexes []int
+	for i := 0; i < fp.Len(); i++ {
+		if box.containsPoint(fp.GetPointAt(i)) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
@@ -0,0 +1,351 @@
+package SimpleRTree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+)
+
+// Binary format written by MarshalBinary, all values are little endian.
+//
+//   header: magic "SRTR", version (1 byte), flags (1 byte), tree type (1 byte), MAX_ENTRIES (1 byte),
+//           height (uint32), number of points (uint64), number of nodes (uint64),
+//           quantization offset x, offset y, scale x, scale y (float64, zero unless the quantized flag is set)
+//   nodes:  node type (1 byte), number of children (1 byte), position of the first child node or point (uint32)
+//   points: x, y as float64, or as uint32 if quantized
+//   checksum: CRC-32 (IEEE) of everything before it (uint32)
+//
+// Bboxes of the nodes are not stored, they are computed again from the points when reading.
+//
+// Flags are capabilities the reader must support to decode the data, readers reject flags they do not know instead of
+// misreading the data. Changes to the layout increase the version, readers only read the current version and
+// MigrateBinary upgrades data written by older builds.
+//
+// Versions:
+//   1: initial format
+//   2: adds the checksum
+const (
+	binaryVersion      = 2
+	binaryHeaderSize   = 4 + 4 + 4 + 8 + 8 + 4*8
+	binaryNodeSize     = 6
+	binaryChecksumSize = 4
+
+	binaryFlagQuantized = 1 << 0
+	binaryKnownFlags    = binaryFlagQuantized
+)
+
+var binaryMagic = [4]byte{'S', 'R', 'T', 'R'}
+
+// quantization maps coordinates to uint32 values, coordinate = offset + value * scale
+type quantization struct {
+	offsetX, offsetY, scaleX, scaleY float64
+}
+
+// MarshalBinary encodes the tree and its points, so that it can be read with UnmarshalBinary without building it again.
+// Only trees loaded from FlatPoints can be encoded
+func (r *SimpleRTree) MarshalBinary() ([]byte, error) {
+	return r.marshalBinary(false)
+}
+
+// MarshalBinaryQuantized encodes the tree as MarshalBinary but storing each coordinate as a uint32 scaled to the
+// bbox of the points, which halves the size of the points. The offset and scale of each axis are stored in the header.
+// Coordinates read back are off by at most half the scale, that is (max - min) / (2 * (2**32 - 1)) for each axis,
+// so it is only lossless for data on a coarser grid. Distances in queries are computed with the decoded coordinates
+func (r *SimpleRTree) MarshalBinaryQuantized() ([]byte, error) {
+	return r.marshalBinary(true)
+}
+
+func (r *SimpleRTree) marshalBinary(quantized bool) ([]byte, error) {
+	if !r.built || len(r.nodes) == 0 {
+		return nil, ErrNotBuilt
+	}
+	if r.points == nil {
+		return nil, errors.New("only trees loaded from FlatPoints can be encoded")
+	}
+	pointSize := 16
+	var flags uint8
+	var q quantization
+	if quantized {
+		pointSize = 8
+		flags |= binaryFlagQuantized
+		q = newQuantization(r.points)
+	}
+	nPoints := r.points.Len()
+	buf := make([]byte, binaryHeaderSize, binaryHeaderSize+len(r.nodes)*binaryNodeSize+nPoints*pointSize+binaryChecksumSize)
+	copy(buf, binaryMagic[:])
+	buf[4] = binaryVersion
+	buf[5] = flags
+	buf[6] = uint8(r.options.TreeType)
+	buf[7] = uint8(r.options.MAX_ENTRIES)
+	le := binary.LittleEndian
+	le.PutUint32(buf[8:], uint32(r.height))
+	le.PutUint64(buf[12:], uint64(nPoints))
+	le.PutUint64(buf[20:], uint64(len(r.nodes)))
+	for i, v := range []float64{q.offsetX, q.offsetY, q.scaleX, q.scaleY} {
+		le.PutUint64(buf[28+8*i:], math.Float64bits(v))
+	}
+
+	var record [binaryNodeSize]byte
+	for i := range r.nodes {
+		n := &r.nodes[i]
+		record[0] = uint8(n.nodeType)
+		record[1] = uint8(n.nChildren)
+		if n.nodeType == preleaf_node {
+			le.PutUint32(record[2:], uint32(n.firstPointIndex()))
+		} else {
+			le.PutUint32(record[2:], uint32(n.firstChildIndex()))
+		}
+		buf = append(buf, record[:]...)
+	}
+	var point [16]byte
+	for i := 0; i < nPoints; i++ {
+		x, y := r.points.GetPointAt(i)
+		if quantized {
+			le.PutUint32(point[0:], q.quantize(x, q.offsetX, q.scaleX))
+			le.PutUint32(point[4:], q.quantize(y, q.offsetY, q.scaleY))
+		} else {
+			le.PutUint64(point[0:], math.Float64bits(x))
+			le.PutUint64(point[8:], math.Float64bits(y))
+		}
+		buf = append(buf, point[:pointSize]...)
+	}
+	return appendChecksum(buf), nil
+}
+
+// UnmarshalBinary reads a tree encoded with MarshalBinary or MarshalBinaryQuantized into r, which must not have
+// been loaded. MAX_ENTRIES and TreeType are taken from the data, the rest of the options are kept.
+// Points are owned by the tree and can be read with LeafPointsInOrder.
+// Data written by other versions of the format fails with ErrUnsupportedVersion, older data can be upgraded with
+// MigrateBinary. Data using capabilities this build does not know fails with ErrUnsupportedFlags
+func (r *SimpleRTree) UnmarshalBinary(data []byte) error {
+	if r.built {
+		return ErrAlreadyBuilt
+	}
+	if len(data) < binaryHeaderSize {
+		return errors.New("data is too short for the header")
+	}
+	if [4]byte{data[0], data[1], data[2], data[3]} != binaryMagic {
+		return errors.New("data is not an encoded tree")
+	}
+	if err := checkBinaryVersion(data[4]); err != nil {
+		return err
+	}
+	flags := data[5]
+	if unknown := flags &^ binaryKnownFlags; unknown != 0 {
+		return fmt.Errorf("%w %#x", ErrUnsupportedFlags, unknown)
+	}
+	treeType := TreeType(data[6])
+	maxEntries := int(data[7])
+	if treeType != STR && treeType != HILBERT {
+		return fmt.Errorf("unknown tree type %d", treeType)
+	}
+	if maxEntries < 2 || maxEntries > MAX_POSSIBLE_SIZE {
+		return fmt.Errorf("%w %d", ErrInvalidMaxEntries, maxEntries)
+	}
+	le := binary.LittleEndian
+	height := int(le.Uint32(data[8:]))
+	nPoints := le.Uint64(data[12:])
+	nNodes := le.Uint64(data[20:])
+	var q quantization
+	q.offsetX = math.Float64frombits(le.Uint64(data[28:]))
+	q.offsetY = math.Float64frombits(le.Uint64(data[36:]))
+	q.scaleX = math.Float64frombits(le.Uint64(data[44:]))
+	q.scaleY = math.Float64frombits(le.Uint64(data[52:]))
+
+	pointSize := uint64(16)
+	if flags&binaryFlagQuantized != 0 {
+		pointSize = 8
+	}
+	if nPoints == 0 || nNodes == 0 || nPoints >= math.MaxInt32/uint64(node_size) || nNodes > 2*nPoints+1 {
+		return fmt.Errorf("invalid number of points %d or nodes %d", nPoints, nNodes)
+	}
+	if expected := binaryHeaderSize + nNodes*binaryNodeSize + nPoints*pointSize + binaryChecksumSize; uint64(len(data)) != expected {
+		return fmt.Errorf("data has %d bytes, expected %d", len(data), expected)
+	}
+	payload := data[:len(data)-binaryChecksumSize]
+	if le.Uint32(data[len(payload):]) != crc32.ChecksumIEEE(payload) {
+		return errors.New("checksum mismatch, data is corrupted")
+	}
+
+	// a tree of nPoints points built with maxEntries children per node is never higher than this, Hilbert trees have
+	// one more level than STR trees
+	if height > strHeight(int(nPoints), maxEntries)+1 {
+		return fmt.Errorf("invalid height %d for %d points", height, nPoints)
+	}
+
+	nodes := make([]rNode, nNodes)
+	// every node has at most one parent and the root none, so that the nodes reachable from the root form a tree and
+	// computeBBoxes visits each of them once. Shared children would make it visit them exponentially many times.
+	// Parents come after their children in Hilbert trees, so the order of the nodes is not checked
+	hasParent := make([]bool, nNodes)
+	offset := binaryHeaderSize
+	for i := range nodes {
+		nodeType := nodeType(data[offset])
+		nChildren := int(int8(data[offset+1]))
+		first := uint64(le.Uint32(data[offset+2:]))
+		offset += binaryNodeSize
+		limit, size, maxChildren := nNodes, uint64(node_size), maxEntries
+		if nodeType == preleaf_node {
+			// leaves hold more than MAX_ENTRIES points in trees built with Options.MaxHeight
+			limit, size, maxChildren = nPoints, uint64(flat_point_size), maxLeafSize
+		} else if nodeType != default_node {
+			return fmt.Errorf("node %d has unknown type %d", i, nodeType)
+		}
+		if nChildren < 1 || nChildren > maxChildren || first+uint64(nChildren) > limit {
+			return fmt.Errorf("node %d has invalid children", i)
+		}
+		if nodeType == default_node {
+			for j := first; j < first+uint64(nChildren); j++ {
+				if j == 0 {
+					return errors.New("root node is a child")
+				}
+				if hasParent[j] {
+					return fmt.Errorf("node %d has more than one parent", j)
+				}
+				hasParent[j] = true
+			}
+		}
+		nodes[i] = rNode{nodeType: nodeType, nChildren: int8(nChildren), firstChildOffset: uint32(first * size)}
+	}
+	points := make(FlatPoints, 2*nPoints)
+	for i := uint64(0); i < nPoints; i++ {
+		if pointSize == 8 {
+			points[2*i] = q.offsetX + float64(le.Uint32(data[offset:]))*q.scaleX
+			points[2*i+1] = q.offsetY + float64(le.Uint32(data[offset+4:]))*q.scaleY
+		} else {
+			points[2*i] = math.Float64frombits(le.Uint64(data[offset:]))
+			points[2*i+1] = math.Float64frombits(le.Uint64(data[offset+8:]))
+		}
+		offset += int(pointSize)
+	}
+	if err := checkPoints(points); err != nil {
+		return err
+	}
+
+	r.options.MAX_ENTRIES = maxEntries
+	r.options.TreeType = treeType
+	r.nodes = nodes
+	r.points = points
+	r.source = nil
+	if _, err := r.computeBBoxes(0, height); err != nil {
+		r.nodes, r.points = nil, nil
+		return err
+	}
+	r.height = height
+	if r.options.StoreCentroids {
+		r.computeCentroids()
+	}
+	if r.options.StoreHilbertValues {
+		r.computeHilbertValues()
+	}
+	if r.options.OrientedLeaves {
+		r.computeOrientedBoxes()
+	}
+	if r.options.BoundingCircles {
+		r.computeBoundingCircles()
+	}
+	r.sorterBuffer = make([]int, 0, maxEntries+1)
+	r.initQueues(height)
+	r.built = true
+	return nil
+}
+
+// MigrateBinary upgrades data written by MarshalBinary or MarshalBinaryQuantized of an older build to the current
+// version of the format, so that it can be read with UnmarshalBinary. Data already in the current version is returned
+// as is, data is never modified in place. Data of newer versions fails with ErrUnsupportedVersion
+func MigrateBinary(data []byte) ([]byte, error) {
+	if len(data) < binaryHeaderSize {
+		return nil, errors.New("data is too short for the header")
+	}
+	if [4]byte{data[0], data[1], data[2], data[3]} != binaryMagic {
+		return nil, errors.New("data is not an encoded tree")
+	}
+	switch data[4] {
+	case binaryVersion:
+		return data, nil
+	case 1:
+		// version 2 only appends the checksum
+		migrated := make([]byte, len(data), len(data)+binaryChecksumSize)
+		copy(migrated, data)
+		migrated[4] = binaryVersion
+		return appendChecksum(migrated), nil
+	}
+	return nil, checkBinaryVersion(data[4])
+}
+
+// appendChecksum appends the checksum of buf
+func appendChecksum(buf []byte) []byte {
+	var checksum [binaryChecksumSize]byte
+	binary.LittleEndian.PutUint32(checksum[:], crc32.ChecksumIEEE(buf))
+	return append(buf, checksum[:]...)
+}
+
+// checkBinaryVersion returns an error explaining how to proceed if version is not the one this build reads
+func checkBinaryVersion(version uint8) error {
+	switch {
+	case version == binaryVersion:
+		return nil
+	case version > 0 && version < binaryVersion:
+		return fmt.Errorf("%w %d, this build reads version %d, upgrade the data with MigrateBinary", ErrUnsupportedVersion, version, binaryVersion)
+	}
+	return fmt.Errorf("%w %d, this build reads version %d", ErrUnsupportedVersion, version, binaryVersion)
+}
+
+// computeBBoxes sets the bboxes of the node at position i and its descendants from the points.
+// Nodes deeper than height are rejected, so that malformed data cannot make it loop
+func (r *SimpleRTree) computeBBoxes(i, height int) (VectorBBox, error) {
+	if height <= 0 {
+		return VectorBBox{}, errors.New("nodes are deeper than the height of the tree")
+	}
+	n := &r.nodes[i]
+	if n.nodeType == preleaf_node {
+		start := n.firstPointIndex()
+		x, y := r.points.GetPointAt(start)
+		bbox := VectorBBox{x, y, x, y}
+		for j := start + 1; j < start+int(n.nChildren); j++ {
+			x, y := r.points.GetPointAt(j)
+			bbox = VectorBBoxExtend(bbox, VectorBBox{x, y, x, y})
+		}
+		n.BBox = bbox
+		return bbox, nil
+	}
+	first := n.firstChildIndex()
+	bbox, err := r.computeBBoxes(first, height-1)
+	if err != nil {
+		return bbox, err
+	}
+	for j := first + 1; j < first+int(n.nChildren); j++ {
+		childBBox, err := r.computeBBoxes(j, height-1)
+		if err != nil {
+			return bbox, err
+		}
+		bbox = VectorBBoxExtend(bbox, childBBox)
+	}
+	n.BBox = bbox
+	return bbox, nil
+}
+
+func newQuantization(points FlatPoints) quantization {
+	x0, y0 := points.GetPointAt(0)
+	bbox := VectorBBox{x0, y0, x0, y0}
+	for i := 1; i < points.Len(); i++ {
+		x, y := points.GetPointAt(i)
+		bbox = VectorBBoxExtend(bbox, VectorBBox{x, y, x, y})
+	}
+	return quantization{
+		offsetX: bbox[VECTOR_BBOX_MIN_X],
+		offsetY: bbox[VECTOR_BBOX_MIN_Y],
+		scaleX:  (bbox[VECTOR_BBOX_MAX_X] - bbox[VECTOR_BBOX_MIN_X]) / math.MaxUint32,
+		scaleY:  (bbox[VECTOR_BBOX_MAX_Y] - bbox[VECTOR_BBOX_MIN_Y]) / math.MaxUint32,
+	}
+}
+
+// quantize returns the closest value to v in the grid given by offset and scale
+func (q quantization) quantize(v, offset, scale float64) uint32 {
+	if scale == 0 {
+		return 0
+	}
+	return uint32(math.Min(math.Round((v-offset)/scale), math.MaxUint32))
+}
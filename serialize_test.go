@@ -0,0 +1,183 @@
+package SimpleRTree
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestSimpleRTree_MarshalBinary(t *testing.T) {
+	const size = 10000
+	for _, options := range []Options{{}, {TreeType: HILBERT}, {MAX_ENTRIES: 4, UnsafeConcurrencyMode: true}} {
+		points := make(FlatPoints, size*2)
+		for i := range points {
+			points[i] = rand.Float64()*2000 - 1000
+		}
+		r := NewWithOptions(options).Load(points)
+		data, err := r.MarshalBinary()
+		assert.NoError(t, err)
+
+		decoded := NewWithOptions(Options{UnsafeConcurrencyMode: options.UnsafeConcurrencyMode})
+		assert.NoError(t, decoded.UnmarshalBinary(data))
+		assert.Equal(t, r.options.MAX_ENTRIES, decoded.options.MAX_ENTRIES)
+		assert.Equal(t, r.options.TreeType, decoded.options.TreeType)
+		assert.Equal(t, r.height, decoded.height)
+		assert.Equal(t, r.LeafPointsInOrder(), decoded.LeafPointsInOrder())
+		assert.Equal(t, len(r.nodes), len(decoded.nodes))
+		// root bbox is not always computed
+		assert.Equal(t, r.nodes[1:], decoded.nodes[1:])
+		assertBBoxesContainChildren(t, decoded)
+		for i := 0; i < 100; i++ {
+			x, y := rand.Float64()*2000-1000, rand.Float64()*2000-1000
+			x1, y1, d1 := r.FindNearestPoint(x, y)
+			x2, y2, d2 := decoded.FindNearestPoint(x, y)
+			assert.Equal(t, [3]float64{x1, y1, d1}, [3]float64{x2, y2, d2})
+		}
+	}
+}
+
+func TestSimpleRTree_MarshalBinaryQuantized(t *testing.T) {
+	const size = 10000
+	points := make(FlatPoints, size*2)
+	for i := range points {
+		points[i] = rand.Float64()*2000 - 1000
+	}
+	r := New().Load(points)
+	data, err := r.MarshalBinary()
+	assert.NoError(t, err)
+	quantizedData, err := r.MarshalBinaryQuantized()
+	assert.NoError(t, err)
+	// points take half the space
+	nodesSize := binaryHeaderSize + len(r.nodes)*binaryNodeSize + binaryChecksumSize
+	assert.Equal(t, (len(data)-nodesSize)/2, len(quantizedData)-nodesSize)
+
+	decoded := New()
+	assert.NoError(t, decoded.UnmarshalBinary(quantizedData))
+	assertBBoxesContainChildren(t, decoded)
+	q := newQuantization(points)
+	decodedPoints := decoded.LeafPointsInOrder()
+	for i := 0; i < points.Len(); i++ {
+		x, y := points.GetPointAt(i)
+		dx, dy := decodedPoints.GetPointAt(i)
+		assert.InDelta(t, x, dx, q.scaleX*0.5001)
+		assert.InDelta(t, y, dy, q.scaleY*0.5001)
+	}
+	// min and max are kept exactly, points on a coarse grid are too
+	grid := FlatPoints{0, 0, 1, 2, 2, 4, 3, 6}
+	data, err = New().Load(grid).MarshalBinaryQuantized()
+	assert.NoError(t, err)
+	decoded = New()
+	assert.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, sortedPoints(grid), sortedPoints(decoded.LeafPointsInOrder()))
+	// a single point has zero scale
+	data, err = New().Load(FlatPoints{3, -7}).MarshalBinaryQuantized()
+	assert.NoError(t, err)
+	decoded = New()
+	assert.NoError(t, decoded.UnmarshalBinary(data))
+	x, y, d := decoded.FindNearestPoint(3, -6)
+	assert.Equal(t, [3]float64{3, -7, 1}, [3]float64{x, y, d})
+}
+
+func TestSimpleRTree_UnmarshalBinaryErrors(t *testing.T) {
+	_, err := New().MarshalBinary()
+	assert.Error(t, err)
+	_, err = New().LoadInterface(IntPoints{1, 2, 3, 4}).MarshalBinary()
+	assert.Error(t, err)
+
+	r := New().Load(FlatPoints{0, 0, 1, 1, 2, 2})
+	data, err := r.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Error(t, r.UnmarshalBinary(data), "Tree already loaded")
+	assert.Error(t, New().UnmarshalBinary(data[:10]))
+	assert.Error(t, New().UnmarshalBinary(data[:len(data)-1]))
+
+	// the checksum is computed again, so that the rest of the checks are reached
+	corrupt := func(pos int, value byte) []byte {
+		c := append([]byte(nil), data[:len(data)-binaryChecksumSize]...)
+		c[pos] = value
+		return appendChecksum(c)
+	}
+	assert.Error(t, New().UnmarshalBinary(corrupt(0, 'X')), "Magic")
+	assert.Error(t, New().UnmarshalBinary(corrupt(4, 99)), "Version")
+	assert.Error(t, New().UnmarshalBinary(corrupt(6, 7)), "Tree type")
+	assert.Error(t, New().UnmarshalBinary(corrupt(7, MAX_POSSIBLE_SIZE+1)), "MAX_ENTRIES")
+	assert.Error(t, New().UnmarshalBinary(corrupt(binaryHeaderSize, 5)), "Node type")
+	assert.Error(t, New().UnmarshalBinary(corrupt(binaryHeaderSize+1, 4)), "Number of children")
+	assert.Error(t, New().UnmarshalBinary(corrupt(binaryHeaderSize+2, 1)), "First point")
+	assert.Error(t, New().UnmarshalBinary(corrupt(8, 0)), "Height")
+	assert.ErrorIs(t, New().UnmarshalBinary(corrupt(5, 1<<7)), ErrUnsupportedFlags)
+	flipped := append([]byte(nil), data...)
+	flipped[len(flipped)-binaryChecksumSize-1] ^= 1
+	assert.Error(t, New().UnmarshalBinary(flipped), "Checksum")
+	assert.NoError(t, New().UnmarshalBinary(data))
+}
+
+func TestSimpleRTree_BinaryVersions(t *testing.T) {
+	points := FlatPoints{0, 0, 1, 1, 2, 2, 5, -3}
+	data, err := New().Load(append(FlatPoints{}, points...)).MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(binaryVersion), data[4])
+
+	// version 1 is the current format without the checksum
+	v1 := append([]byte(nil), data[:len(data)-binaryChecksumSize]...)
+	v1[4] = 1
+	err = New().UnmarshalBinary(v1)
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	assert.Contains(t, err.Error(), "MigrateBinary")
+	migrated, err := MigrateBinary(v1)
+	assert.NoError(t, err)
+	assert.Equal(t, data, migrated)
+	assert.Equal(t, uint8(1), v1[4], "Data is not modified")
+	decoded := New()
+	assert.NoError(t, decoded.UnmarshalBinary(migrated))
+	assert.Equal(t, sortedPoints(points), sortedPoints(decoded.LeafPointsInOrder()))
+
+	current, err := MigrateBinary(data)
+	assert.NoError(t, err)
+	assert.Equal(t, data, current)
+
+	future := append([]byte(nil), data...)
+	future[4] = binaryVersion + 1
+	err = New().UnmarshalBinary(future)
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	assert.NotContains(t, err.Error(), "MigrateBinary")
+	_, err = MigrateBinary(future)
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	_, err = MigrateBinary([]byte("SRTR"))
+	assert.Error(t, err)
+}
+
+func TestSimpleRTree_UnmarshalBinarySharedChildren(t *testing.T) {
+	// a chain of nodes where each one shares a child with the next, which visited as a tree takes exponential time
+	const nNodes, nPoints = 60, 30
+	le := binary.LittleEndian
+	encode := func(height int, nodes [][3]int) []byte {
+		buf := make([]byte, binaryHeaderSize)
+		copy(buf, binaryMagic[:])
+		buf[4] = binaryVersion
+		buf[6] = uint8(STR)
+		buf[7] = 2
+		le.PutUint32(buf[8:], uint32(height))
+		le.PutUint64(buf[12:], nPoints)
+		le.PutUint64(buf[20:], uint64(len(nodes)))
+		for _, n := range nodes {
+			record := make([]byte, binaryNodeSize)
+			record[0], record[1] = uint8(n[0]), uint8(n[1])
+			le.PutUint32(record[2:], uint32(n[2]))
+			buf = append(buf, record...)
+		}
+		buf = append(buf, make([]byte, 16*nPoints)...)
+		return appendChecksum(buf)
+	}
+	var shared [][3]int
+	for i := 0; i < nNodes-2; i++ {
+		shared = append(shared, [3]int{int(default_node), 2, i + 1})
+	}
+	shared = append(shared, [3]int{int(preleaf_node), 1, 0}, [3]int{int(preleaf_node), 1, 0})
+	assert.EqualError(t, New().UnmarshalBinary(encode(6, shared)), "node 2 has more than one parent")
+	// the root as a child, a cycle
+	assert.EqualError(t, New().UnmarshalBinary(encode(6, [][3]int{{int(default_node), 1, 1}, {int(default_node), 1, 0}})), "root node is a child")
+	// heights a single point cannot reach
+	assert.EqualError(t, New().UnmarshalBinary(encode(nNodes, shared)), "invalid height 60 for 30 points")
+}
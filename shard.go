@@ -0,0 +1,124 @@
+package SimpleRTree
+
+import (
+	"math"
+	"sort"
+)
+
+// ShardRouter maps queries to the shards of a ShardedRTree that can hold their results. It only keeps the shard
+// function and the bbox of the points of every shard, so it can live apart from the trees, for example in the front
+// end of a distributed deployment
+type ShardRouter struct {
+	shard func(x, y float64) int
+	boxes map[int]VectorBBox
+}
+
+// Home returns the shard that x and y belong to, the first one to consult for a nearest point query. It might hold
+// no points
+func (rt *ShardRouter) Home(x, y float64) int {
+	return rt.shard(x, y)
+}
+
+// RouteWithin returns the shards holding points that can be at distance d or less from x and y, in increasing order
+// of the distance to their bbox. For nearest point queries, once the home shard answers with a point at distance d,
+// the shards returned by RouteWithin for d are the only ones that can hold a closer point
+func (rt *ShardRouter) RouteWithin(x, y, d float64) []int {
+	if d < 0 {
+		return nil
+	}
+	return rt.byDistance(x, y, d*d)
+}
+
+// RouteBox returns the shards holding points that can be inside box, in increasing order of id
+func (rt *ShardRouter) RouteBox(box BBox) []int {
+	var shards []int
+	for id, bbox := range rt.boxes {
+		if box.intersects(bbox.ToBBox()) {
+			shards = append(shards, id)
+		}
+	}
+	sort.Ints(shards)
+	return shards
+}
+
+// byDistance returns the shards whose bbox is at distance squared limitSquared or less from x and y, closest first
+func (rt *ShardRouter) byDistance(x, y, limitSquared float64) []int {
+	var shards []int
+	distances := make(map[int]float64, len(rt.boxes))
+	for id, bbox := range rt.boxes {
+		if mind, _ := computeDistances(bbox, x, y, 1); mind <= limitSquared {
+			shards = append(shards, id)
+			distances[id] = mind
+		}
+	}
+	sort.Slice(shards, func(a, b int) bool {
+		da, db := distances[shards[a]], distances[shards[b]]
+		return da < db || (da == db && shards[a] < shards[b])
+	})
+	return shards
+}
+
+// ShardedRTree partitions points by a spatial hash, such as a grid cell or a geohash prefix, and builds one tree per
+// shard, for deployments that spread the shards across machines. Results of the trees of the shards are positions
+// in those trees, as in Result
+type ShardedRTree struct {
+	router *ShardRouter
+	trees  map[int]*SimpleRTree
+}
+
+// NewSharded builds one tree with the given options for each shard returned by shard for the points. points is not
+// modified. Shards without points get no tree
+func NewSharded(o Options, points FlatPoints, shard func(x, y float64) int) *ShardedRTree {
+	parts := make(map[int]FlatPoints)
+	for i := 0; i < points.Len(); i++ {
+		x, y := points.GetPointAt(i)
+		id := shard(x, y)
+		parts[id] = append(parts[id], x, y)
+	}
+	s := &ShardedRTree{
+		router: &ShardRouter{shard: shard, boxes: make(map[int]VectorBBox, len(parts))},
+		trees:  make(map[int]*SimpleRTree, len(parts)),
+	}
+	for id, part := range parts {
+		tree := NewWithOptions(o).Load(part)
+		s.trees[id] = tree
+		s.router.boxes[id] = tree.rootBBox()
+	}
+	return s
+}
+
+// Router returns the router of the shards
+func (s *ShardedRTree) Router() *ShardRouter {
+	return s.router
+}
+
+// Shard returns the tree of the shard id, nil if it has no points
+func (s *ShardedRTree) Shard(id int) *SimpleRTree {
+	return s.trees[id]
+}
+
+// FindNearestPoint returns the closest point to x and y and the shard holding it, consulting the home shard first
+// and then only the shards whose bbox is closer than the best point found so far, as a distributed deployment would
+// with the Router. Index in the result is a position in the tree of the shard
+func (s *ShardedRTree) FindNearestPoint(x, y float64) (res Result, shard int, found bool) {
+	best := math.Inf(1)
+	home := s.router.Home(x, y)
+	if tree := s.trees[home]; tree != nil {
+		if res, found = tree.findNearestPointWithin(x, y, best, nil); found {
+			shard, best = home, res.Distance
+		}
+	}
+	for _, id := range s.router.byDistance(x, y, best) {
+		if id == home {
+			continue
+		}
+		// shards are sorted by distance, the best point can only shrink the list
+		if mind, _ := computeDistances(s.router.boxes[id], x, y, 1); mind > best {
+			break
+		}
+		if candidate, ok := s.trees[id].findNearestPointWithin(x, y, best, nil); ok && candidate.Distance < best {
+			res, shard, found, best = candidate, id, true, candidate.Distance
+		}
+	}
+	return
+}
@@ -0,0 +1,87 @@
+package SimpleRTree
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRTree_Sharded(t *testing.T) {
+	// 4 x 4 grid cells over the unit square
+	grid := func(x, y float64) int {
+		cx := math.Max(0, math.Min(3, math.Floor(x*4)))
+		cy := math.Max(0, math.Min(3, math.Floor(y*4)))
+		return int(cx)*4 + int(cy)
+	}
+	points := generateDataset(clusteredDataset, 20000, 1)
+	original := append(FlatPoints(nil), points...)
+	s := NewSharded(Options{}, points, grid)
+	assert.Equal(t, original, points)
+	global := New().Load(append(FlatPoints(nil), points...))
+
+	total := 0
+	for id := 0; id < 16; id++ {
+		if tree := s.Shard(id); tree != nil {
+			total += tree.getLen()
+			for i := 0; i < tree.getLen(); i++ {
+				assert.Equal(t, id, grid(tree.getPointAt(i)))
+			}
+		}
+	}
+	assert.Equal(t, points.Len(), total)
+
+	for n := 0; n < 1000; n++ {
+		// queries close to the boundaries of the cells
+		x := float64(rand.Intn(5))/4 + rand.NormFloat64()*0.01
+		y := rand.Float64()
+		if n%2 == 0 {
+			x, y = y, x
+		}
+		res, shard, found := s.FindNearestPoint(x, y)
+		assert.True(t, found)
+		_, _, d := global.FindNearestPoint(x, y)
+		assert.Equal(t, d, res.Distance)
+		px, py := s.Shard(shard).getPointAt(res.Index)
+		assert.Equal(t, [2]float64{px, py}, [2]float64{res.X, res.Y})
+
+		// routed range queries find the same points as the global tree
+		within := 0.05 * rand.Float64()
+		var routed [][2]float64
+		for _, id := range s.Router().RouteWithin(x, y, within) {
+			for _, res := range s.Shard(id).FindPointsWithin(x, y, within) {
+				routed = append(routed, [2]float64{res.X, res.Y})
+			}
+		}
+		var expected [][2]float64
+		for _, res := range global.FindPointsWithin(x, y, within) {
+			expected = append(expected, [2]float64{res.X, res.Y})
+		}
+		sortPoints := func(p [][2]float64) {
+			sort.Slice(p, func(i, j int) bool { return p[i][0] < p[j][0] || (p[i][0] == p[j][0] && p[i][1] < p[j][1]) })
+		}
+		sortPoints(routed)
+		sortPoints(expected)
+		assert.Equal(t, expected, routed)
+
+		box := randomBBox(0.3)
+		var boxed FlatPoints
+		for _, id := range s.Router().RouteBox(box) {
+			boxed = s.Shard(id).SearchInto(box, boxed)
+		}
+		assert.Equal(t, sortedPoints(global.SearchInto(box, nil)), sortedPoints(boxed))
+	}
+
+	// a query whose home shard has no points
+	s = NewSharded(Options{}, FlatPoints{0.1, 0.1, 0.9, 0.9}, grid)
+	assert.Nil(t, s.Shard(grid(0.2, 0.9)))
+	res, shard, found := s.FindNearestPoint(0.2, 0.9)
+	assert.True(t, found)
+	assert.Equal(t, grid(0.9, 0.9), shard)
+	assert.Equal(t, [2]float64{0.9, 0.9}, [2]float64{res.X, res.Y})
+	assert.Nil(t, s.Router().RouteWithin(0, 0, -1))
+	_, _, found = NewSharded(Options{}, nil, grid).FindNearestPoint(0, 0)
+	assert.False(t, found)
+}
@@ -0,0 +1,202 @@
+package SimpleRTree
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// BBox3D is a 3D bbox, boundary included
+type BBox3D struct {
+	Min, Max [3]float64
+}
+
+// Result3D is a point returned by the queries of SimpleRTree3D. Index is the position of the point in the points
+// given to Load, which are not reordered. Distance is the distance squared to the query coordinates
+type Result3D struct {
+	Index    int
+	Coords   [3]float64
+	Distance float64
+}
+
+// SimpleRTree3D is a static 3D RTree of points packed with STR: points are sorted along each axis in turn and cut
+// in slabs, so that leaves are tiles of about the same number of points. Upper levels group consecutive nodes.
+// Queries are safe to call concurrently, Load is not. It is a separate implementation that shares no code with
+// SimpleRTree, so none of its options and only its basic queries are available
+type SimpleRTree3D struct {
+	maxEntries int
+	points     [][3]float64
+	order      []int    // positions of the points in the input, in the order of the leaves
+	nodes      []node3D // root is the last one
+}
+
+type node3D struct {
+	bbox  BBox3D
+	first int // position of the first child in nodes, or of the first point in order for leaves
+	n     int
+	leaf  bool
+}
+
+// New3D returns an empty SimpleRTree3D with MAX_POSSIBLE_SIZE entries per node
+func New3D() *SimpleRTree3D {
+	return &SimpleRTree3D{maxEntries: MAX_POSSIBLE_SIZE}
+}
+
+// Load builds the tree with points, given as consecutive groups of 3 coordinates. points is copied, the caller's
+// array is not modified. It panics if the number of coordinates is not a multiple of 3
+func (r *SimpleRTree3D) Load(points []float64) *SimpleRTree3D {
+	if len(points)%3 != 0 {
+		panic(fmt.Sprintf("%d coordinates is not a multiple of 3", len(points)))
+	}
+	n := len(points) / 3
+	r.points = make([][3]float64, n)
+	r.order = make([]int, n)
+	for i := range r.points {
+		copy(r.points[i][:], points[3*i:])
+		r.order[i] = i
+	}
+	r.nodes = nil
+	if n == 0 {
+		return r
+	}
+	r.tile(r.order, 0, (n+r.maxEntries-1)/r.maxEntries)
+	for start := 0; start < n; start += r.maxEntries {
+		end := minInt(start+r.maxEntries, n)
+		bbox := BBox3D{r.points[r.order[start]], r.points[r.order[start]]}
+		for _, i := range r.order[start+1 : end] {
+			bbox = bbox.extend(BBox3D{r.points[i], r.points[i]})
+		}
+		r.nodes = append(r.nodes, node3D{bbox: bbox, first: start, n: end - start, leaf: true})
+	}
+	for levelStart, levelEnd := 0, len(r.nodes); levelEnd-levelStart > 1; levelStart, levelEnd = levelEnd, len(r.nodes) {
+		for start := levelStart; start < levelEnd; start += r.maxEntries {
+			end := minInt(start+r.maxEntries, levelEnd)
+			bbox := r.nodes[start].bbox
+			for i := start + 1; i < end; i++ {
+				bbox = bbox.extend(r.nodes[i].bbox)
+			}
+			r.nodes = append(r.nodes, node3D{bbox: bbox, first: start, n: end - start})
+		}
+	}
+	return r
+}
+
+// tile sorts order so that each run of maxEntries points is a tile, cutting it along axis in slabs of whole leaves
+func (r *SimpleRTree3D) tile(order []int, axis, leaves int) {
+	sort.Slice(order, func(i, j int) bool {
+		return r.points[order[i]][axis] < r.points[order[j]][axis]
+	})
+	if axis == 3-1 || leaves <= 1 {
+		return
+	}
+	slabs := int(math.Ceil(math.Pow(float64(leaves), 1/float64(3-axis))))
+	leavesPerSlab := (leaves + slabs - 1) / slabs
+	size := leavesPerSlab * r.maxEntries
+	for start := 0; start < len(order); start += size {
+		end := minInt(start+size, len(order))
+		r.tile(order[start:end], axis+1, (end-start+r.maxEntries-1)/r.maxEntries)
+	}
+}
+
+// Len returns the number of points
+func (r *SimpleRTree3D) Len() int {
+	return len(r.points)
+}
+
+// FindNearestPoint returns the closest point to the given coordinates. found is false if the tree is empty
+func (r *SimpleRTree3D) FindNearestPoint(x, y, z float64) (res Result3D, found bool) {
+	if len(r.nodes) == 0 {
+		return
+	}
+	query := [3]float64{x, y, z}
+	q := queue3D{item3D{index: len(r.nodes) - 1}}
+	for len(q) > 0 {
+		item := heap.Pop(&q).(item3D)
+		if item.isPoint {
+			i := r.order[item.index]
+			return Result3D{Index: i, Coords: r.points[i], Distance: item.distance}, true
+		}
+		n := &r.nodes[item.index]
+		for i := n.first; i < n.first+n.n; i++ {
+			if n.leaf {
+				p := r.points[r.order[i]]
+				heap.Push(&q, item3D{index: i, isPoint: true, distance: BBox3D{p, p}.distance(query)})
+			} else {
+				heap.Push(&q, item3D{index: i, distance: r.nodes[i].bbox.distance(query)})
+			}
+		}
+	}
+	return
+}
+
+// Search returns the positions in the input of the points inside box, boundary included.
+// Order of the results is not specified
+func (r *SimpleRTree3D) Search(box BBox3D) []int {
+	var results []int
+	if len(r.nodes) == 0 {
+		return results
+	}
+	stack := []int{len(r.nodes) - 1}
+	for len(stack) > 0 {
+		n := &r.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		for i := n.first; i < n.first+n.n; i++ {
+			if !n.leaf {
+				if box.intersects(r.nodes[i].bbox) {
+					stack = append(stack, i)
+				}
+			} else if p := r.points[r.order[i]]; box.intersects(BBox3D{p, p}) {
+				results = append(results, r.order[i])
+			}
+		}
+	}
+	return results
+}
+
+func (b BBox3D) extend(b2 BBox3D) BBox3D {
+	for axis := 0; axis < 3; axis++ {
+		b.Min[axis] = minFloat(b.Min[axis], b2.Min[axis])
+		b.Max[axis] = maxFloat(b.Max[axis], b2.Max[axis])
+	}
+	return b
+}
+
+func (b BBox3D) intersects(b2 BBox3D) bool {
+	for axis := 0; axis < 3; axis++ {
+		if b2.Min[axis] > b.Max[axis] || b2.Max[axis] < b.Min[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+// distance returns the distance squared from p to the closest point of the bbox
+func (b BBox3D) distance(p [3]float64) float64 {
+	d := 0.
+	for axis := 0; axis < 3; axis++ {
+		delta := maxFloat(maxFloat(b.Min[axis]-p[axis], p[axis]-b.Max[axis]), 0)
+		d += delta * delta
+	}
+	return d
+}
+
+// queue3D is a min heap of nodes and points on their distance to the query
+type queue3D []item3D
+
+type item3D struct {
+	index    int // position in nodes, or in order if isPoint is set
+	isPoint  bool
+	distance float64
+}
+
+func (q queue3D) Len() int            { return len(q) }
+func (q queue3D) Less(i, j int) bool  { return q[i].distance < q[j].distance }
+func (q queue3D) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *queue3D) Push(x interface{}) { *q = append(*q, x.(item3D)) }
+func (q *queue3D) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,51 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSimpleRTree3D(t *testing.T) {
+	for _, size := range []int{1, 9, 10, 1000, 20000} {
+		points := make([]float64, size*3)
+		for i := range points {
+			points[i] = rand.Float64()
+		}
+		original := append([]float64{}, points...)
+		r := New3D().Load(points)
+		assert.Equal(t, original, points, "Caller's points are not modified")
+		assert.Equal(t, size, r.Len())
+		for n := 0; n < 100; n++ {
+			x, y, z := rand.Float64(), rand.Float64(), rand.Float64()
+			res, found := r.FindNearestPoint(x, y, z)
+			assert.True(t, found)
+			expected := -1.
+			for i := 0; i < size; i++ {
+				dx, dy, dz := points[3*i]-x, points[3*i+1]-y, points[3*i+2]-z
+				if d := dx*dx + dy*dy + dz*dz; expected < 0 || d < expected {
+					expected = d
+				}
+			}
+			assert.Equal(t, expected, res.Distance)
+			assert.Equal(t, [3]float64{points[3*res.Index], points[3*res.Index+1], points[3*res.Index+2]}, res.Coords)
+
+			box := BBox3D{Min: [3]float64{x, y, z}, Max: [3]float64{x + 0.3, y + 0.3, z + 0.3}}
+			var inside []int
+			for i := 0; i < size; i++ {
+				p := [3]float64{points[3*i], points[3*i+1], points[3*i+2]}
+				if box.intersects(BBox3D{p, p}) {
+					inside = append(inside, i)
+				}
+			}
+			results := r.Search(box)
+			sort.Ints(results)
+			assert.Equal(t, inside, results)
+		}
+	}
+	_, found := New3D().Load(nil).FindNearestPoint(0, 0, 0)
+	assert.False(t, found)
+	assert.Empty(t, New3D().Search(BBox3D{Max: [3]float64{1, 1, 1}}))
+	assert.Panics(t, func() { New3D().Load([]float64{1, 2}) })
+}
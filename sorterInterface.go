@@ -0,0 +1,128 @@
+package SimpleRTree
+
+// This is copy paste from floyd rivest over sort.Interface. It is only used for points provided through Interface,
+// FlatPoints use the concrete sorters which are 2.5x faster
+
+import (
+	"math"
+	"sort"
+)
+
+// Buckets. Sort a slice into buckets of given size. All elements from one bucket are smaller than any element  from the next one.
+// elements at position i * bucketSize are guaranteed to be the (i * bucketSize) th smallest elements
+// s := // some slice
+// FloydRivest.Buckets(sort.Interface(s), 5)
+// s is now sorted into buckets of size 5
+// max(s[0:5]) < min(s[5:10])
+// max(s[10: 15]) < min(s[15:20])
+// ...
+func bucketsInterface(slice sort.Interface, bucketSize int, buffer []int) {
+	left := 0
+	right := slice.Len() - 1
+	stack := buffer[:0]
+	stack = append(stack, left)
+	stack = append(stack, right)
+	s := interfaceSorterStack(stack)
+	var mid int
+	for len(s) > 0 {
+		s, right = s.pop()
+		s, left = s.pop()
+		// right is inclusive, the interval holds right - left + 1 elements
+		if right-left < bucketSize {
+			continue
+		}
+		// + bucketSize is to do math ceil of the number of elements
+		mid = left + ((right-left+bucketSize)/bucketSize/2)*bucketSize
+		selectInterface(slice, mid, left, right)
+
+		s = s.push(left)
+		s = s.push(mid - 1)
+		s = s.push(mid)
+		s = s.push(right)
+	}
+}
+
+// left is the left index for the interval
+// right is the right index for the interval
+// k is the desired index value, where array[k] is the k+1 smallest element
+// when left = 0
+func selectInterface(array sort.Interface, k, left, right int) {
+	length := array.Len()
+	for right > left {
+		if right-left > 600 {
+			var n = float64(right - left + 1)
+			var kf = float64(k)
+			var m = float64(k - left + 1)
+			var z = math.Log(n)
+			var s = 0.5 * math.Exp(2*z/3)
+			sign := float64(1)
+			if m-n/2 < 0 {
+				sign = -1
+			}
+			var sd = 0.5 * math.Sqrt(z*s*(n-s)/n) * sign
+			var newLeft = interfaceSorterMax(left, int(math.Floor(kf-m*s/n+sd)))
+			var newRight = interfaceSorterMin(right, int(math.Floor(kf+(n-m)*s/n+sd)))
+			selectInterface(array, k, newLeft, newRight)
+		}
+
+		var i = left
+		var j = right
+		array.Swap(left, k)
+		// in the original algorithm array[k] is stored to a value. To use golangs sort interface we need to keep track of the changes for the index
+		// we define it as right because in the first iteration of for i<j it will be changed
+		pointIndex := right
+		if array.Less(left, right) {
+			array.Swap(left, right)
+			pointIndex = left
+		}
+
+		for i < j {
+			// pointIndex is swapped only once in the first iteration. Later it will either be bigger (if left) or smaller (if right)
+			array.Swap(i, j)
+			i++
+			j--
+			for i < length && array.Less(i, pointIndex) {
+				i++
+			}
+			for j >= 0 && array.Less(pointIndex, j) {
+				j--
+			}
+		}
+		if !array.Less(left, pointIndex) && !array.Less(pointIndex, left) {
+			array.Swap(left, j)
+		} else {
+			j++
+			array.Swap(j, right)
+		}
+		if j <= k {
+			left = j + 1
+		}
+		if k <= j {
+			right = j - 1
+		}
+	}
+}
+
+func interfaceSorterMin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func interfaceSorterMax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+type interfaceSorterStack []int
+
+func (s interfaceSorterStack) push(v int) interfaceSorterStack {
+	return append(s, v)
+}
+func (s interfaceSorterStack) pop() (interfaceSorterStack, int) {
+	l := len(s)
+	return s[:l-1], s[l-1]
+}
@@ -25,15 +25,16 @@ func bucketsY(slice ySorter, bucketSize int, buffer []int) {
 	for len(s) > 0 {
 		s, right = s.pop()
 		s, left = s.pop()
-		if right-left <= bucketSize {
+		// right is inclusive, the interval holds right - left + 1 elements
+		if right-left < bucketSize {
 			continue
 		}
-		// + bucketSize - 1 is to do math ceil
-		mid = left + ((right-left+bucketSize-1)/bucketSize/2)*bucketSize
+		// + bucketSize is to do math ceil of the number of elements
+		mid = left + ((right-left+bucketSize)/bucketSize/2)*bucketSize
 		selectY(slice, mid, left, right)
 
 		s = s.push(left)
-		s = s.push(mid)
+		s = s.push(mid - 1)
 		s = s.push(mid)
 		s = s.push(right)
 	}
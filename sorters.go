@@ -47,3 +47,49 @@ func (s ySorter) Sort(buffer []int) {
 	// we already do the shifting on the sort functions
 	bucketsY(s, s.bucketSize, buffer)
 }
+
+type interfaceXSorter struct {
+	points                 Interface
+	start, end, bucketSize int
+}
+
+func (s interfaceXSorter) Less(i, j int) bool {
+	x1, _ := s.points.GetPointAt(i + s.start)
+	x2, _ := s.points.GetPointAt(j + s.start)
+	return x1 < x2
+}
+
+func (s interfaceXSorter) Swap(i, j int) {
+	s.points.Swap(i+s.start, j+s.start)
+}
+
+func (s interfaceXSorter) Len() int {
+	return s.end - s.start
+}
+
+func (s interfaceXSorter) Sort(buffer []int) {
+	bucketsInterface(s, s.bucketSize, buffer)
+}
+
+type interfaceYSorter struct {
+	points                 Interface
+	start, end, bucketSize int
+}
+
+func (s interfaceYSorter) Less(i, j int) bool {
+	_, y1 := s.points.GetPointAt(i + s.start)
+	_, y2 := s.points.GetPointAt(j + s.start)
+	return y1 < y2
+}
+
+func (s interfaceYSorter) Swap(i, j int) {
+	s.points.Swap(i+s.start, j+s.start)
+}
+
+func (s interfaceYSorter) Len() int {
+	return s.end - s.start
+}
+
+func (s interfaceYSorter) Sort(buffer []int) {
+	bucketsInterface(s, s.bucketSize, buffer)
+}
@@ -0,0 +1,148 @@
+package SimpleRTree
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+// spillBufferSize is the smallest buffer used to write or read a run of the spilling sort
+const spillBufferSize = 4096
+
+// spillSort sorts points lexicographically with an external merge sort when Options.MaxBuildMemory is set and the
+// points of an STR build take more bytes than it, and returns whether the points are sorted, so that the build
+// skips the split of the root along x. It returns isSorted unchanged when the sort does not apply.
+// Points are sorted in runs of at most MaxBuildMemory bytes that are written to a temp file and merged back into
+// points, so the build reads and writes them sequentially instead of selecting over the whole array, which suits
+// arrays larger than the memory comfortably available, for example backed by a memory mapped file. The nodes are the
+// same as the ones built in memory when x coordinates are distinct, ties can be split differently. If writing or reading the temp file fails the points may have been partially
+// overwritten
+func (r *SimpleRTree) spillSort(points Interface, isSorted bool) (bool, error) {
+	fp, ok := points.(FlatPoints)
+	if !ok || isSorted || r.options.MaxBuildMemory == 0 || r.options.TreeType != STR || r.sortKey != nil {
+		return isSorted, nil
+	}
+	if fp.Len() < r.options.LinearScanThreshold {
+		return isSorted, nil
+	}
+	if fp.Len()*int(flat_point_size) <= r.options.MaxBuildMemory {
+		return isSorted, nil
+	}
+	// tiny runs would only add runs to merge
+	runSize := maxInt(r.options.MaxBuildMemory, spillBufferSize) / int(flat_point_size)
+	if err := externalSort(fp, runSize); err != nil {
+		return false, fmt.Errorf("spilling the points of the build: %w", err)
+	}
+	return true, nil
+}
+
+// externalSort sorts points lexicographically, sorting runs of runSize points in place, writing them to a temp file
+// and merging them back into points. The buffers of the merge share runSize points worth of memory, they are released
+// before the build allocates its nodes
+func externalSort(points FlatPoints, runSize int) (err error) {
+	f, err := os.CreateTemp("", "SimpleRTree-runs-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if removeErr := os.Remove(f.Name()); err == nil {
+			err = removeErr
+		}
+	}()
+
+	w := bufio.NewWriterSize(f, spillBufferSize)
+	scratch := make([]byte, 8)
+	var runs []*spillRun
+	for start := 0; start < points.Len(); start += runSize {
+		end := minInt(start+runSize, points.Len())
+		run := points[2*start : 2*end]
+		sort.Sort(lexicographicSorter(run))
+		for _, c := range run {
+			binary.LittleEndian.PutUint64(scratch, math.Float64bits(c))
+			if _, err := w.Write(scratch); err != nil {
+				return err
+			}
+		}
+		runs = append(runs, &spillRun{offset: int64(start) * int64(flat_point_size), left: end - start})
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	bufferSize := maxInt(runSize*int(flat_point_size)/len(runs), spillBufferSize)
+	merge := make(spillMerge, 0, len(runs))
+	for _, run := range runs {
+		run.r = bufio.NewReaderSize(io.NewSectionReader(f, run.offset, int64(run.left)*int64(flat_point_size)), bufferSize)
+		if err := run.next(); err != nil {
+			return err
+		}
+		merge = append(merge, run)
+	}
+	heap.Init(&merge)
+	for i := 0; len(merge) > 0; i++ {
+		run := merge[0]
+		points[2*i], points[2*i+1] = run.x, run.y
+		if run.left == 0 {
+			heap.Pop(&merge)
+			continue
+		}
+		if err := run.next(); err != nil {
+			return err
+		}
+		heap.Fix(&merge, 0)
+	}
+	return nil
+}
+
+// spillRun is a sorted run of the temp file of externalSort and its smallest point not merged yet
+type spillRun struct {
+	r      *bufio.Reader
+	buffer [16]byte
+	offset int64
+	left   int // points not read yet
+	x, y   float64
+}
+
+// next reads the following point of the run
+func (run *spillRun) next() error {
+	if _, err := io.ReadFull(run.r, run.buffer[:]); err != nil {
+		return err
+	}
+	run.x = math.Float64frombits(binary.LittleEndian.Uint64(run.buffer[:8]))
+	run.y = math.Float64frombits(binary.LittleEndian.Uint64(run.buffer[8:]))
+	run.left--
+	return nil
+}
+
+// spillMerge is a heap of the runs being merged by their smallest point
+type spillMerge []*spillRun
+
+func (m spillMerge) Len() int { return len(m) }
+func (m spillMerge) Less(i, j int) bool {
+	return m[i].x < m[j].x || m[i].x == m[j].x && m[i].y < m[j].y
+}
+func (m spillMerge) Swap(i, j int)       { m[i], m[j] = m[j], m[i] }
+func (m *spillMerge) Push(x interface{}) { *m = append(*m, x.(*spillRun)) }
+func (m *spillMerge) Pop() interface{} {
+	old := *m
+	run := old[len(old)-1]
+	*m = old[:len(old)-1]
+	return run
+}
+
+// lexicographicSorter sorts points by x and then by y, the order LoadSortedArray expects
+type lexicographicSorter FlatPoints
+
+func (s lexicographicSorter) Len() int { return FlatPoints(s).Len() }
+func (s lexicographicSorter) Less(i, j int) bool {
+	return s[2*i] < s[2*j] || s[2*i] == s[2*j] && s[2*i+1] < s[2*j+1]
+}
+func (s lexicographicSorter) Swap(i, j int) { FlatPoints(s).Swap(i, j) }
@@ -0,0 +1,74 @@
+package SimpleRTree
+
+import "fmt"
+
+// LoadWithTimestamps builds the RTree over points with a timestamp per point, for example the time of the last
+// position report of a tracked vehicle, in any unit as long as it grows with time. Timestamps are reordered together
+// with the points, so that timestamps[i] stays the timestamp of the point at position i, and are used by
+// FindNearestPointFresherThan. Rebuild drops the timestamps, it does not receive new ones.
+// RebuildRegion keeps the timestamp of every point.
+//
+// Note: rtree is assumed to have sole access to points and timestamps, it will reorder them together
+func (r *SimpleRTree) LoadWithTimestamps(points FlatPoints, timestamps []float64) *SimpleRTree {
+	if len(timestamps) != points.Len() {
+		panic(fmt.Sprintf("%d timestamps for %d points", len(timestamps), points.Len()))
+	}
+	r.load(attrPoints{points: points, values: [][]float64{timestamps}}, false)
+	// the points were sorted in place, from now on they are read directly as in Load
+	r.points, r.source, r.leafPoints = points, nil, nil
+	r.timestamps = timestamps
+	return r
+}
+
+// Timestamp returns the timestamp of the point at position idx and true, or false if the tree was not loaded with
+// timestamps, see LoadWithTimestamps
+func (r *SimpleRTree) Timestamp(idx int) (float64, bool) {
+	if r.timestamps == nil {
+		return 0, false
+	}
+	return r.timestamps[idx], true
+}
+
+// SetTimestamp updates the timestamp of the point at position idx, for example when a tracked object reports again
+// from the same position. Timestamps are only checked on the points, so updates cost nothing and can happen between
+// queries. It panics if the tree was not loaded with timestamps.
+//
+// SetTimestamp is not safe to call concurrently with queries
+func (r *SimpleRTree) SetTimestamp(idx int, timestamp float64) {
+	if r.timestamps == nil {
+		panic("tree was not loaded with timestamps")
+	}
+	r.timestamps[idx] = timestamp
+}
+
+// FindNearestPointFresherThan returns the closest point to x and y whose timestamp is at least minTimestamp, so that
+// stale points are ignored, see LoadWithTimestamps. Nodes are pruned by distance only and timestamps are checked on
+// the points of the leaves visited, so queries slow down when most of the nearby points are stale.
+// Trees loaded without timestamps have no fresh points. Disabled points are skipped
+func (r *SimpleRTree) FindNearestPointFresherThan(x, y, minTimestamp float64) (res Result, found bool) {
+	if r.timestamps == nil {
+		return
+	}
+	r.bestFirst(
+		func(bbox VectorBBox) (float64, bool) {
+			mind, _ := computeDistances(bbox, x, y, r.insideFactor)
+			return mind, true
+		},
+		func(i int, px, py float64) (float64, bool) {
+			if r.timestamps[i] < minTimestamp || (r.disabled != nil && r.disabled[i]) {
+				return 0, false
+			}
+			_, _, d := r.pointDistance(i, x, y)
+			return d, true
+		},
+		func(i int, px, py, d float64) bool {
+			res = Result{Index: i, X: px, Y: py, Distance: d}
+			found = true
+			return false
+		},
+	)
+	if found && r.options.RobustDistance {
+		res.Distance = r.finalDistance(res.X, res.Y, x, y)
+	}
+	return
+}
@@ -0,0 +1,74 @@
+package SimpleRTree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRTree_FindNearestPointFresherThan(t *testing.T) {
+	const size = 5000
+	points := generateDataset(clusteredDataset, size, rand.Int63())
+	original := append(FlatPoints(nil), points...)
+	// timestamps are the original positions, so that they tell which point they belong to
+	timestamps := make([]float64, size)
+	for i := range timestamps {
+		timestamps[i] = float64(i)
+	}
+	r := New().LoadWithTimestamps(points, timestamps)
+	for i := 0; i < size; i++ {
+		x, y := points.GetPointAt(i)
+		ox, oy := original.GetPointAt(int(timestamps[i]))
+		assert.Equal(t, [2]float64{ox, oy}, [2]float64{x, y})
+		ts, ok := r.Timestamp(i)
+		assert.True(t, ok)
+		assert.Equal(t, timestamps[i], ts)
+	}
+	r.SetEnabled(0, false)
+
+	for n := 0; n < 300; n++ {
+		x, y := rand.Float64(), rand.Float64()
+		minTimestamp := float64(rand.Intn(size + 1))
+		expected, expectedFound := math.Inf(1), false
+		for i := 0; i < size; i++ {
+			if timestamps[i] >= minTimestamp && i != 0 {
+				_, _, d := r.pointDistance(i, x, y)
+				expected, expectedFound = math.Min(expected, d), true
+			}
+		}
+		res, found := r.FindNearestPointFresherThan(x, y, minTimestamp)
+		assert.Equal(t, expectedFound, found)
+		if found {
+			assert.Equal(t, expected, res.Distance)
+			assert.True(t, timestamps[res.Index] >= minTimestamp)
+			px, py := r.getPointAt(res.Index)
+			assert.Equal(t, [2]float64{px, py}, [2]float64{res.X, res.Y})
+		}
+	}
+
+	// a report refreshes a point
+	res, _ := r.FindNearestPointFresherThan(0.5, 0.5, 0)
+	_, found := r.FindNearestPointFresherThan(res.X, res.Y, size)
+	assert.False(t, found)
+	r.SetTimestamp(res.Index, size)
+	refreshed, found := r.FindNearestPointFresherThan(res.X, res.Y, size)
+	assert.True(t, found)
+	assert.Equal(t, res.Index, refreshed.Index)
+
+	assert.Panics(t, func() {
+		New().LoadWithTimestamps(FlatPoints{0, 0, 1, 1}, []float64{1})
+	})
+	p := New().Load(FlatPoints{0, 0, 1, 1})
+	_, found = p.FindNearestPointFresherThan(0, 0, math.Inf(-1))
+	assert.False(t, found)
+	_, ok := p.Timestamp(0)
+	assert.False(t, ok)
+	assert.Panics(t, func() {
+		p.SetTimestamp(0, 1)
+	})
+	assert.NoError(t, r.Rebuild(FlatPoints{0, 0, 1, 1}))
+	_, ok = r.Timestamp(0)
+	assert.False(t, ok)
+}
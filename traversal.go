@@ -0,0 +1,115 @@
+package SimpleRTree
+
+import "math"
+
+// traversalItem is a node or a point waiting to be visited by bestFirst
+type traversalItem struct {
+	index    int // position in nodes, or in points if isPoint is set
+	isPoint  bool
+	height   int // height of the node, see bestFirstNodes
+	priority float64
+}
+
+// traversalQueue is a binary min heap on priority. Unlike searchQueue it is meant for queries that can hold many
+// items at the same time
+type traversalQueue []traversalItem
+
+func (q *traversalQueue) push(item traversalItem) {
+	*q = append(*q, item)
+	h := *q
+	i := len(h) - 1
+	for i > 0 {
+		parent := (i - 1) / 2
+		if h[parent].priority <= h[i].priority {
+			break
+		}
+		h[parent], h[i] = h[i], h[parent]
+		i = parent
+	}
+}
+
+func (q *traversalQueue) pop() traversalItem {
+	h := *q
+	top := h[0]
+	last := len(h) - 1
+	h[0] = h[last]
+	h = h[:last]
+	i := 0
+	for {
+		smallest := i
+		left, right := 2*i+1, 2*i+2
+		if left < len(h) && h[left].priority < h[smallest].priority {
+			smallest = left
+		}
+		if right < len(h) && h[right].priority < h[smallest].priority {
+			smallest = right
+		}
+		if smallest == i {
+			break
+		}
+		h[i], h[smallest] = h[smallest], h[i]
+		i = smallest
+	}
+	*q = h
+	return top
+}
+
+// bestFirst visits the points of the tree in increasing order of priority.
+// nodePriority returns the priority of a node from its bbox, it must be a lower bound of the priority of every point
+// inside it. pointPriority returns the priority of the point at position i. Both can return false to skip the node
+// or point. visit is called on every point in order of priority until it returns false.
+func (r *SimpleRTree) bestFirst(
+	nodePriority func(bbox VectorBBox) (float64, bool),
+	pointPriority func(i int, x, y float64) (float64, bool),
+	visit func(i int, x, y, priority float64) bool,
+) {
+	r.bestFirstNodes(
+		func(i, height int) (float64, bool) {
+			return nodePriority(r.nodes[i].BBox)
+		},
+		pointPriority,
+		visit,
+	)
+}
+
+// bestFirstNodes is bestFirst for queries that need to know which node they are pruning. nodePriority receives the
+// position of the node in nodes and its height, counted as in GeoJSONFilter
+func (r *SimpleRTree) bestFirstNodes(
+	nodePriority func(i, height int) (float64, bool),
+	pointPriority func(i int, x, y float64) (float64, bool),
+	visit func(i int, x, y, priority float64) bool,
+) {
+	if !r.built || len(r.nodes) == 0 {
+		return
+	}
+	// root bbox is not always computed, so it is always visited
+	q := make(traversalQueue, 0, r.height*r.options.MAX_ENTRIES+1)
+	q.push(traversalItem{index: 0, height: r.height, priority: math.Inf(-1)})
+	for len(q) > 0 {
+		item := q.pop()
+		if item.isPoint {
+			x, y := r.getPointAt(item.index)
+			if !visit(item.index, x, y, item.priority) {
+				return
+			}
+			continue
+		}
+		n := &r.nodes[item.index]
+		if n.nodeType == preleaf_node {
+			start := n.firstPointIndex()
+			for i := start; i < start+int(n.nChildren); i++ {
+				x, y := r.getPointAt(i)
+				if priority, ok := pointPriority(i, x, y); ok {
+					q.push(traversalItem{index: i, isPoint: true, priority: priority})
+				}
+			}
+			continue
+		}
+		first := n.firstChildIndex()
+		for i := first; i < first+int(n.nChildren); i++ {
+			if priority, ok := nodePriority(i, item.height-1); ok {
+				q.push(traversalItem{index: i, height: item.height - 1, priority: priority})
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package SimpleRTree
+
+import "math"
+
+// TuneMaxEntries recommends a value of MAX_ENTRIES for the given points and a sample of the expected queries.
+// It builds a tree for every possible MAX_ENTRIES and runs the sample queries on each of them, counting the
+// nodes visited. A node is visited when its distance to the query is computed, so the count reflects the work
+// done by the query. The value with the lowest average number of visited nodes is returned, the smallest one if
+// several are tied.
+//
+// This is an offline tuning aid, it builds several trees and it is much slower than a single Load.
+// Points are copied, so their order is not modified.
+func TuneMaxEntries(points FlatPoints, sampleQueries FlatPoints) int {
+	best := MAX_POSSIBLE_SIZE
+	if points.Len() == 0 || sampleQueries.Len() == 0 {
+		return best
+	}
+	bestAverage := math.Inf(1)
+	buffer := make(FlatPoints, len(points))
+	for maxEntries := 2; maxEntries <= MAX_POSSIBLE_SIZE; maxEntries++ {
+		copy(buffer, points)
+		r := NewWithOptions(Options{MAX_ENTRIES: maxEntries, UnsafeConcurrencyMode: true}).Load(buffer)
+		visited := 0
+		for i := 0; i < sampleQueries.Len(); i++ {
+			x, y := sampleQueries.GetPointAt(i)
+			r.findNearestPointWithin(x, y, math.Inf(1), &visited)
+		}
+		average := float64(visited) / float64(sampleQueries.Len())
+		if average < bestAverage {
+			bestAverage = average
+			best = maxEntries
+		}
+	}
+	return best
+}
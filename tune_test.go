@@ -0,0 +1,56 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestTuneMaxEntries(t *testing.T) {
+	const size = 5000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	queries := make([]float64, 200)
+	for i := range queries {
+		queries[i] = rand.Float64()
+	}
+	original := append([]float64{}, points...)
+	best := TuneMaxEntries(FlatPoints(points), FlatPoints(queries))
+	assert.True(t, best >= 2 && best <= MAX_POSSIBLE_SIZE)
+	assert.Equal(t, original, points, "Points are not reordered")
+
+	// the recommended value visits no more nodes than other fanouts on the same queries
+	bestVisited := averageVisited(FlatPoints(points), FlatPoints(queries), best)
+	for _, maxEntries := range []int{2, 3, 5, 7, MAX_POSSIBLE_SIZE} {
+		assert.True(t, bestVisited <= averageVisited(FlatPoints(points), FlatPoints(queries), maxEntries), "%d against %d", best, maxEntries)
+	}
+	assert.Equal(t, MAX_POSSIBLE_SIZE, TuneMaxEntries(FlatPoints{}, FlatPoints(queries)))
+}
+
+func TestTuneMaxEntriesSmall(t *testing.T) {
+	points := generateDataset(uniformDataset, 40, rand.Int63())
+	queries := generateDataset(uniformDataset, 100, rand.Int63())
+	best := TuneMaxEntries(append(FlatPoints{}, points...), queries)
+	assert.True(t, best >= 2 && best <= MAX_POSSIBLE_SIZE)
+	for maxEntries := 2; maxEntries <= MAX_POSSIBLE_SIZE; maxEntries++ {
+		assert.True(t, averageVisited(points, queries, best) <= averageVisited(points, queries, maxEntries), "%d against %d", best, maxEntries)
+	}
+
+	// every fanout builds the same single leaf for two points, ties go to the smallest value
+	assert.Equal(t, 2, TuneMaxEntries(FlatPoints{0, 0, 1, 1}, queries))
+}
+
+// averageVisited returns the average number of nodes and points visited by the nearest point queries on a tree of
+// points with maxEntries
+func averageVisited(points, queries FlatPoints, maxEntries int) float64 {
+	r := NewWithOptions(Options{MAX_ENTRIES: maxEntries}).Load(append(FlatPoints{}, points...))
+	visited := 0
+	for i := 0; i < queries.Len(); i++ {
+		x, y := queries.GetPointAt(i)
+		r.findNearestPointWithin(x, y, math.Inf(1), &visited)
+	}
+	return float64(visited) / float64(queries.Len())
+}
@@ -1,27 +1,31 @@
 package SimpleRTree
 
-type rVectorBBox [4]float64
+// VectorBBox is a bbox stored as an array, the layout used by the nodes of the tree so that bboxes can be
+// processed as vectors. Components are indexed with the VECTOR_BBOX constants
+type VectorBBox [4]float64
 
+// Positions of the components of a VectorBBox
 const (
-	vector_bbox_min_x = 0
-	vector_bbox_min_y = 1
-	vector_bbox_max_x = 2
-	vector_bbox_max_y = 3
+	VECTOR_BBOX_MIN_X = 0
+	VECTOR_BBOX_MIN_Y = 1
+	VECTOR_BBOX_MAX_X = 2
+	VECTOR_BBOX_MAX_Y = 3
 )
 
-func newVectorBBox(MinX, MinY, MaxX, MaxY float64) rVectorBBox {
+func newVectorBBox(MinX, MinY, MaxX, MaxY float64) VectorBBox {
 	return [4]float64{MinX, MinY, MaxX, MaxY}
 }
 
-func bbox2VectorBBox(b rBBox) rVectorBBox {
+// BBoxToVector returns b as a VectorBBox
+func BBoxToVector(b BBox) VectorBBox {
 	return newVectorBBox(b.MinX, b.MinY, b.MaxX, b.MaxY)
 }
 
-/**
-Code from
-https://github.com/slimsag/rand/blob/master/simd/vec64.go
-*/
-func vectorBBoxExtend(b1, b2 rVectorBBox) rVectorBBox {
+// VectorBBoxExtend returns the smallest bbox containing b1 and b2.
+//
+// Code from
+// https://github.com/slimsag/rand/blob/master/simd/vec64.go
+func VectorBBoxExtend(b1, b2 VectorBBox) VectorBBox {
 	return [4]float64{
 		minFloat(b1[0], b2[0]),
 		minFloat(b1[1], b2[1]),
@@ -30,11 +34,12 @@ func vectorBBoxExtend(b1, b2 rVectorBBox) rVectorBBox {
 	}
 }
 
-func (b1 rVectorBBox) toBBox() rBBox {
-	return rBBox{
-		MinX: b1[vector_bbox_min_x],
-		MinY: b1[vector_bbox_min_y],
-		MaxX: b1[vector_bbox_max_x],
-		MaxY: b1[vector_bbox_max_y],
+// ToBBox returns b1 as a BBox, it is the inverse of BBoxToVector
+func (b1 VectorBBox) ToBBox() BBox {
+	return BBox{
+		MinX: b1[VECTOR_BBOX_MIN_X],
+		MinY: b1[VECTOR_BBOX_MIN_Y],
+		MaxX: b1[VECTOR_BBOX_MAX_X],
+		MaxY: b1[VECTOR_BBOX_MAX_Y],
 	}
 }
@@ -0,0 +1,92 @@
+package SimpleRTree
+
+import (
+	"fmt"
+	"math"
+)
+
+// verifyBBoxes checks that the bbox of every node matches the bbox of its points computed again with plain scalar
+// comparisons, independently of VectorBBoxExtend and of the bboxes of the children stored in the nodes.
+// Min and max are exact operations, so boxes must match exactly. See Options.VerifyBBoxes
+func (r *SimpleRTree) verifyBBoxes() error {
+	if len(r.nodes) == 0 {
+		return nil
+	}
+	var verify func(i int) (minX, minY, maxX, maxY float64, err error)
+	verify = func(i int) (minX, minY, maxX, maxY float64, err error) {
+		minX, minY, maxX, maxY = math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+		n := &r.nodes[i]
+		if n.nodeType == preleaf_node {
+			for j := n.firstPointIndex(); j < n.firstPointIndex()+int(n.nChildren); j++ {
+				x, y := r.getPointAt(j)
+				if x < minX {
+					minX = x
+				}
+				if x > maxX {
+					maxX = x
+				}
+				if y < minY {
+					minY = y
+				}
+				if y > maxY {
+					maxY = y
+				}
+			}
+		} else {
+			for j := n.firstChildIndex(); j < n.firstChildIndex()+int(n.nChildren); j++ {
+				cMinX, cMinY, cMaxX, cMaxY, err := verify(j)
+				if err != nil {
+					return minX, minY, maxX, maxY, err
+				}
+				if cMinX < minX {
+					minX = cMinX
+				}
+				if cMaxX > maxX {
+					maxX = cMaxX
+				}
+				if cMinY < minY {
+					minY = cMinY
+				}
+				if cMaxY > maxY {
+					maxY = cMaxY
+				}
+			}
+		}
+		// the root does not always store its bbox
+		if i == 0 && n.nodeType != preleaf_node {
+			return
+		}
+		if expected := (VectorBBox{minX, minY, maxX, maxY}); n.BBox != expected {
+			err = fmt.Errorf("%w: node %d has %v, its points span %v", ErrBBoxMismatch, i, n.BBox, expected)
+		}
+		return
+	}
+	_, _, _, _, err := verify(0)
+	return err
+}
+
+// verifyNearest checks a result of findNearestPointWithin against a scan of all the enabled points and panics if the
+// tree missed a closer point, reported a wrong distance or found a point where there is none.
+// See Options.VerifyAgainstBruteForce
+func (r *SimpleRTree) verifyNearest(x, y, dsquared float64, res Result, found bool) {
+	best, bestDistance := -1, math.Inf(1)
+	for i := 0; i < r.getLen(); i++ {
+		if r.disabled != nil && r.disabled[i] {
+			continue
+		}
+		if _, _, d := r.pointDistance(i, x, y); d <= dsquared && d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	switch {
+	case found != (best != -1):
+		panic(fmt.Sprintf("nearest point to (%v, %v) within %v: tree found %v, a scan found point %d at distance %v", x, y, dsquared, found, best, bestDistance))
+	case !found:
+		return
+	case res.Distance != bestDistance:
+		panic(fmt.Sprintf("nearest point to (%v, %v): tree returned %+v, a scan found point %d at distance %v", x, y, res, best, bestDistance))
+	}
+	if px, py := r.getPointAt(res.Index); px != res.X || py != res.Y || (r.disabled != nil && r.disabled[res.Index]) {
+		panic(fmt.Sprintf("nearest point to (%v, %v): tree returned %+v, which is not an enabled point at (%v, %v)", x, y, res, px, py))
+	}
+}
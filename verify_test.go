@@ -0,0 +1,63 @@
+package SimpleRTree
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleRTree_VerifyBBoxes(t *testing.T) {
+	for _, kind := range datasetKinds {
+		for _, options := range []Options{{VerifyBBoxes: true}, {VerifyBBoxes: true, TreeType: HILBERT}, {VerifyBBoxes: true, MAX_ENTRIES: 2}} {
+			points := generateDataset(kind, 1000, rand.Int63())
+			var r *SimpleRTree
+			assert.NotPanics(t, func() {
+				r = NewWithOptions(options).Load(points)
+			})
+			assert.NoError(t, r.verifyBBoxes())
+			assert.NoError(t, r.Rebuild(generateDataset(kind, 500, rand.Int63())))
+		}
+	}
+
+	// a wrong extend of a single coordinate is detected
+	r := New().Load(generateDataset(uniformDataset, 1000, 1))
+	leaf := r.cellNodes(0)[3]
+	bbox := r.nodes[leaf].BBox
+	r.nodes[leaf].BBox[VECTOR_BBOX_MAX_Y] += 1e-9
+	assert.ErrorIs(t, r.verifyBBoxes(), ErrBBoxMismatch)
+	r.nodes[leaf].BBox = bbox
+	assert.NoError(t, r.verifyBBoxes())
+	// so is a parent that does not include all of its children
+	r.nodes[r.nodes[0].firstChildIndex()].BBox[VECTOR_BBOX_MIN_X] += 0.01
+	assert.ErrorIs(t, r.verifyBBoxes(), ErrBBoxMismatch)
+}
+
+func TestSimpleRTree_VerifyAgainstBruteForce(t *testing.T) {
+	for _, kind := range datasetKinds {
+		points := generateDataset(kind, 2000, rand.Int63())
+		r := NewWithOptions(Options{VerifyAgainstBruteForce: true}).Load(points)
+		r.SetEnabled(0, false)
+		assert.NotPanics(t, func() {
+			for n := 0; n < 100; n++ {
+				x, y := rand.Float64(), rand.Float64()
+				r.FindNearestPoint(x, y)
+				r.FindNearestPointWithin(x, y, 0.001)
+			}
+		})
+	}
+
+	// a leaf whose bbox no longer covers its points is pruned by mistake
+	points := generateDataset(uniformDataset, 2000, 1)
+	r := NewWithOptions(Options{VerifyAgainstBruteForce: true}).Load(points)
+	leaf := r.cellNodes(0)[5]
+	x, y := r.getPointAt(r.nodes[leaf].firstPointIndex())
+	r.nodes[leaf].BBox = VectorBBox{10, 10, 11, 11}
+	assert.Panics(t, func() {
+		r.FindNearestPoint(x, y)
+	})
+	r.options.VerifyAgainstBruteForce = false
+	assert.NotPanics(t, func() {
+		r.FindNearestPoint(x, y)
+	})
+}
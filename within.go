@@ -0,0 +1,74 @@
+package SimpleRTree
+
+import "context"
+
+// FindPointsWithin returns all the points at distance d or less from the coordinates x and y.
+// Unlike FindNearestPointWithin, d is a plain distance, not a squared one.
+//
+// Distances are compared in squared space, a point is returned if
+//  (px - x) * (px - x) + (py - y) * (py - y) <= (d + epsilon) * (d + epsilon)
+// where epsilon is Options.WithinEpsilon. Since (d + epsilon)^2 = d^2 + 2 * d * epsilon + epsilon^2, epsilon widens
+// the squared boundary by roughly 2 * d * epsilon. For negative epsilon the comparison is strict, so points lying on
+// the boundary are reliably left out.
+// Order of the results is not specified
+func (r *SimpleRTree) FindPointsWithin(x, y, d float64) []Result {
+	results, _ := r.findPointsWithin(context.Background(), x, y, d)
+	return results
+}
+
+// findPointsWithin is FindPointsWithin stopping with the error of ctx once it is done, see FindPointsWithinContext
+func (r *SimpleRTree) findPointsWithin(ctx context.Context, x, y, d float64) ([]Result, error) {
+	var results []Result
+	if !r.built || len(r.nodes) == 0 {
+		return results, nil
+	}
+	limitSquared, strict, ok := r.withinLimit(d)
+	if !ok {
+		return results, nil
+	}
+
+	stack := make([]int, 1, 32)
+	for visited := 1; len(stack) > 0; visited++ {
+		if visited%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+		}
+		n := &r.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if n.nodeType == preleaf_node {
+			start := n.firstPointIndex()
+			for i := start; i < start+int(n.nChildren); i++ {
+				px, py, distance := r.pointDistance(i, x, y)
+				if isWithin(distance, limitSquared, strict) {
+					results = append(results, Result{Index: i, X: px, Y: py, Distance: distance})
+				}
+			}
+			continue
+		}
+		first := n.firstChildIndex()
+		for i := first; i < first+int(n.nChildren); i++ {
+			mind, _ := computeDistances(r.nodes[i].BBox, x, y, r.insideFactor)
+			if mind <= limitSquared {
+				stack = append(stack, i)
+			}
+		}
+	}
+	return results, nil
+}
+
+// withinLimit returns the squared distance that points must not exceed to be within d, taking into account
+// Options.WithinEpsilon. If strict is true points at exactly limitSquared are not within d.
+// ok is false if no point can be within d
+func (r *SimpleRTree) withinLimit(d float64) (limitSquared float64, strict, ok bool) {
+	epsilon := r.options.WithinEpsilon
+	limit := d + epsilon
+	if limit < 0 {
+		return 0, false, false
+	}
+	return limit * limit, epsilon < 0, true
+}
+
+func isWithin(distance, limitSquared float64, strict bool) bool {
+	return distance < limitSquared || (!strict && distance == limitSquared)
+}
@@ -0,0 +1,67 @@
+package SimpleRTree
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSimpleRTree_FindPointsWithin(t *testing.T) {
+	const size = 20000
+	points := make([]float64, size*2)
+	for i := 0; i < 2*size; i++ {
+		points[i] = rand.Float64()
+	}
+	fp := FlatPoints(points)
+	r := New().Load(fp)
+	for i := 0; i < 100; i++ {
+		x, y, d := rand.Float64(), rand.Float64(), rand.Float64()*0.1
+		results := r.FindPointsWithin(x, y, d)
+		expected := fp.linearPointsWithin(x, y, d*d)
+		indexes := make([]int, 0, len(results))
+		for _, res := range results {
+			px, py := fp.GetPointAt(res.Index)
+			assert.Equal(t, px, res.X)
+			assert.Equal(t, py, res.Y)
+			assert.Equal(t, computeLeafDistance(px, py, x, y), res.Distance)
+			indexes = append(indexes, res.Index)
+		}
+		sort.Ints(indexes)
+		assert.Equal(t, expected, indexes)
+	}
+}
+
+func TestSimpleRTree_FindPointsWithinBoundary(t *testing.T) {
+	// all points lie exactly at distance 5 of the origin except the last one, slightly further
+	points := []float64{3, 4, -3, 4, 5, 0, 0, -5, -4, -3, 5.0000001, 0}
+	testCases := []struct {
+		epsilon  float64
+		expected int
+	}{
+		{0, 5},
+		{-1e-9, 0},
+		{1e-6, 6},
+	}
+	for _, tc := range testCases {
+		fp := FlatPoints(append([]float64{}, points...))
+		r := NewWithOptions(Options{WithinEpsilon: tc.epsilon}).Load(fp)
+		assert.Len(t, r.FindPointsWithin(0, 0, 5), tc.expected, "epsilon %v", tc.epsilon)
+	}
+}
+
+func TestSimpleRTree_FindPointsWithinEmpty(t *testing.T) {
+	r := New().Load(FlatPoints{})
+	assert.Empty(t, r.FindPointsWithin(0, 0, 1))
+}
+
+func (fp FlatPoints) linearPointsWithin(x, y, dsquared float64) []int {
+	indexes := []int{}
+	for i := 0; i < fp.Len(); i++ {
+		px, py := fp.GetPointAt(i)
+		if computeLeafDistance(px, py, x, y) <= dsquared {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}